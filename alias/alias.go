@@ -0,0 +1,200 @@
+// Package alias generates the aliases needed to rename resources, or move
+// them between components, without Pulumi replacing them.
+//
+// Moves maps the current logical name of each resource to a description
+// of how it was previously registered.  Its transformation adds the
+// corresponding alias to each resource as it's registered, so that a
+// refactor doesn't require an Aliases option on every resource:
+//
+//	moves := alias.Renamed(map[string]string{"web-logs": "logs"}).
+//		Merge(alias.FromRoot("web-bucket", "web-policy"))
+//	web, err := NewWebsite(ctx, "web", args, pulumi.Transformations(
+//		[]pulumi.ResourceTransformation{moves.Transformation()},
+//	))
+//
+// Pulumi derives the aliases of a component's children from those of the
+// component, so when a component is moved only the component itself needs
+// an alias.
+package alias
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrInvalidMove is returned if a Move is contradictory.
+var ErrInvalidMove = errors.New("invalid move")
+
+// Move describes how a resource was previously registered.  Empty fields
+// default to the resource's current name, type and parent.
+type Move struct {
+	// Name is the previous logical name of the resource.
+	Name string
+	// Type is the previous type token of the resource.
+	Type string
+	// Parent is the previous parent of the resource.
+	Parent pulumi.Resource
+	// ParentURN is the URN of the previous parent of the resource, for
+	// parents that are no longer registered by the program.
+	ParentURN pulumi.URNInput
+	// Root is set if the resource was previously registered at the root
+	// of the stack, without a parent.
+	Root bool
+}
+
+// Validate checks that at most one previous parent is specified.
+func (m Move) Validate() error {
+	parents := 0
+	for _, set := range []bool{m.Parent != nil, m.ParentURN != nil, m.Root} {
+		if set {
+			parents++
+		}
+	}
+	if parents > 1 {
+		return fmt.Errorf("%w: Parent, ParentURN and Root are mutually exclusive", ErrInvalidMove)
+	}
+	return nil
+}
+
+// Alias returns the alias describing the resource's previous registration.
+func (m Move) Alias() pulumi.Alias {
+	var a pulumi.Alias
+	if m.Name != "" {
+		a.Name = pulumi.String(m.Name)
+	}
+	if m.Type != "" {
+		a.Type = pulumi.String(m.Type)
+	}
+	switch {
+	case m.Parent != nil:
+		a.Parent = m.Parent
+	case m.ParentURN != nil:
+		a.ParentURN = m.ParentURN
+	case m.Root:
+		a.NoParent = pulumi.Bool(true)
+	}
+	return a
+}
+
+// Moves maps the current logical names of resources to their previous
+// registrations.
+type Moves map[string]Move
+
+// Renamed returns the moves for resources renamed within the same parent,
+// mapping each current name to the previous one.
+func Renamed(renames map[string]string) Moves {
+	m := make(Moves, len(renames))
+	for name, old := range renames {
+		m[name] = Move{Name: old}
+	}
+	return m
+}
+
+// FromRoot returns the moves for resources that have been moved, under
+// the same names, from the root of the stack into a component.
+func FromRoot(names ...string) Moves {
+	return from(Move{Root: true}, names)
+}
+
+// FromParent returns the moves for resources that have been moved, under
+// the same names, from parent into another component.
+func FromParent(parent pulumi.Resource, names ...string) Moves {
+	return from(Move{Parent: parent}, names)
+}
+
+// FromParentURN returns the moves for resources that have been moved,
+// under the same names, from the component with the given URN, which is
+// no longer registered, into another component.
+func FromParentURN(urn pulumi.URNInput, names ...string) Moves {
+	return from(Move{ParentURN: urn}, names)
+}
+
+func from(move Move, names []string) Moves {
+	m := make(Moves, len(names))
+	for _, name := range names {
+		m[name] = move
+	}
+	return m
+}
+
+// Merge returns the combination of m and others.  Fields set for the same
+// name in more than one are combined, with later values taking precedence,
+// so that a resource may be both renamed and moved.
+func (m Moves) Merge(others ...Moves) Moves {
+	result := make(Moves, len(m))
+	for _, moves := range append([]Moves{m}, others...) {
+		for name, move := range moves {
+			result[name] = merge(result[name], move)
+		}
+	}
+	return result
+}
+
+func merge(a, b Move) Move {
+	if b.Name != "" {
+		a.Name = b.Name
+	}
+	if b.Type != "" {
+		a.Type = b.Type
+	}
+	if b.Parent != nil || b.ParentURN != nil || b.Root {
+		a.Parent, a.ParentURN, a.Root = b.Parent, b.ParentURN, b.Root
+	}
+	return a
+}
+
+// Validate checks each of the moves.
+func (m Moves) Validate() error {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := m[name].Validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Option returns the resource option adding the alias for the resource
+// with the given current name, or an option with no effect if it hasn't
+// moved.
+//
+// Will panic if Validate returns an error.
+func (m Moves) Option(name string) pulumi.ResourceOption {
+	if err := m.Validate(); err != nil {
+		panic(err)
+	}
+	move, ok := m[name]
+	if !ok {
+		return pulumi.Aliases(nil)
+	}
+	return pulumi.Aliases([]pulumi.Alias{move.Alias()})
+}
+
+// Transformation returns a resource transformation that adds the alias
+// for each moved resource.  It may be registered as a stack
+// transformation, or supplied to the components that resources have been
+// moved into using pulumi.Transformations.
+//
+// Will panic if Validate returns an error.
+func (m Moves) Transformation() pulumi.ResourceTransformation {
+	if err := m.Validate(); err != nil {
+		panic(err)
+	}
+	return func(args *pulumi.ResourceTransformationArgs) *pulumi.ResourceTransformationResult {
+		move, ok := m[args.Name]
+		if !ok {
+			return nil
+		}
+		return &pulumi.ResourceTransformationResult{
+			Props: args.Props,
+			Opts:  append(args.Opts[:len(args.Opts):len(args.Opts)], pulumi.Aliases([]pulumi.Alias{move.Alias()})),
+		}
+	}
+}
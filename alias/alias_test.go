@@ -0,0 +1,92 @@
+package alias
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type component struct {
+	pulumi.ResourceState
+}
+
+func TestTransformation(t *testing.T) {
+	mocks := testutil.Run(t, func(ctx *pulumi.Context) error {
+		old := &component{}
+		if err := ctx.RegisterComponentResource("test:index:Old", "old", old); err != nil {
+			return err
+		}
+		moves := Renamed(map[string]string{"web-logs": "logs"}).Merge(
+			FromRoot("web-logs", "web-site"),
+			FromParent(old, "web-assets"),
+			Moves{"web-data": {Type: "aws:s3/bucketV2:BucketV2", ParentURN: pulumi.URN("urn:pulumi:stack::project::test:index:Gone::gone")}},
+		)
+		web := &component{}
+		if err := ctx.RegisterComponentResource("test:index:Web", "web", web,
+			pulumi.Transformations([]pulumi.ResourceTransformation{moves.Transformation()})); err != nil {
+			return err
+		}
+		for _, name := range []string{"web-logs", "web-site", "web-assets", "web-data", "web-other"} {
+			if _, err := s3.NewBucket(ctx, name, nil, pulumi.Parent(web)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	tests := map[string][]string{
+		"web-logs":   {"urn:pulumi:stack::project::aws:s3/bucket:Bucket::logs"},
+		"web-site":   {"urn:pulumi:stack::project::aws:s3/bucket:Bucket::web-site"},
+		"web-assets": {"urn:pulumi:stack::project::test:index:Old$aws:s3/bucket:Bucket::web-assets"},
+		"web-data":   {"urn:pulumi:stack::project::test:index:Gone$aws:s3/bucketV2:BucketV2::web-data"},
+		"web-other":  nil,
+		"web":        nil,
+	}
+	for name, expected := range tests {
+		r, ok := mocks.Resource(name)
+		assert.True(t, ok, name)
+		if expected == nil {
+			assert.Empty(t, r.RegisterRPC.AliasURNs, name)
+		} else {
+			assert.Equal(t, expected, r.RegisterRPC.AliasURNs, name)
+		}
+	}
+}
+
+func TestOption(t *testing.T) {
+	mocks := testutil.Run(t, func(ctx *pulumi.Context) error {
+		moves := Renamed(map[string]string{"new": "old"})
+		if _, err := s3.NewBucket(ctx, "new", nil, moves.Option("new")); err != nil {
+			return err
+		}
+		_, err := s3.NewBucket(ctx, "other", nil, moves.Option("other"))
+		return err
+	})
+	r, _ := mocks.Resource("new")
+	assert.Len(t, r.RegisterRPC.AliasURNs, 1)
+	assert.True(t, strings.HasSuffix(r.RegisterRPC.AliasURNs[0], "aws:s3/bucket:Bucket::old"), r.RegisterRPC.AliasURNs[0])
+	r, _ = mocks.Resource("other")
+	assert.Empty(t, r.RegisterRPC.AliasURNs)
+}
+
+func TestMerge(t *testing.T) {
+	merged := Renamed(map[string]string{"a": "old-a"}).Merge(FromRoot("a", "b"), Renamed(map[string]string{"b": "old-b"}))
+	assert.Equal(t, Moves{
+		"a": {Name: "old-a", Root: true},
+		"b": {Name: "old-b", Root: true},
+	}, merged)
+}
+
+func TestValidate(t *testing.T) {
+	moves := Moves{"a": {Root: true, ParentURN: pulumi.URN("urn")}}
+	err := moves.Validate()
+	assert.True(t, errors.Is(err, ErrInvalidMove), "unexpected error %v", err)
+	assert.Panics(t, func() { moves.Transformation() })
+	assert.Panics(t, func() { moves.Option("a") })
+	assert.NoError(t, FromRoot("a").Validate())
+}
@@ -0,0 +1,122 @@
+// Package arn provides helpers for building and parsing Amazon Resource
+// Names whose components may be Pulumi outputs, in place of formatting
+// them by hand with pulumi.Sprintf.
+//
+//	bucketARN := arn.Build("s3", "", "", bucket.Bucket)
+//	tableARN := arn.Build("dynamodb", region.Name, caller.AccountId, pulumi.Sprintf("table/%s", table.Name))
+//
+//	parsed := arn.Parse(role.Arn)
+//	ctx.Export("account", parsed.AccountID)
+package arn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// DefaultPartition is the partition used by Build.
+const DefaultPartition = "aws"
+
+// ErrInvalidARN is returned if a string can't be parsed as an ARN.
+var ErrInvalidARN = errors.New("invalid ARN")
+
+// ARN holds the components of an Amazon Resource Name.
+type ARN struct {
+	Partition string
+	Service   string
+	Region    string
+	AccountID string
+	Resource  string
+}
+
+// String formats the ARN.
+func (a ARN) String() string {
+	return strings.Join([]string{"arn", a.Partition, a.Service, a.Region, a.AccountID, a.Resource}, ":")
+}
+
+// ParseString parses s as an ARN of the form
+// arn:partition:service:region:account-id:resource.  The resource may
+// itself contain colons.
+func ParseString(s string) (ARN, error) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return ARN{}, fmt.Errorf("%w: %q", ErrInvalidARN, s)
+	}
+	a := ARN{
+		Partition: parts[1],
+		Service:   parts[2],
+		Region:    parts[3],
+		AccountID: parts[4],
+		Resource:  parts[5],
+	}
+	if a.Partition == "" || a.Service == "" || a.Resource == "" {
+		return ARN{}, fmt.Errorf("%w: %q: partition, service and resource are required", ErrInvalidARN, s)
+	}
+	return a, nil
+}
+
+// Build returns an ARN in the default partition.  Each component may be a
+// string or StringInput; region and account should be empty for services
+// such as S3 and IAM whose ARNs omit them.
+func Build(service, region, account, resource interface{}) pulumi.StringOutput {
+	return BuildPartition(DefaultPartition, service, region, account, resource)
+}
+
+// BuildPartition is equivalent to Build, but specifies the partition, eg.
+// "aws-cn" or "aws-us-gov".
+func BuildPartition(partition, service, region, account, resource interface{}) pulumi.StringOutput {
+	components := []interface{}{partition, service, region, account, resource}
+	for i, c := range components {
+		switch c.(type) {
+		case string, pulumi.StringInput:
+		case nil:
+			components[i] = ""
+		default:
+			panic(fmt.Sprintf("unexpected type passed to Build: %T: %#v", c, c))
+		}
+	}
+	return pulumi.All(components...).ApplyT(func(args []interface{}) (string, error) {
+		a := ARN{
+			Partition: args[0].(string),
+			Service:   args[1].(string),
+			Region:    args[2].(string),
+			AccountID: args[3].(string),
+			Resource:  args[4].(string),
+		}
+		if a.Partition == "" || a.Service == "" || a.Resource == "" {
+			return "", fmt.Errorf("%w: partition, service and resource are required: %q", ErrInvalidARN, a)
+		}
+		return a.String(), nil
+	}).(pulumi.StringOutput)
+}
+
+// Output holds each component of a parsed ARN as an output.
+type Output struct {
+	Partition pulumi.StringOutput
+	Service   pulumi.StringOutput
+	Region    pulumi.StringOutput
+	AccountID pulumi.StringOutput
+	Resource  pulumi.StringOutput
+}
+
+// Parse splits an ARN, such as the Arn output of a resource, into its
+// components.  If the ARN is invalid each output resolves to an error
+// wrapping ErrInvalidARN.
+func Parse(input pulumi.StringInput) Output {
+	parsed := input.ToStringOutput().ApplyT(func(s string) (ARN, error) {
+		return ParseString(s)
+	})
+	field := func(f func(ARN) string) pulumi.StringOutput {
+		return parsed.ApplyT(func(a interface{}) string { return f(a.(ARN)) }).(pulumi.StringOutput)
+	}
+	return Output{
+		Partition: field(func(a ARN) string { return a.Partition }),
+		Service:   field(func(a ARN) string { return a.Service }),
+		Region:    field(func(a ARN) string { return a.Region }),
+		AccountID: field(func(a ARN) string { return a.AccountID }),
+		Resource:  field(func(a ARN) string { return a.Resource }),
+	}
+}
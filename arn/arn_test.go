@@ -0,0 +1,108 @@
+package arn
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ARN
+		err      bool
+	}{
+		{
+			input:    "arn:aws:iam::123456789012:role/app",
+			expected: ARN{Partition: "aws", Service: "iam", AccountID: "123456789012", Resource: "role/app"},
+		}, {
+			input:    "arn:aws-cn:logs:cn-north-1:123456789012:log-group:/app:*",
+			expected: ARN{Partition: "aws-cn", Service: "logs", Region: "cn-north-1", AccountID: "123456789012", Resource: "log-group:/app:*"},
+		},
+		{input: "arn:aws:s3:::", err: true},
+		{input: "arn:aws:s3", err: true},
+		{input: "urn:aws:s3:::bucket", err: true},
+	}
+	for _, test := range tests {
+		a, err := ParseString(test.input)
+		if test.err {
+			assert.True(t, errors.Is(err, ErrInvalidARN), "%s: unexpected error %v", test.input, err)
+			continue
+		}
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, a, test.input)
+		assert.Equal(t, test.input, a.String())
+	}
+}
+
+func TestBuild(t *testing.T) {
+	assert := assert.New(t)
+	var wg sync.WaitGroup
+	var bucket, table, gov string
+	var invalidErr error
+	wg.Add(3)
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		Build("s3", "", "", pulumi.String("my-bucket").ToStringOutput()).ApplyT(func(s string) string {
+			defer wg.Done()
+			bucket = s
+			return s
+		})
+		Build("dynamodb", pulumi.String("us-west-2"), "123456789012", pulumi.Sprintf("table/%s", "orders")).ApplyT(func(s string) string {
+			defer wg.Done()
+			table = s
+			return s
+		})
+		BuildPartition("aws-us-gov", "iam", nil, "123456789012", "role/app").ApplyT(func(s string) string {
+			defer wg.Done()
+			gov = s
+			return s
+		})
+		wg.Wait()
+		ctx.Export("invalid", Build("", "", "", "bucket"))
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	invalidErr = err
+	assert.Equal("arn:aws:s3:::my-bucket", bucket)
+	assert.Equal("arn:aws:dynamodb:us-west-2:123456789012:table/orders", table)
+	assert.Equal("arn:aws-us-gov:iam::123456789012:role/app", gov)
+	assert.True(errors.Is(invalidErr, ErrInvalidARN), "unexpected error %v", invalidErr)
+
+	assert.Panics(func() { Build(42, "", "", "bucket") })
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+	var wg sync.WaitGroup
+	result := make([]string, 5)
+	wg.Add(5)
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		out := Parse(pulumi.String("arn:aws:sqs:us-east-1:123456789012:queue").ToStringOutput())
+		for i, o := range []pulumi.StringOutput{out.Partition, out.Service, out.Region, out.AccountID, out.Resource} {
+			i := i
+			o.ApplyT(func(s string) string {
+				defer wg.Done()
+				result[i] = s
+				return s
+			})
+		}
+		wg.Wait()
+		ctx.Export("invalid", Parse(pulumi.String("not-an-arn")).AccountID)
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.True(errors.Is(err, ErrInvalidARN), "unexpected error %v", err)
+	assert.Equal([]string{"aws", "sqs", "us-east-1", "123456789012", "queue"}, result)
+}
@@ -0,0 +1,373 @@
+// Package auto provides high level wrappers around the Pulumi Automation
+// API for tools that embed a Pulumi program, such as operators and
+// provisioning services.
+//
+// A Program combines an inline Pulumi program with its stack and
+// configuration.  Engine events are delivered to a callback in a
+// simplified form, and the stack's outputs can be decoded into a struct:
+//
+//	prog := auto.New("network", "dev", func(ctx *pulumi.Context) error {
+//		vpc, err := ec2.NewVpc(ctx, "vpc", &ec2.VpcArgs{CidrBlock: pulumi.String("10.0.0.0/16")})
+//		if err != nil {
+//			return err
+//		}
+//		ctx.Export("vpcId", vpc.ID())
+//		return nil
+//	},
+//		auto.Config("aws:region", "us-west-2"),
+//		auto.OnEvent(func(e auto.Event) {
+//			if e.Type == auto.ResourceOutputs {
+//				log.Printf("%s %s", e.Op, e.URN)
+//			}
+//		}),
+//	)
+//
+//	result, err := prog.Up(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	var outputs struct {
+//		VpcID string `json:"vpcId"`
+//	}
+//	err = result.Decode(&outputs)
+//
+// The Pulumi CLI must be installed to use the Automation API.
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	pulumiauto "github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Types of Event.
+const (
+	Stdout          = "stdout"
+	Diagnostic      = "diagnostic"
+	Prelude         = "prelude"
+	Summary         = "summary"
+	ResourcePre     = "resourcePre"
+	ResourceOutputs = "resourceOutputs"
+	ResourceFailed  = "resourceFailed"
+	Policy          = "policy"
+	Cancel          = "cancel"
+	Error           = "error"
+)
+
+// Event is a simplified engine event.
+type Event struct {
+	// Type is the kind of event, eg. ResourceOutputs.
+	Type string
+	// Sequence orders the events of an operation.
+	Sequence int
+	// URN, ResourceType and Op describe the resource and the operation
+	// being performed on it, for resource events.
+	URN          string
+	ResourceType string
+	Op           string
+	// Severity and Message are set for diagnostic and stdout events.
+	Severity string
+	Message  string
+	// Err is set for Error events, raised if the event log couldn't be
+	// read.
+	Err error
+	// Raw holds the original event.
+	Raw events.EngineEvent
+}
+
+// Program is an inline Pulumi program, along with the stack it's deployed
+// to and its configuration.
+type Program struct {
+	project  string
+	stack    string
+	run      pulumi.RunFunc
+	config   pulumiauto.ConfigMap
+	wsOpts   []pulumiauto.LocalWorkspaceOption
+	refresh  bool
+	onEvent  []func(Event)
+	progress []io.Writer
+	upOpts   []optup.Option
+}
+
+// Opt is implemented by functions that can be passed to New.
+type Opt func(*Program)
+
+// New creates a Program that runs run as the named stack of project.  The
+// stack is created if it doesn't already exist.
+func New(project, stack string, run pulumi.RunFunc, opts ...Opt) *Program {
+	p := &Program{
+		project: project,
+		stack:   stack,
+		run:     run,
+		config:  make(pulumiauto.ConfigMap),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Config sets a configuration value for the stack, eg. "aws:region".
+func Config(key, value string) Opt {
+	return func(p *Program) {
+		p.config[key] = pulumiauto.ConfigValue{Value: value}
+	}
+}
+
+// Secret sets a secret configuration value for the stack.
+func Secret(key, value string) Opt {
+	return func(p *Program) {
+		p.config[key] = pulumiauto.ConfigValue{Value: value, Secret: true}
+	}
+}
+
+// WorkDir sets the directory holding the project's settings.  A temporary
+// directory is used by default.
+func WorkDir(dir string) Opt {
+	return WorkspaceOption(pulumiauto.WorkDir(dir))
+}
+
+// EnvVars sets environment variables for the Pulumi CLI, such as
+// PULUMI_BACKEND_URL or PULUMI_CONFIG_PASSPHRASE.
+func EnvVars(vars map[string]string) Opt {
+	return WorkspaceOption(pulumiauto.EnvVars(vars))
+}
+
+// WorkspaceOption passes an option through to the Automation API's
+// LocalWorkspace.
+func WorkspaceOption(opt pulumiauto.LocalWorkspaceOption) Opt {
+	return func(p *Program) {
+		p.wsOpts = append(p.wsOpts, opt)
+	}
+}
+
+// Refresh refreshes the stack's state from the cloud provider before
+// each update or preview.
+func Refresh() Opt {
+	return func(p *Program) {
+		p.refresh = true
+	}
+}
+
+// OnEvent calls f for each engine event.  Events are delivered in order,
+// from a single goroutine.  All events have been delivered by the time a
+// successful operation returns.
+func OnEvent(f func(Event)) Opt {
+	return func(p *Program) {
+		p.onEvent = append(p.onEvent, f)
+	}
+}
+
+// Progress writes the CLI's human readable progress output to w.
+func Progress(w io.Writer) Opt {
+	return func(p *Program) {
+		p.progress = append(p.progress, w)
+	}
+}
+
+// UpOption passes an option through to the Automation API's Up.
+func UpOption(opt optup.Option) Opt {
+	return func(p *Program) {
+		p.upOpts = append(p.upOpts, opt)
+	}
+}
+
+// Result holds the outcome of an update.
+type Result struct {
+	Outputs pulumiauto.OutputMap
+	Summary pulumiauto.UpdateSummary
+	// StdOut holds the CLI's progress output.
+	StdOut string
+}
+
+// Decode decodes the stack's outputs into v, as described by
+// DecodeOutputs.
+func (r *Result) Decode(v interface{}) error {
+	return DecodeOutputs(r.Outputs, v)
+}
+
+// DecodeOutputs decodes stack outputs into v, which should be a pointer
+// to a struct or map.  Struct fields are matched with outputs by their
+// json tags, as by encoding/json, and secret outputs are decoded as
+// plain values.
+func DecodeOutputs(outputs pulumiauto.OutputMap, v interface{}) error {
+	values := make(map[string]interface{}, len(outputs))
+	for k, o := range outputs {
+		values[k] = o.Value
+	}
+	js, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outputs: %w", err)
+	}
+	if err := json.Unmarshal(js, v); err != nil {
+		return fmt.Errorf("failed to decode outputs: %w", err)
+	}
+	return nil
+}
+
+// Stack creates or selects the program's stack and applies its
+// configuration, for operations not wrapped by Program.
+func (p *Program) Stack(ctx context.Context) (pulumiauto.Stack, error) {
+	stack, err := pulumiauto.UpsertStackInlineSource(ctx, p.stack, p.project, p.run, p.wsOpts...)
+	if err != nil {
+		return stack, fmt.Errorf("failed to create or select stack %s: %w", p.stack, err)
+	}
+	if len(p.config) > 0 {
+		if err := stack.SetAllConfig(ctx, p.config); err != nil {
+			return stack, fmt.Errorf("failed to set config for stack %s: %w", p.stack, err)
+		}
+	}
+	return stack, nil
+}
+
+// Up deploys the program, refreshing the stack first if Refresh was
+// supplied.
+func (p *Program) Up(ctx context.Context) (*Result, error) {
+	stack, err := p.Stack(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.doRefresh(ctx, stack); err != nil {
+		return nil, err
+	}
+
+	ch, wait := p.events()
+	opts := append([]optup.Option{optup.ProgressStreams(p.progress...), optup.EventStreams(ch)}, p.upOpts...)
+	res, err := stack.Up(ctx, opts...)
+	wait(err)
+	if err != nil {
+		return nil, fmt.Errorf("update of stack %s failed: %w", p.stack, err)
+	}
+	return &Result{Outputs: res.Outputs, Summary: res.Summary, StdOut: res.StdOut}, nil
+}
+
+// Preview previews the changes an update would make, returning the
+// number of resources affected by each type of operation.
+func (p *Program) Preview(ctx context.Context) (map[string]int, error) {
+	stack, err := p.Stack(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.doRefresh(ctx, stack); err != nil {
+		return nil, err
+	}
+
+	ch, wait := p.events()
+	res, err := stack.Preview(ctx, optpreview.ProgressStreams(p.progress...), optpreview.EventStreams(ch))
+	wait(err)
+	if err != nil {
+		return nil, fmt.Errorf("preview of stack %s failed: %w", p.stack, err)
+	}
+	changes := make(map[string]int, len(res.ChangeSummary))
+	for op, n := range res.ChangeSummary {
+		changes[string(op)] = n
+	}
+	return changes, nil
+}
+
+// Destroy deletes all of the stack's resources.  The stack itself is
+// retained.
+func (p *Program) Destroy(ctx context.Context) error {
+	stack, err := p.Stack(ctx)
+	if err != nil {
+		return err
+	}
+	ch, wait := p.events()
+	_, err = stack.Destroy(ctx, optdestroy.ProgressStreams(p.progress...), optdestroy.EventStreams(ch))
+	wait(err)
+	if err != nil {
+		return fmt.Errorf("destroy of stack %s failed: %w", p.stack, err)
+	}
+	return nil
+}
+
+func (p *Program) doRefresh(ctx context.Context, stack pulumiauto.Stack) error {
+	if !p.refresh {
+		return nil
+	}
+	ch, wait := p.events()
+	_, err := stack.Refresh(ctx, optrefresh.ProgressStreams(p.progress...), optrefresh.EventStreams(ch))
+	wait(err)
+	if err != nil {
+		return fmt.Errorf("refresh of stack %s failed: %w", p.stack, err)
+	}
+	return nil
+}
+
+// events returns a channel to receive engine events, and a function that
+// waits for them all to be delivered to the OnEvent callbacks.  The
+// Automation API closes the channel once the operation completes, but not
+// if it fails before the CLI is started, so the wait is skipped if the
+// operation failed.
+func (p *Program) events() (chan<- events.EngineEvent, func(error)) {
+	ch := make(chan events.EngineEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range ch {
+			event := convertEvent(e)
+			for _, f := range p.onEvent {
+				f(event)
+			}
+		}
+	}()
+	return ch, func(err error) {
+		if err == nil {
+			<-done
+		}
+	}
+}
+
+// convertEvent converts an engine event to an Event.
+func convertEvent(e events.EngineEvent) Event {
+	event := Event{Sequence: e.Sequence, Err: e.Error, Raw: e}
+	switch {
+	case e.Error != nil:
+		event.Type = Error
+		event.Message = e.Error.Error()
+	case e.StdoutEvent != nil:
+		event.Type = Stdout
+		event.Message = e.StdoutEvent.Message
+	case e.DiagnosticEvent != nil:
+		event.Type = Diagnostic
+		event.URN = e.DiagnosticEvent.URN
+		event.Severity = e.DiagnosticEvent.Severity
+		event.Message = e.DiagnosticEvent.Message
+	case e.PreludeEvent != nil:
+		event.Type = Prelude
+	case e.SummaryEvent != nil:
+		event.Type = Summary
+	case e.ResourcePreEvent != nil:
+		event.Type = ResourcePre
+		event.URN = e.ResourcePreEvent.Metadata.URN
+		event.ResourceType = e.ResourcePreEvent.Metadata.Type
+		event.Op = string(e.ResourcePreEvent.Metadata.Op)
+	case e.ResOutputsEvent != nil:
+		event.Type = ResourceOutputs
+		event.URN = e.ResOutputsEvent.Metadata.URN
+		event.ResourceType = e.ResOutputsEvent.Metadata.Type
+		event.Op = string(e.ResOutputsEvent.Metadata.Op)
+	case e.ResOpFailedEvent != nil:
+		event.Type = ResourceFailed
+		event.URN = e.ResOpFailedEvent.Metadata.URN
+		event.ResourceType = e.ResOpFailedEvent.Metadata.Type
+		event.Op = string(e.ResOpFailedEvent.Metadata.Op)
+	case e.PolicyEvent != nil:
+		event.Type = Policy
+		event.URN = e.PolicyEvent.ResourceURN
+		event.Severity = e.PolicyEvent.EnforcementLevel
+		event.Message = e.PolicyEvent.Message
+	case e.CancelEvent != nil:
+		event.Type = Cancel
+	}
+	return event
+}
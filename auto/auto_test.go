@@ -0,0 +1,99 @@
+package auto
+
+import (
+	"errors"
+	"testing"
+
+	pulumiauto "github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeOutputs(t *testing.T) {
+	outputs := pulumiauto.OutputMap{
+		"vpcId":     {Value: "vpc-1234"},
+		"subnetIds": {Value: []interface{}{"subnet-1", "subnet-2"}},
+		"port":      {Value: float64(443)},
+		"password":  {Value: "hunter2", Secret: true},
+	}
+	var result struct {
+		VpcID     string   `json:"vpcId"`
+		SubnetIDs []string `json:"subnetIds"`
+		Port      int      `json:"port"`
+		Password  string   `json:"password"`
+		Missing   string   `json:"missing"`
+	}
+	assert.NoError(t, (&Result{Outputs: outputs}).Decode(&result))
+	assert.Equal(t, "vpc-1234", result.VpcID)
+	assert.Equal(t, []string{"subnet-1", "subnet-2"}, result.SubnetIDs)
+	assert.Equal(t, 443, result.Port)
+	assert.Equal(t, "hunter2", result.Password)
+	assert.Empty(t, result.Missing)
+
+	var wrongType struct {
+		VpcID int `json:"vpcId"`
+	}
+	assert.Error(t, DecodeOutputs(outputs, &wrongType))
+}
+
+func TestConvertEvent(t *testing.T) {
+	meta := apitype.StepEventMetadata{Op: apitype.OpCreate, URN: "urn:x", Type: "aws:s3/bucket:Bucket"}
+	errBad := errors.New("bad")
+	tests := []struct {
+		name     string
+		event    apitype.EngineEvent
+		err      error
+		expected Event
+	}{
+		{"stdout", apitype.EngineEvent{Sequence: 1, StdoutEvent: &apitype.StdoutEngineEvent{Message: "hi"}}, nil,
+			Event{Type: Stdout, Sequence: 1, Message: "hi"}},
+		{"diagnostic", apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{URN: "urn:x", Severity: "error", Message: "failed"}}, nil,
+			Event{Type: Diagnostic, URN: "urn:x", Severity: "error", Message: "failed"}},
+		{"pre", apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{Metadata: meta}}, nil,
+			Event{Type: ResourcePre, URN: "urn:x", ResourceType: "aws:s3/bucket:Bucket", Op: "create"}},
+		{"outputs", apitype.EngineEvent{ResOutputsEvent: &apitype.ResOutputsEvent{Metadata: meta}}, nil,
+			Event{Type: ResourceOutputs, URN: "urn:x", ResourceType: "aws:s3/bucket:Bucket", Op: "create"}},
+		{"failed", apitype.EngineEvent{ResOpFailedEvent: &apitype.ResOpFailedEvent{Metadata: meta}}, nil,
+			Event{Type: ResourceFailed, URN: "urn:x", ResourceType: "aws:s3/bucket:Bucket", Op: "create"}},
+		{"summary", apitype.EngineEvent{SummaryEvent: &apitype.SummaryEvent{}}, nil, Event{Type: Summary}},
+		{"cancel", apitype.EngineEvent{CancelEvent: &apitype.CancelEvent{}}, nil, Event{Type: Cancel}},
+		{"error", apitype.EngineEvent{}, errBad, Event{Type: Error, Message: "bad", Err: errBad}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := events.EngineEvent{EngineEvent: test.event, Error: test.err}
+			test.expected.Raw = raw
+			assert.Equal(t, test.expected, convertEvent(raw))
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	var received []string
+	p := New("project", "stack", func(*pulumi.Context) error { return nil },
+		OnEvent(func(e Event) { received = append(received, e.Message) }),
+	)
+	ch, wait := p.events()
+	ch <- events.EngineEvent{EngineEvent: apitype.EngineEvent{StdoutEvent: &apitype.StdoutEngineEvent{Message: "a"}}}
+	ch <- events.EngineEvent{EngineEvent: apitype.EngineEvent{StdoutEvent: &apitype.StdoutEngineEvent{Message: "b"}}}
+	close(ch)
+	wait(nil)
+	assert.Equal(t, []string{"a", "b"}, received)
+}
+
+func TestNew(t *testing.T) {
+	p := New("project", "stack", nil,
+		Config("aws:region", "us-west-2"),
+		Secret("dbPassword", "hunter2"),
+		WorkDir(t.TempDir()),
+		Refresh(),
+	)
+	assert.Equal(t, pulumiauto.ConfigMap{
+		"aws:region": {Value: "us-west-2"},
+		"dbPassword": {Value: "hunter2", Secret: true},
+	}, p.config)
+	assert.True(t, p.refresh)
+	assert.Len(t, p.wsOpts, 1)
+}
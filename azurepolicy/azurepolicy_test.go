@@ -0,0 +1,250 @@
+package azurepolicy
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestRoleJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		role := NewRole("Storage Reader",
+			Description("Read access to blobs"),
+			Actions("Microsoft.Storage/storageAccounts/read"),
+			DataActions(pulumi.StringArray{pulumi.String("Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read")}),
+			AssignableScopes(pulumi.String("/subscriptions/sub/resourceGroups/rg").ToStringOutput()),
+		)
+
+		expected := `{
+			"Name": "Storage Reader",
+			"IsCustom": true,
+			"Description": "Read access to blobs",
+			"Actions": ["Microsoft.Storage/storageAccounts/read"],
+			"NotActions": [],
+			"DataActions": ["Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"],
+			"NotDataActions": [],
+			"AssignableScopes": ["/subscriptions/sub/resourceGroups/rg"]
+		}`
+		role.ToStringOutput().ApplyT(func(js string) int {
+			assert.JSONEq(expected, js)
+			wg.Done()
+			return 0
+		})
+		role.PermissionsOutput().ApplyT(func(perms []interface{}) int {
+			assert.Len(perms, 1)
+			assert.Equal([]string{"Microsoft.Storage/storageAccounts/read"}, perms[0].(map[string]interface{})["actions"])
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestRoleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     *RoleDefinition
+		expected error
+	}{
+		{"ok", NewRole("r", Actions("*/read"), AssignableScopes("/subscriptions/sub")), nil},
+		{"data-only", NewRole("r", DataActions("x/read"), AssignableScopes("/subscriptions/sub")), nil},
+		{"no-name", NewRole("", Actions("*/read"), AssignableScopes("/subscriptions/sub")), ErrInvalidRole},
+		{"no-actions", NewRole("r", NotActions("*/write"), AssignableScopes("/subscriptions/sub")), ErrInvalidRole},
+		{"no-scopes", NewRole("r", Actions("*/read")), ErrInvalidRole},
+		{"bad-scope", NewRole("r", Actions("*/read"), AssignableScopes("subscriptions/sub")), ErrInvalidRole},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.role.Validate()
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestRuleJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		rule := NewRule(
+			AllOf(
+				Field("type", "equals", "Microsoft.Storage/storageAccounts"),
+				Not(Field("location", "in", pulumi.StringArray{pulumi.String("westus")}.ToStringArrayOutput())),
+			),
+			Deny,
+		)
+
+		expected := `{
+			"if": {
+				"allOf": [
+					{"field": "type", "equals": "Microsoft.Storage/storageAccounts"},
+					{"not": {"field": "location", "in": ["westus"]}}
+				]
+			},
+			"then": {"effect": "deny"}
+		}`
+		rule.ToStringOutput().ApplyT(func(js string) int {
+			assert.JSONEq(expected, js)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestRuleValidate(t *testing.T) {
+	cond := Field("type", "equals", "x")
+	tests := []struct {
+		name     string
+		rule     *Rule
+		expected error
+	}{
+		{"deny", NewRule(cond, Deny), nil},
+		{"parameterized", NewRule(cond, "[parameters('effect')]"), nil},
+		{"no-cond", NewRule(nil, Deny), ErrInvalidRule},
+		{"no-effect", NewRule(cond, ""), ErrInvalidRule},
+		{"modify-no-details", NewRule(cond, Modify), ErrInvalidRule},
+		{"modify", NewRule(cond, Modify, Details(map[string]interface{}{"operations": []interface{}{}})), nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.rule.Validate()
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestDefinitionJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		def := NewDefinition("Allowed locations",
+			NewRule(
+				AllOf(
+					Field("type", "equals", "Microsoft.Storage/storageAccounts"),
+					Not(Field("location", "in", Param("locations"))),
+				),
+				Param("effect"),
+			),
+			PolicyDescription("Restricts storage account locations"),
+			Mode(ModeIndexed),
+			Category("Storage"),
+			WithParameter("locations", NewParameter(ArrayParam,
+				ParamDisplayName("Locations"),
+				StrongType("location"),
+				DefaultValue(pulumi.StringArray{pulumi.String("westus").ToStringOutput()}),
+			)),
+			WithParameter("effect", EffectParameter(Audit, Audit, Deny)),
+		)
+
+		expected := `{
+			"displayName": "Allowed locations",
+			"description": "Restricts storage account locations",
+			"mode": "Indexed",
+			"metadata": {"category": "Storage"},
+			"parameters": {
+				"effect": {
+					"type": "String",
+					"metadata": {"displayName": "Effect", "description": "The effect of the policy"},
+					"defaultValue": "audit",
+					"allowedValues": ["audit", "deny"]
+				},
+				"locations": {
+					"type": "Array",
+					"metadata": {"displayName": "Locations", "strongType": "location"},
+					"defaultValue": ["westus"]
+				}
+			},
+			"policyRule": {
+				"if": {
+					"allOf": [
+						{"field": "type", "equals": "Microsoft.Storage/storageAccounts"},
+						{"not": {"field": "location", "in": "[parameters('locations')]"}}
+					]
+				},
+				"then": {"effect": "[parameters('effect')]"}
+			}
+		}`
+		def.ToStringOutput().ApplyT(func(js string) int {
+			assert.JSONEq(expected, js)
+			wg.Done()
+			return 0
+		})
+		def.PolicyRuleOutput().ApplyT(func(rule interface{}) int {
+			assert.Equal(map[string]interface{}{"effect": "[parameters('effect')]"}, rule.(map[string]interface{})["then"])
+			wg.Done()
+			return 0
+		})
+		def.ParametersOutput().ApplyT(func(params interface{}) int {
+			assert.Equal([]interface{}{"westus"}, params.(map[string]interface{})["locations"].(map[string]interface{})["defaultValue"])
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	rule := NewRule(Field("location", "in", Param("locations")), Deny)
+	locations := WithParameter("locations", NewParameter(ArrayParam))
+	tests := []struct {
+		name     string
+		def      *Definition
+		expected error
+	}{
+		{"ok", NewDefinition("d", rule, locations), nil},
+		{"no-name", NewDefinition("", rule, locations), ErrInvalidDefinition},
+		{"no-mode", NewDefinition("d", rule, locations, Mode("")), ErrInvalidDefinition},
+		{"no-rule", NewDefinition("d", nil), ErrInvalidRule},
+		{"undefined-param", NewDefinition("d", rule), ErrInvalidDefinition},
+		{"bad-param-type", NewDefinition("d", rule, WithParameter("locations", NewParameter("List"))), ErrInvalidDefinition},
+		{"default-not-allowed", NewDefinition("d", NewRule(Field("type", "equals", "x"), Param("effect")),
+			WithParameter("effect", EffectParameter(Disabled, Audit, Deny))), ErrInvalidDefinition},
+		{"output-values", NewDefinition("d", NewRule(Field("id", "equals", pulumi.String("/subscriptions/sub").ToStringOutput()), Deny)), nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.def.Validate()
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package azurepolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Modes that may be supplied to Mode.  Resource provider modes, such as
+// "Microsoft.KeyVault.Data", may also be used.
+const (
+	ModeAll     = "All"
+	ModeIndexed = "Indexed"
+)
+
+// Parameter types that may be supplied to NewParameter.
+const (
+	StringParam   = "String"
+	ArrayParam    = "Array"
+	ObjectParam   = "Object"
+	BooleanParam  = "Boolean"
+	IntegerParam  = "Integer"
+	FloatParam    = "Float"
+	DateTimeParam = "DateTime"
+)
+
+var paramRefRegexp = regexp.MustCompile(`parameters\('([^']+)'\)`)
+
+// Param returns a template expression referencing the named parameter, for
+// use as a value within a rule, eg. Field("location", "in", Param("locations")).
+func Param(name string) string {
+	return fmt.Sprintf("[parameters('%s')]", name)
+}
+
+// Parameter defines a parameter of a policy definition.
+type Parameter struct {
+	Type          string                 `json:"type"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	DefaultValue  interface{}            `json:"defaultValue,omitempty"`
+	AllowedValues []interface{}          `json:"allowedValues,omitempty"`
+}
+
+// ParamOpt is implemented by functions that can be passed to NewParameter.
+type ParamOpt func(*Parameter)
+
+// NewParameter creates a parameter of the given type.
+func NewParameter(typ string, opts ...ParamOpt) Parameter {
+	p := Parameter{Type: typ}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// ParamDisplayName sets the name of the parameter shown in the portal.
+func ParamDisplayName(name string) ParamOpt {
+	return func(p *Parameter) { p.setMetadata("displayName", name) }
+}
+
+// ParamDescription sets the description of the parameter.
+func ParamDescription(description string) ParamOpt {
+	return func(p *Parameter) { p.setMetadata("description", description) }
+}
+
+// StrongType sets the type of value the portal offers for the parameter,
+// such as "location" or "resourceTypes".
+func StrongType(typ string) ParamOpt {
+	return func(p *Parameter) { p.setMetadata("strongType", typ) }
+}
+
+// DefaultValue sets the value used if the parameter isn't supplied when
+// the policy is assigned.  value may be a Pulumi input.
+func DefaultValue(value interface{}) ParamOpt {
+	return func(p *Parameter) { p.DefaultValue = value }
+}
+
+// AllowedValues restricts the values the parameter may be assigned.
+func AllowedValues(values ...interface{}) ParamOpt {
+	return func(p *Parameter) { p.AllowedValues = append(p.AllowedValues, values...) }
+}
+
+func (p *Parameter) setMetadata(key string, value interface{}) {
+	if p.Metadata == nil {
+		p.Metadata = make(map[string]interface{})
+	}
+	p.Metadata[key] = value
+}
+
+// EffectParameter returns a String parameter for use as a rule's effect,
+// allowing the effect to be chosen when the policy is assigned.
+//
+//	azurepolicy.NewDefinition("Require tags",
+//	    azurepolicy.NewRule(cond, azurepolicy.Param("effect")),
+//	    azurepolicy.WithParameter("effect", azurepolicy.EffectParameter(azurepolicy.Audit, azurepolicy.Audit, azurepolicy.Deny)),
+//	)
+func EffectParameter(defaultEffect string, allowed ...string) Parameter {
+	values := make([]interface{}, len(allowed))
+	for i, effect := range allowed {
+		values[i] = effect
+	}
+	return NewParameter(StringParam,
+		ParamDisplayName("Effect"),
+		ParamDescription("The effect of the policy"),
+		DefaultValue(defaultEffect),
+		AllowedValues(values...),
+	)
+}
+
+// Definition defines an Azure Policy definition.  It marshals to the
+// properties of a policy definition, as accepted by the azure-native
+// authorization.PolicyDefinition resource.
+type Definition struct {
+	DisplayName string                 `json:"displayName"`
+	Description string                 `json:"description,omitempty"`
+	Mode        string                 `json:"mode"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Parameters  map[string]Parameter   `json:"parameters,omitempty"`
+	PolicyRule  Rule                   `json:"policyRule"`
+}
+
+// DefinitionOpt is implemented by functions that can be passed to
+// NewDefinition.
+type DefinitionOpt func(*Definition)
+
+// NewDefinition creates a policy definition that enforces rule.  The mode
+// defaults to ModeAll.
+func NewDefinition(displayName string, rule *Rule, opts ...DefinitionOpt) *Definition {
+	d := &Definition{DisplayName: displayName, Mode: ModeAll}
+	if rule != nil {
+		d.PolicyRule = *rule
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// PolicyDescription sets the description of the policy definition.
+func PolicyDescription(description string) DefinitionOpt {
+	return func(d *Definition) { d.Description = description }
+}
+
+// Mode sets which resource types are evaluated by the policy.
+func Mode(mode string) DefinitionOpt {
+	return func(d *Definition) { d.Mode = mode }
+}
+
+// Category sets the category under which the policy is shown in the
+// portal.
+func Category(category string) DefinitionOpt {
+	return func(d *Definition) {
+		if d.Metadata == nil {
+			d.Metadata = make(map[string]interface{})
+		}
+		d.Metadata["category"] = category
+	}
+}
+
+// WithParameter adds a named parameter to the policy definition.
+func WithParameter(name string, param Parameter) DefinitionOpt {
+	return func(d *Definition) {
+		if d.Parameters == nil {
+			d.Parameters = make(map[string]Parameter)
+		}
+		d.Parameters[name] = param
+	}
+}
+
+// Validate performs a basic structural check of the policy definition,
+// including its rule, and checks that every parameter the rule references
+// is defined.
+func (d Definition) Validate() error {
+	if d.DisplayName == "" {
+		return fmt.Errorf("%w: display name is required", ErrInvalidDefinition)
+	}
+	if d.Mode == "" {
+		return fmt.Errorf("%w: policy %q has no mode", ErrInvalidDefinition, d.DisplayName)
+	}
+	if err := d.PolicyRule.Validate(); err != nil {
+		return fmt.Errorf("policy %q: %w", d.DisplayName, err)
+	}
+	for _, name := range sortedParams(d.Parameters) {
+		p := d.Parameters[name]
+		switch p.Type {
+		case StringParam, ArrayParam, ObjectParam, BooleanParam, IntegerParam, FloatParam, DateTimeParam:
+		default:
+			return fmt.Errorf("%w: parameter %q has invalid type %q", ErrInvalidDefinition, name, p.Type)
+		}
+		if def, ok := p.DefaultValue.(string); ok && len(p.AllowedValues) > 0 && !containsValue(p.AllowedValues, def) {
+			return fmt.Errorf("%w: default value %q of parameter %q is not allowed", ErrInvalidDefinition, def, name)
+		}
+	}
+	js, err := json.Marshal(staticValues(d.PolicyRule))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+	for _, m := range paramRefRegexp.FindAllStringSubmatch(string(js), -1) {
+		if _, ok := d.Parameters[m[1]]; !ok {
+			return fmt.Errorf("%w: policy %q references undefined parameter %q", ErrInvalidDefinition, d.DisplayName, m[1])
+		}
+	}
+	return nil
+}
+
+// ToStringOutput generates the policy definition as JSON.
+func (d Definition) ToStringOutput() pulumi.StringOutput {
+	return d.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext generates the policy definition as JSON.
+func (d Definition) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := d.Validate(); err != nil {
+		panic(err)
+	}
+	return marshalOutput(ctx, d)
+}
+
+// PolicyRuleOutput resolves the rule into the form used by the PolicyRule
+// argument of the azure-native authorization.PolicyDefinition resource.
+func (d Definition) PolicyRuleOutput() pulumi.AnyOutput {
+	return d.resolved("policyRule")
+}
+
+// ParametersOutput resolves the parameters into the form used by the
+// Parameters argument of the azure-native authorization.PolicyDefinition
+// resource.
+func (d Definition) ParametersOutput() pulumi.AnyOutput {
+	return d.resolved("parameters")
+}
+
+func (d Definition) resolved(key string) pulumi.AnyOutput {
+	return d.ToStringOutput().ApplyT(func(js string) (interface{}, error) {
+		var resolved map[string]interface{}
+		if err := json.Unmarshal([]byte(js), &resolved); err != nil {
+			return nil, err
+		}
+		return resolved[key], nil
+	}).(pulumi.AnyOutput)
+}
+
+// staticValues returns a copy of v with Pulumi inputs, whose values are
+// unknown until the rule is rendered, removed.
+func staticValues(v interface{}) interface{} {
+	switch v := v.(type) {
+	case pulumi.Input:
+		return nil
+	case Rule:
+		return map[string]interface{}{"if": staticValues(v.If), "then": staticValues(v.Then)}
+	case Cond:
+		return staticValues(map[string]interface{}(v))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = staticValues(e)
+		}
+		return m
+	case []Cond:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = staticValues(e)
+		}
+		return a
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = staticValues(e)
+		}
+		return a
+	}
+	return v
+}
+
+func containsValue(values []interface{}, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedParams(params map[string]Parameter) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
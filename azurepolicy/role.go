@@ -0,0 +1,203 @@
+// Package azurepolicy provides helpers for generating Azure custom role
+// definitions, and Azure Policy rules and definitions, from Pulumi outputs.
+//
+// Role definitions render to the JSON accepted by "az role definition
+// create", and their permissions can also be resolved into the form used
+// by the Permissions argument of the azure-native
+// authorization.RoleDefinition resource:
+//
+//	role := azurepolicy.NewRole("Storage Reader",
+//	    azurepolicy.Description("Read access to blobs"),
+//	    azurepolicy.Actions("Microsoft.Storage/storageAccounts/read"),
+//	    azurepolicy.DataActions("Microsoft.Storage/storageAccounts/blobServices/containers/blobs/read"),
+//	    azurepolicy.AssignableScopes(rg.ID()),
+//	)
+//
+// Policy definitions combine a rule with its parameters, and render to the
+// properties of a policy definition.  The rule and parameters can also be
+// resolved for the PolicyRule and Parameters arguments of the azure-native
+// authorization.PolicyDefinition resource:
+//
+//	def := azurepolicy.NewDefinition("Allowed locations",
+//	    azurepolicy.NewRule(
+//	        azurepolicy.Not(azurepolicy.Field("location", "in", azurepolicy.Param("locations"))),
+//	        azurepolicy.Param("effect"),
+//	    ),
+//	    azurepolicy.Mode(azurepolicy.ModeIndexed),
+//	    azurepolicy.WithParameter("locations", azurepolicy.NewParameter(azurepolicy.ArrayParam,
+//	        azurepolicy.StrongType("location"),
+//	        azurepolicy.DefaultValue(pulumi.StringArray{rg.Location}),
+//	    )),
+//	    azurepolicy.WithParameter("effect", azurepolicy.EffectParameter(azurepolicy.Deny, azurepolicy.Audit, azurepolicy.Deny)),
+//	)
+package azurepolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Package errors returned during validation.
+var (
+	ErrInvalidRole       = errors.New("invalid role definition")
+	ErrInvalidRule       = errors.New("invalid policy rule")
+	ErrInvalidDefinition = errors.New("invalid policy definition")
+)
+
+// Strings holds a list of entries that may be strings, string slices or
+// their Pulumi input equivalents.  It always marshals to a JSON array.
+type Strings []interface{}
+
+// MarshalJSON implements json.Marshaler.
+func (s Strings) MarshalJSON() ([]byte, error) {
+	out := make([]string, 0, len(s))
+	for _, el := range s {
+		switch v := el.(type) {
+		case string:
+			out = append(out, v)
+		case []string:
+			out = append(out, v...)
+		default:
+			return nil, fmt.Errorf("unexpected type in list: %T", el)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// static returns the entries that are already known, skipping outputs.
+func (s Strings) static() (out []string) {
+	for _, el := range s {
+		switch v := el.(type) {
+		case string:
+			out = append(out, v)
+		case []string:
+			out = append(out, v...)
+		}
+	}
+	return out
+}
+
+// RoleDefinition defines an Azure custom role.
+type RoleDefinition struct {
+	Name             string
+	IsCustom         bool
+	Description      string `json:",omitempty"`
+	Actions          Strings
+	NotActions       Strings
+	DataActions      Strings
+	NotDataActions   Strings
+	AssignableScopes Strings
+}
+
+// RoleOpt is implemented by functions that can be passed to NewRole.
+type RoleOpt func(*RoleDefinition)
+
+// NewRole creates a new custom role definition.
+func NewRole(name string, opts ...RoleOpt) *RoleDefinition {
+	r := &RoleDefinition{Name: name, IsCustom: true}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Description sets the description of the role.
+func Description(description string) RoleOpt {
+	return func(r *RoleDefinition) { r.Description = description }
+}
+
+// Actions adds management plane operations the role permits.
+//
+// action arguments may be string, []string, StringInput or StringArrayInput
+// slices and arrays will be flattened into a single list.
+func Actions(action ...interface{}) RoleOpt {
+	return func(r *RoleDefinition) { r.Actions = append(r.Actions, action...) }
+}
+
+// NotActions adds management plane operations excluded from Actions.
+func NotActions(action ...interface{}) RoleOpt {
+	return func(r *RoleDefinition) { r.NotActions = append(r.NotActions, action...) }
+}
+
+// DataActions adds data plane operations the role permits.
+func DataActions(action ...interface{}) RoleOpt {
+	return func(r *RoleDefinition) { r.DataActions = append(r.DataActions, action...) }
+}
+
+// NotDataActions adds data plane operations excluded from DataActions.
+func NotDataActions(action ...interface{}) RoleOpt {
+	return func(r *RoleDefinition) { r.NotDataActions = append(r.NotDataActions, action...) }
+}
+
+// AssignableScopes adds the scopes, such as subscription or resource group
+// ids, at which the role may be assigned.
+func AssignableScopes(scope ...interface{}) RoleOpt {
+	return func(r *RoleDefinition) { r.AssignableScopes = append(r.AssignableScopes, scope...) }
+}
+
+// Validate performs a basic structural check of the role definition.
+func (r RoleDefinition) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRole)
+	}
+	if len(r.Actions) == 0 && len(r.DataActions) == 0 {
+		return fmt.Errorf("%w: role %q must have at least one action or data action", ErrInvalidRole, r.Name)
+	}
+	if len(r.AssignableScopes) == 0 {
+		return fmt.Errorf("%w: role %q must have at least one assignable scope", ErrInvalidRole, r.Name)
+	}
+	for _, scope := range r.AssignableScopes.static() {
+		if !strings.HasPrefix(scope, "/") {
+			return fmt.Errorf("%w: role %q has invalid scope %q", ErrInvalidRole, r.Name, scope)
+		}
+	}
+	return nil
+}
+
+// ToStringOutput generates the role definition as JSON.
+func (r RoleDefinition) ToStringOutput() pulumi.StringOutput {
+	return r.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext generates the role definition as JSON.
+func (r RoleDefinition) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := r.Validate(); err != nil {
+		panic(err)
+	}
+	return marshalOutput(ctx, r)
+}
+
+// PermissionsOutput resolves the role's permissions into the list of maps
+// with actions, notActions, dataActions and notDataActions keys used by
+// the azure-native authorization.RoleDefinition resource.
+func (r RoleDefinition) PermissionsOutput() pulumi.ArrayOutput {
+	return r.ToStringOutput().ApplyT(func(js string) ([]interface{}, error) {
+		var resolved struct {
+			Actions, NotActions, DataActions, NotDataActions []string
+		}
+		if err := json.Unmarshal([]byte(js), &resolved); err != nil {
+			return nil, err
+		}
+		return []interface{}{map[string]interface{}{
+			"actions":        resolved.Actions,
+			"notActions":     resolved.NotActions,
+			"dataActions":    resolved.DataActions,
+			"notDataActions": resolved.NotDataActions,
+		}}, nil
+	}).(pulumi.ArrayOutput)
+}
+
+func marshalOutput(ctx context.Context, v interface{}) pulumi.StringOutput {
+	return pulumi.ToOutput(v).ApplyTWithContext(ctx, func(_ context.Context, data interface{}) (string, error) {
+		js, err := json.MarshalIndent(data, "", "    ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal json: %w", err)
+		}
+		return string(js), nil
+	}).(pulumi.StringOutput)
+}
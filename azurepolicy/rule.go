@@ -0,0 +1,111 @@
+package azurepolicy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Effects that may be applied by an Azure Policy rule.
+const (
+	Deny              = "deny"
+	Audit             = "audit"
+	Append            = "append"
+	Modify            = "modify"
+	Disabled          = "disabled"
+	AuditIfNotExists  = "auditIfNotExists"
+	DeployIfNotExists = "deployIfNotExists"
+)
+
+// Cond is a single Azure Policy condition or logical operator.  Values
+// held within it may be Pulumi inputs, which are resolved when the rule is
+// rendered.
+type Cond map[string]interface{}
+
+// Field returns a condition comparing a field, such as "type" or
+// "location", using an operator such as "equals", "in" or "like".
+func Field(field, op string, value interface{}) Cond {
+	return Cond{"field": field, op: value}
+}
+
+// Value returns a condition comparing the result of a template
+// expression rather than a field.
+func Value(value interface{}, op string, operand interface{}) Cond {
+	return Cond{"value": value, op: operand}
+}
+
+// AllOf returns a condition that's true if all of conds are true.
+func AllOf(conds ...Cond) Cond {
+	return Cond{"allOf": conds}
+}
+
+// AnyOf returns a condition that's true if any of conds are true.
+func AnyOf(conds ...Cond) Cond {
+	return Cond{"anyOf": conds}
+}
+
+// Not negates a condition.
+func Not(cond Cond) Cond {
+	return Cond{"not": cond}
+}
+
+// Rule defines an Azure Policy rule, as supplied to the PolicyRule field
+// of a policy definition.
+type Rule struct {
+	If   Cond                   `json:"if"`
+	Then map[string]interface{} `json:"then"`
+}
+
+// RuleOpt is implemented by functions that can be passed to NewRule.
+type RuleOpt func(*Rule)
+
+// NewRule creates a rule that applies effect when cond is true.
+func NewRule(cond Cond, effect string, opts ...RuleOpt) *Rule {
+	r := &Rule{If: cond, Then: map[string]interface{}{"effect": effect}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Details sets the details of the rule's effect, as required by effects
+// such as modify, append and deployIfNotExists.
+func Details(details interface{}) RuleOpt {
+	return func(r *Rule) { r.Then["details"] = details }
+}
+
+// Validate performs a basic structural check of the rule.
+func (r Rule) Validate() error {
+	if len(r.If) == 0 {
+		return fmt.Errorf("%w: rule has no condition", ErrInvalidRule)
+	}
+	switch r.Then["effect"] {
+	case Deny, Audit, Disabled:
+	case Append, Modify, AuditIfNotExists, DeployIfNotExists:
+		if r.Then["details"] == nil {
+			return fmt.Errorf("%w: effect %q requires details", ErrInvalidRule, r.Then["effect"])
+		}
+	case "":
+		return fmt.Errorf("%w: rule has no effect", ErrInvalidRule)
+	default:
+		// effects may also be parameterized, eg. "[parameters('effect')]"
+		if _, ok := r.Then["effect"].(string); !ok {
+			return fmt.Errorf("%w: effect must be a string", ErrInvalidRule)
+		}
+	}
+	return nil
+}
+
+// ToStringOutput generates the rule as JSON.
+func (r Rule) ToStringOutput() pulumi.StringOutput {
+	return r.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext generates the rule as JSON.
+func (r Rule) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := r.Validate(); err != nil {
+		panic(err)
+	}
+	return marshalOutput(ctx, r)
+}
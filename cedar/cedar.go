@@ -0,0 +1,270 @@
+// Package cedar provides a helper for generating Cedar policy statements,
+// as used by Amazon Verified Permissions, from Pulumi outputs.
+//
+//	p := cedar.Permit(
+//		cedar.PrincipalIn(cedar.Entity("Group", group.Id)),
+//		cedar.ActionIn(cedar.Entity("Action", "view"), cedar.Entity("Action", "edit")),
+//		cedar.ResourceIs("Photo"),
+//		cedar.When("resource.owner == principal"),
+//	)
+//	verifiedpermissions.NewPolicy(ctx, "photos", &verifiedpermissions.PolicyArgs{
+//		PolicyStoreId: store.ID(),
+//		Definition: &verifiedpermissions.PolicyDefinitionArgs{
+//			Static: &verifiedpermissions.PolicyDefinitionStaticArgs{
+//				Statement: p.ToStringOutput(),
+//			},
+//		},
+//	})
+package cedar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrInvalidPolicy is returned if a policy fails validation.
+var ErrInvalidPolicy = errors.New("invalid cedar policy")
+
+// Effect of a Cedar policy.
+type Effect string
+
+// Valid Cedar effects.
+const (
+	PermitEffect Effect = "permit"
+	ForbidEffect Effect = "forbid"
+)
+
+// Scope constrains the principal, action or resource of a policy.  An empty
+// Op matches any value.
+type Scope struct {
+	Op       string
+	Type     string
+	Entities []interface{}
+}
+
+// Policy defines a single Cedar policy statement.
+type Policy struct {
+	Effect      Effect
+	Annotations [][2]string
+	Principal   Scope
+	Action      Scope
+	Resource    Scope
+	When        []interface{}
+	Unless      []interface{}
+}
+
+// Opt is implemented by functions that can be passed to Permit or Forbid.
+type Opt func(*Policy)
+
+// Permit creates a policy that permits matching requests.
+func Permit(opts ...Opt) *Policy {
+	return newPolicy(PermitEffect, opts)
+}
+
+// Forbid creates a policy that forbids matching requests.
+func Forbid(opts ...Opt) *Policy {
+	return newPolicy(ForbidEffect, opts)
+}
+
+func newPolicy(effect Effect, opts []Opt) *Policy {
+	p := &Policy{Effect: effect}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Entity returns a Cedar entity reference such as User::"alice".  id may
+// be a string or a StringInput, in which case a StringOutput is returned.
+func Entity(entityType string, id interface{}) interface{} {
+	switch v := id.(type) {
+	case string:
+		return entityType + "::" + Quote(v)
+	case pulumi.StringInput:
+		return v.ToStringOutput().ApplyT(func(s string) string {
+			return entityType + "::" + Quote(s)
+		}).(pulumi.StringOutput)
+	default:
+		panic(fmt.Sprintf("unexpected type passed as an entity id: %T: %#v", id, id))
+	}
+}
+
+// Quote returns s as a quoted Cedar string literal.
+func Quote(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		case 0:
+			out.WriteString(`\0`)
+		default:
+			out.WriteRune(r)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+// Annotation adds an annotation, such as @id("name"), to the policy.
+func Annotation(name, value string) Opt {
+	return func(p *Policy) {
+		p.Annotations = append(p.Annotations, [2]string{name, value})
+	}
+}
+
+// PrincipalEq restricts the policy to a single principal entity.
+func PrincipalEq(entity interface{}) Opt {
+	return func(p *Policy) { p.Principal = Scope{Op: "==", Entities: []interface{}{entity}} }
+}
+
+// PrincipalIn restricts the policy to principals that are members of an
+// entity, such as a group.
+func PrincipalIn(entity interface{}) Opt {
+	return func(p *Policy) { p.Principal = Scope{Op: "in", Entities: []interface{}{entity}} }
+}
+
+// PrincipalIs restricts the policy to principals of a given entity type.
+func PrincipalIs(entityType string) Opt {
+	return func(p *Policy) { p.Principal = Scope{Op: "is", Type: entityType} }
+}
+
+// ActionEq restricts the policy to a single action entity.
+func ActionEq(entity interface{}) Opt {
+	return func(p *Policy) { p.Action = Scope{Op: "==", Entities: []interface{}{entity}} }
+}
+
+// ActionIn restricts the policy to a set of actions, or to the members of
+// action groups.
+func ActionIn(entity ...interface{}) Opt {
+	return func(p *Policy) { p.Action = Scope{Op: "in", Entities: entity} }
+}
+
+// ResourceEq restricts the policy to a single resource entity.
+func ResourceEq(entity interface{}) Opt {
+	return func(p *Policy) { p.Resource = Scope{Op: "==", Entities: []interface{}{entity}} }
+}
+
+// ResourceIn restricts the policy to resources contained by an entity.
+func ResourceIn(entity interface{}) Opt {
+	return func(p *Policy) { p.Resource = Scope{Op: "in", Entities: []interface{}{entity}} }
+}
+
+// ResourceIs restricts the policy to resources of a given entity type.
+func ResourceIs(entityType string) Opt {
+	return func(p *Policy) { p.Resource = Scope{Op: "is", Type: entityType} }
+}
+
+// When adds a condition that must be true for the policy to apply.  expr
+// may be a string or a StringInput.
+func When(expr interface{}) Opt {
+	return func(p *Policy) { p.When = append(p.When, expr) }
+}
+
+// Unless adds a condition that must be false for the policy to apply.
+// expr may be a string or a StringInput.
+func Unless(expr interface{}) Opt {
+	return func(p *Policy) { p.Unless = append(p.Unless, expr) }
+}
+
+// Validate performs a basic structural check of the policy.
+func (p Policy) Validate() error {
+	if p.Effect != PermitEffect && p.Effect != ForbidEffect {
+		return fmt.Errorf("%w: invalid effect %q", ErrInvalidPolicy, p.Effect)
+	}
+	if p.Action.Op == "is" {
+		return fmt.Errorf("%w: action scope does not support \"is\"", ErrInvalidPolicy)
+	}
+	if p.Action.Op == "in" && len(p.Action.Entities) == 0 {
+		return fmt.Errorf("%w: action in requires at least one action", ErrInvalidPolicy)
+	}
+	for _, s := range []Scope{p.Principal, p.Resource} {
+		if s.Op == "is" && s.Type == "" {
+			return fmt.Errorf("%w: \"is\" requires an entity type", ErrInvalidPolicy)
+		}
+	}
+	for _, s := range []Scope{p.Principal, p.Action, p.Resource} {
+		for _, e := range s.Entities {
+			if e == nil || e == "" {
+				return fmt.Errorf("%w: empty entity in scope", ErrInvalidPolicy)
+			}
+		}
+	}
+	return nil
+}
+
+// ToStringOutput renders the policy as a Cedar statement.
+func (p Policy) ToStringOutput() pulumi.StringOutput {
+	return p.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext renders the policy as a Cedar statement.
+func (p Policy) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := p.Validate(); err != nil {
+		panic(err)
+	}
+	return pulumi.All(p.parts()...).ApplyTWithContext(ctx, func(_ context.Context, resolved []interface{}) string {
+		var out strings.Builder
+		for _, s := range resolved {
+			out.WriteString(s.(string))
+		}
+		return out.String()
+	}).(pulumi.StringOutput)
+}
+
+// parts returns the policy as an ordered list of literal strings and
+// StringInputs that are concatenated once resolved.
+func (p Policy) parts() []interface{} {
+	var parts []interface{}
+	for _, a := range p.Annotations {
+		parts = append(parts, fmt.Sprintf("@%s(%s)\n", a[0], Quote(a[1])))
+	}
+	parts = append(parts, string(p.Effect)+" (\n")
+	parts = append(parts, scopeParts("principal", p.Principal, false)...)
+	parts = append(parts, ",\n")
+	parts = append(parts, scopeParts("action", p.Action, true)...)
+	parts = append(parts, ",\n")
+	parts = append(parts, scopeParts("resource", p.Resource, false)...)
+	parts = append(parts, "\n)")
+	for _, expr := range p.When {
+		parts = append(parts, "\nwhen { ", expr, " }")
+	}
+	for _, expr := range p.Unless {
+		parts = append(parts, "\nunless { ", expr, " }")
+	}
+	return append(parts, ";")
+}
+
+func scopeParts(name string, s Scope, list bool) []interface{} {
+	parts := []interface{}{"    " + name}
+	switch {
+	case s.Op == "":
+	case s.Op == "is":
+		parts = append(parts, " is "+s.Type)
+	case list && s.Op == "in":
+		parts = append(parts, " in [")
+		for i, e := range s.Entities {
+			if i > 0 {
+				parts = append(parts, ", ")
+			}
+			parts = append(parts, e)
+		}
+		parts = append(parts, "]")
+	default:
+		parts = append(parts, " "+s.Op+" ", s.Entities[0])
+	}
+	return parts
+}
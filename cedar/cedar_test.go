@@ -0,0 +1,115 @@
+package cedar
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *Policy
+		expected string
+	}{
+		{
+			name:   "unscoped",
+			policy: Permit(),
+			expected: `permit (
+    principal,
+    action,
+    resource
+);`,
+		}, {
+			name: "full",
+			policy: Permit(
+				Annotation("id", "photos"),
+				PrincipalIn(Entity("Group", pulumi.String("admins").ToStringOutput())),
+				ActionIn(Entity("Action", "view"), Entity("Action", pulumi.String("edit"))),
+				ResourceIs("Photo"),
+				When("resource.owner == principal"),
+				Unless(pulumi.Sprintf("resource.tag == %s", Quote("private"))),
+			),
+			expected: `@id("photos")
+permit (
+    principal in Group::"admins",
+    action in [Action::"view", Action::"edit"],
+    resource is Photo
+)
+when { resource.owner == principal }
+unless { resource.tag == "private" };`,
+		}, {
+			name: "forbid",
+			policy: Forbid(
+				PrincipalEq(Entity("User", `a"b`)),
+				ActionEq(Entity("Action", "delete")),
+				ResourceEq(Entity("Photo", "x")),
+			),
+			expected: `forbid (
+    principal == User::"a\"b",
+    action == Action::"delete",
+    resource == Photo::"x"
+);`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			wg.Add(1)
+			_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+				test.policy.ToStringOutput().ApplyT(func(s string) int {
+					assert.Equal(t, test.expected, s)
+					wg.Done()
+					return 0
+				})
+				return nil
+			}, pulumi.WithMocks("project", "stack", mocks(0)))
+			wg.Wait()
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `"a\\b\n\"c\""`, Quote("a\\b\n\"c\""))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *Policy
+		expected error
+	}{
+		{"ok", Permit(ActionIn(Entity("Action", "a"))), nil},
+		{"bad-effect", &Policy{Effect: "allow"}, ErrInvalidPolicy},
+		{"empty-action-in", Permit(ActionIn()), ErrInvalidPolicy},
+		{"action-is", Permit(func(p *Policy) { p.Action = Scope{Op: "is", Type: "Action"} }), ErrInvalidPolicy},
+		{"is-no-type", Permit(ResourceIs("")), ErrInvalidPolicy},
+		{"empty-entity", Permit(PrincipalEq("")), ErrInvalidPolicy},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Validate()
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package cidr provides subnet calculations over CIDR blocks that may be
+// Pulumi outputs, equivalent to Terraform's cidrsubnet and cidrhost
+// functions, so that network layouts can be derived from a configured VPC
+// CIDR without hand written Apply functions.
+//
+//	vpc, _ := ec2.NewVpc(ctx, "vpc", &ec2.VpcArgs{CidrBlock: pulumi.String(cfg.Require("cidr"))})
+//	private := cidr.Subnets(vpc.CidrBlock, 4, 3)
+//	for i, az := range zones {
+//		ec2.NewSubnet(ctx, az, &ec2.SubnetArgs{
+//			VpcId:            vpc.ID(),
+//			AvailabilityZone: pulumi.String(az),
+//			CidrBlock:        private.Index(pulumi.Int(i)),
+//		})
+//	}
+//
+// Both IPv4 and IPv6 blocks are supported.
+package cidr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrInvalidCIDR is returned if a calculation can't be performed on the
+// supplied block.
+var ErrInvalidCIDR = errors.New("invalid CIDR calculation")
+
+// Subnet returns the netNum'th subnet of prefix created by extending its
+// prefix length by newBits, as with Terraform's cidrsubnet.  eg. the 2nd
+// subnet of 10.0.0.0/16 with 8 new bits is 10.0.2.0/24.
+func Subnet(prefix pulumi.StringInput, newBits, netNum int) pulumi.StringOutput {
+	return prefix.ToStringOutput().ApplyT(func(p string) (string, error) {
+		return SubnetString(p, newBits, netNum)
+	}).(pulumi.StringOutput)
+}
+
+// Subnets returns count consecutive subnets of prefix, each created by
+// extending its prefix length by newBits, starting from the first.
+func Subnets(prefix pulumi.StringInput, newBits, count int) pulumi.StringArrayOutput {
+	return prefix.ToStringOutput().ApplyT(func(p string) ([]string, error) {
+		return SubnetsString(p, newBits, count)
+	}).(pulumi.StringArrayOutput)
+}
+
+// Host returns the address of the hostNum'th host within prefix, as with
+// Terraform's cidrhost.  Negative numbers count back from the end of the
+// block, so -1 is the last address.
+func Host(prefix pulumi.StringInput, hostNum int) pulumi.StringOutput {
+	return prefix.ToStringOutput().ApplyT(func(p string) (string, error) {
+		return HostString(p, hostNum)
+	}).(pulumi.StringOutput)
+}
+
+// SubnetString is equivalent to Subnet, for a prefix that's already known.
+func SubnetString(prefix string, newBits, netNum int) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidCIDR, err)
+	}
+	ones, bits := network.Mask.Size()
+	newOnes := ones + newBits
+	if newBits < 0 || newOnes > bits {
+		return "", fmt.Errorf("%w: cannot extend /%d prefix %s by %d bits", ErrInvalidCIDR, ones, prefix, newBits)
+	}
+	if netNum < 0 || big.NewInt(int64(netNum)).BitLen() > newBits {
+		return "", fmt.Errorf("%w: subnet number %d does not fit in %d bits", ErrInvalidCIDR, netNum, newBits)
+	}
+	base := new(big.Int).SetBytes(network.IP)
+	offset := new(big.Int).Lsh(big.NewInt(int64(netNum)), uint(bits-newOnes))
+	ip := toIP(base.Or(base, offset), len(network.IP))
+	return (&net.IPNet{IP: ip, Mask: net.CIDRMask(newOnes, bits)}).String(), nil
+}
+
+// SubnetsString is equivalent to Subnets, for a prefix that's already
+// known.
+func SubnetsString(prefix string, newBits, count int) ([]string, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("%w: cannot allocate %d subnets", ErrInvalidCIDR, count)
+	}
+	subnets := make([]string, count)
+	for i := range subnets {
+		s, err := SubnetString(prefix, newBits, i)
+		if err != nil {
+			return nil, err
+		}
+		subnets[i] = s
+	}
+	return subnets, nil
+}
+
+// HostString is equivalent to Host, for a prefix that's already known.
+func HostString(prefix string, hostNum int) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidCIDR, err)
+	}
+	ones, bits := network.Mask.Size()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	n := big.NewInt(int64(hostNum))
+	if hostNum < 0 {
+		n.Add(n, size)
+	}
+	if n.Sign() < 0 || n.Cmp(size) >= 0 {
+		return "", fmt.Errorf("%w: host number %d is outside %s", ErrInvalidCIDR, hostNum, prefix)
+	}
+	base := new(big.Int).SetBytes(network.IP)
+	return toIP(base.Add(base, n), len(network.IP)).String(), nil
+}
+
+// toIP converts n to an IP address of length bytes.
+func toIP(n *big.Int, length int) net.IP {
+	ip := make(net.IP, length)
+	n.FillBytes(ip)
+	return ip
+}
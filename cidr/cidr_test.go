@@ -0,0 +1,115 @@
+package cidr
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestSubnetString(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		newBits  int
+		netNum   int
+		expected string
+	}{
+		{"10.0.0.0/16", 8, 2, "10.0.2.0/24"},
+		{"10.0.0.0/16", 4, 15, "10.0.240.0/20"},
+		{"10.1.2.3/16", 0, 0, "10.1.0.0/16"},
+		{"172.16.0.0/12", 4, 1, "172.17.0.0/16"},
+		{"fd00:1::/56", 8, 3, "fd00:1:0:3::/64"},
+		{"10.0.0.0/16", 17, 0, ""},
+		{"10.0.0.0/16", 2, 4, ""},
+		{"10.0.0.0/16", 2, -1, ""},
+		{"bogus", 2, 0, ""},
+	}
+	for _, test := range tests {
+		result, err := SubnetString(test.prefix, test.newBits, test.netNum)
+		if test.expected == "" {
+			assert.True(t, errors.Is(err, ErrInvalidCIDR), "%v: unexpected error %v", test, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, result, test)
+	}
+}
+
+func TestSubnetsString(t *testing.T) {
+	subnets, err := SubnetsString("10.0.0.0/16", 8, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/24", "10.0.1.0/24"}, subnets)
+
+	_, err = SubnetsString("10.0.0.0/16", 8, -1)
+	assert.True(t, errors.Is(err, ErrInvalidCIDR), "unexpected error %v", err)
+}
+
+func TestHostString(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		hostNum  int
+		expected string
+	}{
+		{"10.0.0.0/24", 0, "10.0.0.0"},
+		{"10.0.0.0/24", 10, "10.0.0.10"},
+		{"10.0.0.0/24", -1, "10.0.0.255"},
+		{"10.0.0.0/16", 258, "10.0.1.2"},
+		{"fd00::/64", 1, "fd00::1"},
+		{"10.0.0.0/24", 256, ""},
+		{"10.0.0.0/24", -257, ""},
+	}
+	for _, test := range tests {
+		result, err := HostString(test.prefix, test.hostNum)
+		if test.expected == "" {
+			assert.True(t, errors.Is(err, ErrInvalidCIDR), "%v: unexpected error %v", test, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, result, test)
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	var wg sync.WaitGroup
+	var subnets []string
+	var subnet, host string
+	wg.Add(3)
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		vpc := pulumi.String("10.0.0.0/16").ToStringOutput()
+		Subnets(vpc, 4, 3).ApplyT(func(s []string) []string {
+			defer wg.Done()
+			subnets = s
+			return s
+		})
+		Subnet(vpc, 8, 200).ApplyT(func(s string) string {
+			defer wg.Done()
+			subnet = s
+			return s
+		})
+		Host(Subnet(vpc, 8, 1), 5).ApplyT(func(s string) string {
+			defer wg.Done()
+			host = s
+			return s
+		})
+		wg.Wait()
+		ctx.Export("invalid", Subnets(vpc, 1, 3))
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.True(t, errors.Is(err, ErrInvalidCIDR), "unexpected error %v", err)
+	assert.Equal(t, []string{"10.0.0.0/20", "10.0.16.0/20", "10.0.32.0/20"}, subnets)
+	assert.Equal(t, "10.0.200.0/24", subnet)
+	assert.Equal(t, "10.0.1.5", host)
+}
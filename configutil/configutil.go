@@ -0,0 +1,247 @@
+// Package configutil loads Pulumi stack configuration into an annotated
+// struct, in place of individual calls to Require, GetBool and friends.
+//
+//	type appConfig struct {
+//		Region     string              `config:"region" default:"us-west-2"`
+//		Instances  int                 `config:"instances" default:"2" validate:"min=1,max=10"`
+//		Size       string              `config:"size,required" validate:"oneof=small medium large"`
+//		Tags       map[string]string   `config:"tags"`
+//		DBPassword pulumi.StringOutput `config:"dbPassword,required"`
+//	}
+//
+//	var cfg appConfig
+//	if err := configutil.Load(ctx, "", &cfg); err != nil {
+//		return err
+//	}
+//
+// Each exported field is loaded from the key named by its config tag, or
+// the field's name with its first letter in lower case.  The tag may be
+// followed by options:
+//
+//	required  the key must be set, unless the field has a default
+//	secret    the value is secret; implied for StringOutput fields
+//
+// Fields may be strings, bools, integers, floats, time.Duration or
+// pulumi.StringOutput; other types, such as slices, maps and structs, are
+// decoded from JSON.  Fields tagged `config:"-"` are skipped.
+//
+// The validate tag supports the comma separated rules min=n and max=n,
+// which apply to numbers, or the length of strings, slices and maps, and
+// oneof=a b c, which applies to strings.
+package configutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
+)
+
+// Package errors returned by Load.
+var (
+	ErrMissingConfig = errors.New("missing required configuration")
+	ErrInvalidConfig = errors.New("invalid configuration")
+)
+
+var (
+	stringOutputType = reflect.TypeOf(pulumi.StringOutput{})
+	durationType     = reflect.TypeOf(time.Duration(0))
+)
+
+// Load populates the struct pointed to by v from the configuration in
+// namespace, which defaults to the project's namespace if empty.
+//
+// All missing required keys are reported in a single error wrapping
+// ErrMissingConfig; otherwise the first invalid value is reported in an
+// error wrapping ErrInvalidConfig.
+func Load(ctx *pulumi.Context, namespace string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("configutil: Load requires a pointer to a struct, not %T", v)
+	}
+	cfg := config.New(ctx, namespace)
+
+	var missing []string
+	rv = rv.Elem()
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get("config")
+		if tag == "-" {
+			continue
+		}
+		key, opts := parseTag(tag)
+		if key == "" {
+			key = lowerFirst(f.Name)
+		}
+
+		value, err := cfg.Try(key)
+		if err != nil {
+			def, hasDefault := f.Tag.Lookup("default")
+			switch {
+			case hasDefault:
+				value = def
+			case opts["required"]:
+				missing = append(missing, key)
+				continue
+			default:
+				continue
+			}
+		}
+
+		fv := rv.Field(i)
+		if err := set(fv, value, opts["secret"]); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+		}
+		if err := validate(fv, f.Tag.Get("validate")); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingConfig, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func parseTag(tag string) (key string, opts map[string]bool) {
+	parts := strings.Split(tag, ",")
+	opts = make(map[string]bool)
+	for _, opt := range parts[1:] {
+		opts[strings.TrimSpace(opt)] = true
+	}
+	return parts[0], opts
+}
+
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+// set parses value into fv according to its type.
+func set(fv reflect.Value, value string, secret bool) error {
+	if fv.Type() == stringOutputType {
+		fv.Set(reflect.ValueOf(pulumi.ToSecret(pulumi.String(value)).(pulumi.StringOutput)))
+		return nil
+	}
+	if secret {
+		return fmt.Errorf("secret values must be loaded into a pulumi.StringOutput, not %s", fv.Type())
+	}
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	default:
+		ptr := reflect.New(fv.Type())
+		if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+			return err
+		}
+		fv.Set(ptr.Elem())
+	}
+	return nil
+}
+
+// validate checks fv against the rules in a validate tag.
+func validate(fv reflect.Value, rules string) error {
+	if rules == "" {
+		return nil
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s rule %q", name, rule)
+			}
+			n, ok := magnitude(fv)
+			if !ok {
+				return fmt.Errorf("%s rule cannot be applied to %s", name, fv.Type())
+			}
+			if name == "min" && n < limit {
+				return fmt.Errorf("must be at least %s", arg)
+			}
+			if name == "max" && n > limit {
+				return fmt.Errorf("must be at most %s", arg)
+			}
+		case "oneof":
+			if fv.Kind() != reflect.String {
+				return fmt.Errorf("oneof rule cannot be applied to %s", fv.Type())
+			}
+			allowed := strings.Fields(arg)
+			found := false
+			for _, a := range allowed {
+				if fv.String() == a {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("%q must be one of %s", fv.String(), strings.Join(allowed, ", "))
+			}
+		default:
+			return fmt.Errorf("unknown validation rule %q", rule)
+		}
+	}
+	return nil
+}
+
+// magnitude returns the value of a number, or the length of a string,
+// slice or map.
+func magnitude(fv reflect.Value) (float64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(fv.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), true
+	}
+	return 0, false
+}
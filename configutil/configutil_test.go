@@ -0,0 +1,135 @@
+package configutil
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+type appConfig struct {
+	Region     string              `config:"region" default:"us-west-2"`
+	Instances  int                 `config:"instances" default:"2" validate:"min=1,max=10"`
+	Size       string              `config:"size,required" validate:"oneof=small medium large"`
+	Enabled    bool                `config:"enabled"`
+	Ratio      float64             `config:"ratio"`
+	Timeout    time.Duration       `config:"timeout" default:"30s"`
+	Tags       map[string]string   `config:"tags"`
+	Zones      []string            `config:"zones" validate:"min=1"`
+	DBPassword pulumi.StringOutput `config:"dbPassword,required"`
+	LogLevel   string
+	Skipped    string `config:"-"`
+	unexported string
+}
+
+func load(t *testing.T, cfg string, v interface{}) error {
+	t.Setenv(pulumi.EnvConfig, cfg)
+	var loadErr error
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		loadErr = Load(ctx, "", v)
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	return loadErr
+}
+
+func TestLoad(t *testing.T) {
+	assert := assert.New(t)
+	var cfg appConfig
+	err := load(t, `{
+		"project:size": "medium",
+		"project:enabled": "true",
+		"project:ratio": "0.5",
+		"project:tags": "{\"Team\": \"platform\"}",
+		"project:zones": "[\"a\", \"b\"]",
+		"project:dbPassword": "hunter2",
+		"project:logLevel": "debug",
+		"project:Skipped": "x",
+		"other:region": "eu-west-1"
+	}`, &cfg)
+	assert.NoError(err)
+	assert.Equal("us-west-2", cfg.Region)
+	assert.Equal(2, cfg.Instances)
+	assert.Equal("medium", cfg.Size)
+	assert.True(cfg.Enabled)
+	assert.Equal(0.5, cfg.Ratio)
+	assert.Equal(30*time.Second, cfg.Timeout)
+	assert.Equal(map[string]string{"Team": "platform"}, cfg.Tags)
+	assert.Equal([]string{"a", "b"}, cfg.Zones)
+	assert.Equal("debug", cfg.LogLevel)
+	assert.Equal("", cfg.Skipped)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var password string
+	cfg.DBPassword.ApplyT(func(s string) string {
+		defer wg.Done()
+		password = s
+		return s
+	})
+	wg.Wait()
+	assert.Equal("hunter2", password)
+	assert.True(pulumi.IsSecret(cfg.DBPassword))
+}
+
+func TestLoadNamespace(t *testing.T) {
+	var cfg struct {
+		Region string `config:"region,required"`
+	}
+	err := load(t, `{"aws:region": "eu-west-1"}`, &cfg)
+	assert.True(t, errors.Is(err, ErrMissingConfig), "unexpected error %v", err)
+
+	t.Setenv(pulumi.EnvConfig, `{"aws:region": "eu-west-1"}`)
+	err = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		return Load(ctx, "aws", &cfg)
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", cfg.Region)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    error
+	}{
+		{"missing", `{"project:instances": "3"}`, ErrMissingConfig},
+		{"bad-int", `{"project:size": "small", "project:dbPassword": "x", "project:instances": "many"}`, ErrInvalidConfig},
+		{"too-many", `{"project:size": "small", "project:dbPassword": "x", "project:instances": "11"}`, ErrInvalidConfig},
+		{"not-oneof", `{"project:size": "huge", "project:dbPassword": "x"}`, ErrInvalidConfig},
+		{"empty-zones", `{"project:size": "small", "project:dbPassword": "x", "project:zones": "[]"}`, ErrInvalidConfig},
+		{"bad-json", `{"project:size": "small", "project:dbPassword": "x", "project:tags": "{"}`, ErrInvalidConfig},
+	}
+	for _, test := range tests {
+		var cfg appConfig
+		err := load(t, test.config, &cfg)
+		assert.True(t, errors.Is(err, test.err), "%s: unexpected error %v", test.name, err)
+	}
+
+	var cfg appConfig
+	err := load(t, `{}`, &cfg)
+	assert.EqualError(t, err, "missing required configuration: size, dbPassword")
+	assert.Error(t, load(t, `{}`, cfg), "should require a pointer")
+}
+
+func TestSecretRequiresOutput(t *testing.T) {
+	var cfg struct {
+		Token string `config:"token,secret"`
+	}
+	err := load(t, `{"project:token": "x"}`, &cfg)
+	assert.True(t, errors.Is(err, ErrInvalidConfig), "unexpected error %v", err)
+}
@@ -0,0 +1,333 @@
+// Package containerdef provides a helper for generating the container
+// definitions JSON supplied to the ContainerDefinitions field of an
+// ecs.TaskDefinition.
+//
+// Images, environment values, secret ARNs and log settings may be supplied
+// as strings or Pulumi StringInputs, such as the URL of a repository or the
+// ARN of a secret created earlier in the stack.
+//
+//	defs := containerdef.New(
+//		containerdef.Container("app", pulumi.Sprintf("%s:%s", repo.RepositoryUrl, tag),
+//			containerdef.Memory(512),
+//			containerdef.Port(8080),
+//			containerdef.Env("STAGE", ctx.Stack()),
+//			containerdef.Secret("DB_PASSWORD", dbSecret.Arn),
+//			containerdef.AWSLogs(logGroup.Name, "us-west-2", "app"),
+//		),
+//	)
+//	ecs.NewTaskDefinition(ctx, "app", &ecs.TaskDefinitionArgs{
+//		Family:               pulumi.String("app"),
+//		ContainerDefinitions: defs.ToStringOutput(),
+//	})
+package containerdef
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gwatts/pulutil/jsonutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrInvalidDefinition is returned if a container definition fails
+// validation.
+var ErrInvalidDefinition = errors.New("invalid container definition")
+
+// Protocols that may be supplied to PortWithProtocol.
+const (
+	TCP = "tcp"
+	UDP = "udp"
+)
+
+// Conditions that may be supplied to DependsOn.
+const (
+	Start    = "START"
+	Complete = "COMPLETE"
+	Success  = "SUCCESS"
+	Healthy  = "HEALTHY"
+)
+
+// Definitions holds the definitions of the containers in a task.
+type Definitions []*ContainerDefinition
+
+// ContainerDefinition defines a single container.
+type ContainerDefinition struct {
+	Name              string             `json:"name"`
+	Image             pulumi.StringInput `json:"image"`
+	Essential         *bool              `json:"essential,omitempty"`
+	CPU               int                `json:"cpu,omitempty"`
+	Memory            int                `json:"memory,omitempty"`
+	MemoryReservation int                `json:"memoryReservation,omitempty"`
+	Command           []string           `json:"command,omitempty"`
+	EntryPoint        []string           `json:"entryPoint,omitempty"`
+	Environment       []KeyValuePair     `json:"environment,omitempty"`
+	Secrets           []SecretRef        `json:"secrets,omitempty"`
+	PortMappings      []PortMapping      `json:"portMappings,omitempty"`
+	LogConfiguration  *LogConfiguration  `json:"logConfiguration,omitempty"`
+	DependsOn         []Dependency       `json:"dependsOn,omitempty"`
+}
+
+// KeyValuePair is an environment variable.
+type KeyValuePair struct {
+	Name  string             `json:"name"`
+	Value pulumi.StringInput `json:"value"`
+}
+
+// SecretRef exposes a Secrets Manager secret or SSM parameter to the
+// container as an environment variable.
+type SecretRef struct {
+	Name      string             `json:"name"`
+	ValueFrom pulumi.StringInput `json:"valueFrom"`
+}
+
+// PortMapping exposes a container port.
+type PortMapping struct {
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// LogConfiguration configures the container's log driver.
+type LogConfiguration struct {
+	LogDriver string                        `json:"logDriver"`
+	Options   map[string]pulumi.StringInput `json:"options,omitempty"`
+}
+
+// Dependency makes the container's startup depend on the state of
+// another container in the task.
+type Dependency struct {
+	ContainerName string `json:"containerName"`
+	Condition     string `json:"condition"`
+}
+
+// Opt is implemented by functions that can be passed to Container.
+type Opt func(*ContainerDefinition)
+
+// New creates a new set of container definitions.
+func New(containers ...*ContainerDefinition) Definitions {
+	return Definitions(containers)
+}
+
+// Container defines a container.  image may be a string or StringInput.
+func Container(name string, image interface{}, opts ...Opt) *ContainerDefinition {
+	c := &ContainerDefinition{Name: name, Image: stringInput("Container", image)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Essential sets whether the task fails if the container stops.  ECS
+// treats containers as essential unless this is set to false.
+func Essential(essential bool) Opt {
+	return func(c *ContainerDefinition) {
+		c.Essential = &essential
+	}
+}
+
+// CPU sets the number of CPU units reserved for the container.
+func CPU(units int) Opt {
+	return func(c *ContainerDefinition) {
+		c.CPU = units
+	}
+}
+
+// Memory sets the hard limit, in MiB, of memory available to the
+// container.
+func Memory(mib int) Opt {
+	return func(c *ContainerDefinition) {
+		c.Memory = mib
+	}
+}
+
+// MemoryReservation sets the soft limit, in MiB, of memory reserved for
+// the container.
+func MemoryReservation(mib int) Opt {
+	return func(c *ContainerDefinition) {
+		c.MemoryReservation = mib
+	}
+}
+
+// Command sets the command passed to the container.
+func Command(args ...string) Opt {
+	return func(c *ContainerDefinition) {
+		c.Command = args
+	}
+}
+
+// EntryPoint sets the container's entry point.
+func EntryPoint(args ...string) Opt {
+	return func(c *ContainerDefinition) {
+		c.EntryPoint = args
+	}
+}
+
+// Env sets an environment variable.  value may be a string or
+// StringInput.
+func Env(name string, value interface{}) Opt {
+	return func(c *ContainerDefinition) {
+		c.Environment = append(c.Environment, KeyValuePair{Name: name, Value: stringInput("Env", value)})
+	}
+}
+
+// Secret exposes the Secrets Manager secret or SSM parameter with the ARN
+// valueFrom as an environment variable.  valueFrom may be a string or
+// StringInput.
+func Secret(name string, valueFrom interface{}) Opt {
+	return func(c *ContainerDefinition) {
+		c.Secrets = append(c.Secrets, SecretRef{Name: name, ValueFrom: stringInput("Secret", valueFrom)})
+	}
+}
+
+// Port exposes a TCP port.
+func Port(containerPort int) Opt {
+	return PortWithProtocol(containerPort, 0, TCP)
+}
+
+// PortWithProtocol exposes a port using the specified protocol, mapped to
+// hostPort if it's non-zero.
+func PortWithProtocol(containerPort, hostPort int, protocol string) Opt {
+	return func(c *ContainerDefinition) {
+		c.PortMappings = append(c.PortMappings, PortMapping{
+			ContainerPort: containerPort,
+			HostPort:      hostPort,
+			Protocol:      protocol,
+		})
+	}
+}
+
+// LogDriver configures the container's log driver.  Option values may be
+// strings or StringInputs.
+func LogDriver(driver string, options map[string]interface{}) Opt {
+	return func(c *ContainerDefinition) {
+		lc := &LogConfiguration{LogDriver: driver}
+		if len(options) > 0 {
+			lc.Options = make(map[string]pulumi.StringInput, len(options))
+			for k, v := range options {
+				lc.Options[k] = stringInput("LogDriver", v)
+			}
+		}
+		c.LogConfiguration = lc
+	}
+}
+
+// AWSLogs sends the container's logs to a CloudWatch log group.  group
+// and region may be strings or StringInputs.
+func AWSLogs(group, region interface{}, streamPrefix string) Opt {
+	return LogDriver("awslogs", map[string]interface{}{
+		"awslogs-group":         group,
+		"awslogs-region":        region,
+		"awslogs-stream-prefix": streamPrefix,
+	})
+}
+
+// DependsOn delays starting the container until the named container
+// reaches condition, eg. Healthy.
+func DependsOn(containerName, condition string) Opt {
+	return func(c *ContainerDefinition) {
+		c.DependsOn = append(c.DependsOn, Dependency{ContainerName: containerName, Condition: condition})
+	}
+}
+
+// Validate performs a basic structural check of the definitions.
+func (d Definitions) Validate() error {
+	if len(d) == 0 {
+		return fmt.Errorf("%w: no containers defined", ErrInvalidDefinition)
+	}
+	names := make(map[string]bool, len(d))
+	essential := false
+	for _, c := range d {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if names[c.Name] {
+			return fmt.Errorf("%w: duplicate container name %q", ErrInvalidDefinition, c.Name)
+		}
+		names[c.Name] = true
+		if c.Essential == nil || *c.Essential {
+			essential = true
+		}
+	}
+	if !essential {
+		return fmt.Errorf("%w: at least one container must be essential", ErrInvalidDefinition)
+	}
+	for _, c := range d {
+		for _, dep := range c.DependsOn {
+			if !names[dep.ContainerName] {
+				return fmt.Errorf("%w: container %q depends on unknown container %q", ErrInvalidDefinition, c.Name, dep.ContainerName)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the container has a name and image, and valid ports,
+// environment and dependencies.
+func (c *ContainerDefinition) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: container has no name", ErrInvalidDefinition)
+	}
+	if c.Image == nil {
+		return fmt.Errorf("%w: container %q has no image", ErrInvalidDefinition, c.Name)
+	}
+	if c.Memory > 0 && c.MemoryReservation > c.Memory {
+		return fmt.Errorf("%w: container %q memory reservation exceeds its memory limit", ErrInvalidDefinition, c.Name)
+	}
+	for _, p := range c.PortMappings {
+		if p.ContainerPort < 1 || p.ContainerPort > 65535 || p.HostPort < 0 || p.HostPort > 65535 {
+			return fmt.Errorf("%w: container %q has invalid port mapping %d:%d", ErrInvalidDefinition, c.Name, p.HostPort, p.ContainerPort)
+		}
+		if p.Protocol != "" && p.Protocol != TCP && p.Protocol != UDP {
+			return fmt.Errorf("%w: container %q has invalid protocol %q", ErrInvalidDefinition, c.Name, p.Protocol)
+		}
+	}
+	vars := make(map[string]bool)
+	for _, e := range c.Environment {
+		if vars[e.Name] {
+			return fmt.Errorf("%w: container %q sets %q more than once", ErrInvalidDefinition, c.Name, e.Name)
+		}
+		vars[e.Name] = true
+	}
+	for _, s := range c.Secrets {
+		if vars[s.Name] {
+			return fmt.Errorf("%w: container %q sets %q more than once", ErrInvalidDefinition, c.Name, s.Name)
+		}
+		vars[s.Name] = true
+	}
+	for _, dep := range c.DependsOn {
+		switch dep.Condition {
+		case Start, Complete, Success, Healthy:
+		default:
+			return fmt.Errorf("%w: container %q has invalid dependency condition %q", ErrInvalidDefinition, c.Name, dep.Condition)
+		}
+	}
+	return nil
+}
+
+// ToStringOutput generates the container definitions as JSON.
+//
+// Will panic if Validate returns an error.
+func (d Definitions) ToStringOutput() pulumi.StringOutput {
+	return d.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext generates the container definitions as JSON.
+//
+// Will panic if Validate returns an error.
+func (d Definitions) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := d.Validate(); err != nil {
+		panic(err)
+	}
+	return jsonutil.ToJSONOutputWithContext(ctx, []*ContainerDefinition(d))
+}
+
+func stringInput(caller string, v interface{}) pulumi.StringInput {
+	switch v := v.(type) {
+	case string:
+		return pulumi.String(v)
+	case pulumi.StringInput:
+		return v
+	}
+	panic(fmt.Sprintf("unexpected type passed to %s: %T: %#v", caller, v, v))
+}
@@ -0,0 +1,96 @@
+package containerdef
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToStringOutput(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		defs := New(
+			Container("app", pulumi.Sprintf("%s:%s", pulumi.String("1234.dkr.ecr.us-west-2.amazonaws.com/app").ToStringOutput(), "v1"),
+				Memory(512),
+				Command("serve", "--port", "8080"),
+				Port(8080),
+				Env("STAGE", pulumi.String(ctx.Stack()).ToStringOutput()),
+				Secret("DB_PASSWORD", pulumi.String("arn:aws:secretsmanager:us-west-2:1234:secret:db").ToStringOutput()),
+				AWSLogs(pulumi.String("/ecs/app").ToStringOutput(), "us-west-2", "app"),
+				DependsOn("proxy", Healthy),
+			),
+			Container("proxy", "envoy:latest",
+				Essential(false),
+				PortWithProtocol(9901, 9901, UDP),
+			),
+		)
+		actual := testutil.AwaitOutput[string](t, defs.ToStringOutput())
+		expected := `[
+			{
+				"name": "app",
+				"image": "1234.dkr.ecr.us-west-2.amazonaws.com/app:v1",
+				"memory": 512,
+				"command": ["serve", "--port", "8080"],
+				"environment": [{"name": "STAGE", "value": "stack"}],
+				"secrets": [{"name": "DB_PASSWORD", "valueFrom": "arn:aws:secretsmanager:us-west-2:1234:secret:db"}],
+				"portMappings": [{"containerPort": 8080, "protocol": "tcp"}],
+				"logConfiguration": {
+					"logDriver": "awslogs",
+					"options": {
+						"awslogs-group": "/ecs/app",
+						"awslogs-region": "us-west-2",
+						"awslogs-stream-prefix": "app"
+					}
+				},
+				"dependsOn": [{"containerName": "proxy", "condition": "HEALTHY"}]
+			},
+			{
+				"name": "proxy",
+				"image": "envoy:latest",
+				"essential": false,
+				"portMappings": [{"containerPort": 9901, "hostPort": 9901, "protocol": "udp"}]
+			}
+		]`
+		assert.JSONEq(t, expected, actual)
+
+		var decoded []map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(actual), &decoded))
+		assert.Equal(t, "app", decoded[0]["name"], "containers should be rendered in order")
+		return nil
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		defs Definitions
+	}{
+		{"empty", New()},
+		{"no-name", New(Container("", "nginx"))},
+		{"no-image", New(&ContainerDefinition{Name: "app"})},
+		{"duplicate-name", New(Container("app", "nginx"), Container("app", "nginx"))},
+		{"none-essential", New(Container("app", "nginx", Essential(false)))},
+		{"bad-port", New(Container("app", "nginx", Port(0)))},
+		{"bad-host-port", New(Container("app", "nginx", PortWithProtocol(80, 70000, TCP)))},
+		{"bad-protocol", New(Container("app", "nginx", PortWithProtocol(80, 0, "sctp")))},
+		{"bad-memory", New(Container("app", "nginx", Memory(256), MemoryReservation(512)))},
+		{"duplicate-env", New(Container("app", "nginx", Env("A", "1"), Env("A", "2")))},
+		{"env-secret-clash", New(Container("app", "nginx", Env("A", "1"), Secret("A", "arn")))},
+		{"unknown-dependency", New(Container("app", "nginx", DependsOn("db", Start)))},
+		{"bad-condition", New(Container("app", "nginx"), Container("db", "postgres", DependsOn("app", "READY")))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.defs.Validate()
+			assert.True(t, errors.Is(err, ErrInvalidDefinition), "unexpected error %v", err)
+			assert.Panics(t, func() { test.defs.ToStringOutput() })
+		})
+	}
+
+	assert.NoError(t, New(Container("app", "nginx", Memory(512), MemoryReservation(256))).Validate())
+	assert.Panics(t, func() { Container("app", 42) })
+	assert.Panics(t, func() { Env("A", 1)(&ContainerDefinition{}) })
+}
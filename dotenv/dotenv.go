@@ -0,0 +1,172 @@
+// Package dotenv renders environment files, in the .env format read by
+// docker compose, systemd and most dotenv libraries, from values that may
+// be Pulumi outputs.
+//
+//	env := dotenv.New(map[string]interface{}{
+//		"DATABASE_HOST":     db.Address,
+//		"DATABASE_PASSWORD": dbPassword.Result,
+//		"STAGE":             ctx.Stack(),
+//	})
+//	s3.NewBucketObject(ctx, "env", &s3.BucketObjectArgs{
+//		Bucket: bucket.ID(),
+//		Source: env.ToAssetOutput(),
+//	})
+//
+// Variables are written in sorted order, quoting values where needed.  The
+// rendered file is secret if any of its values are.
+package dotenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrInvalidName is returned if a variable name isn't a valid environment
+// variable name.
+var ErrInvalidName = errors.New("invalid variable name")
+
+var (
+	nameRegexp     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	unquotedRegexp = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+)
+
+// File holds the variables of an environment file.
+type File struct {
+	Vars   map[string]interface{}
+	export bool
+	header []string
+}
+
+// Opt is implemented by functions that can be passed to New.
+type Opt func(*File)
+
+// New creates a new environment file.  Values may be strings or
+// StringInputs.
+func New(vars map[string]interface{}, opts ...Opt) *File {
+	for name, v := range vars {
+		switch v.(type) {
+		case string, pulumi.StringInput:
+		default:
+			panic(fmt.Sprintf("unexpected type passed to New for %s: %T: %#v", name, v, v))
+		}
+	}
+	f := &File{Vars: vars}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Export prefixes each variable with "export ", so that the file can be
+// sourced by a shell.
+func Export() Opt {
+	return func(f *File) {
+		f.export = true
+	}
+}
+
+// Header adds a comment to the top of the file, such as a warning that
+// it's generated.
+func Header(lines ...string) Opt {
+	return func(f *File) {
+		f.header = append(f.header, lines...)
+	}
+}
+
+// Validate checks that each variable has a valid name.
+func (f *File) Validate() error {
+	for _, name := range f.names() {
+		if !nameRegexp.MatchString(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
+	}
+	return nil
+}
+
+// ToStringOutput renders the file.
+//
+// Will panic if Validate returns an error.
+func (f *File) ToStringOutput() pulumi.StringOutput {
+	return f.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext renders the file.
+//
+// Will panic if Validate returns an error.
+func (f *File) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := f.Validate(); err != nil {
+		panic(err)
+	}
+	names := f.names()
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		values[i] = f.Vars[name]
+	}
+	return pulumi.AllWithContext(ctx, values...).ApplyTWithContext(ctx, func(_ context.Context, resolved []interface{}) string {
+		var b strings.Builder
+		for _, line := range f.header {
+			b.WriteString("# " + line + "\n")
+		}
+		for i, name := range names {
+			if f.export {
+				b.WriteString("export ")
+			}
+			b.WriteString(name + "=" + Quote(resolved[i].(string)) + "\n")
+		}
+		return b.String()
+	}).(pulumi.StringOutput)
+}
+
+// ToAssetOutput renders the file as a StringAsset, eg. for uploading as
+// an object or including in an archive.
+//
+// Will panic if Validate returns an error.
+func (f *File) ToAssetOutput() pulumi.AssetOutput {
+	return f.ToStringOutput().ApplyT(func(s string) pulumi.Asset {
+		return pulumi.NewStringAsset(s)
+	}).(pulumi.AssetOutput)
+}
+
+func (f *File) names() []string {
+	names := make([]string, 0, len(f.Vars))
+	for name := range f.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Quote returns value as it should be written in an environment file.
+// Values made up of safe characters are written as is; others are double
+// quoted, escaping backslashes, quotes, dollar signs, backticks and
+// control characters.
+func Quote(value string) string {
+	if unquotedRegexp.MatchString(value) {
+		return value
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\', '"', '$', '`':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package dotenv
+
+import (
+	"testing"
+
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"":                     "",
+		"simple":               "simple",
+		"postgres://db:5432/x": "postgres://db:5432/x",
+		"two words":            `"two words"`,
+		`say "hi"`:             `"say \"hi\""`,
+		"a\nb\tc\r":            `"a\nb\tc\r"`,
+		`$HOME\bin`:            `"\$HOME\\bin"`,
+		"`cmd`":                "\"\\`cmd\\`\"",
+		"#comment":             `"#comment"`,
+	}
+	for in, expected := range tests {
+		assert.Equal(t, expected, Quote(in), in)
+	}
+}
+
+func TestToStringOutput(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		f := New(map[string]interface{}{
+			"STAGE":    "prod",
+			"DB_HOST":  pulumi.String("db.example.com").ToStringOutput(),
+			"GREETING": "hello world",
+		}, Header("generated by pulumi"))
+		out := f.ToStringOutput()
+		assert.Equal(t, "# generated by pulumi\nDB_HOST=db.example.com\nGREETING=\"hello world\"\nSTAGE=prod\n",
+			testutil.AwaitOutput[string](t, out))
+		assert.False(t, testutil.IsSecret(t, out))
+
+		f = New(map[string]interface{}{
+			"PASSWORD": pulumi.ToSecret(pulumi.String("p@ss word")).(pulumi.StringOutput),
+		}, Export())
+		out = f.ToStringOutput()
+		assert.Equal(t, "export PASSWORD=\"p@ss word\"\n", testutil.AwaitOutput[string](t, out))
+		assert.True(t, testutil.IsSecret(t, out))
+
+		asset := testutil.AwaitOutput[pulumi.Asset](t, f.ToAssetOutput())
+		assert.Equal(t, "export PASSWORD=\"p@ss word\"\n", asset.Text())
+		return nil
+	})
+}
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{"1ABC", "A-B", "A B", ""} {
+		f := New(map[string]interface{}{name: "x"})
+		assert.ErrorIs(t, f.Validate(), ErrInvalidName, name)
+		assert.Panics(t, func() { f.ToStringOutput() })
+	}
+	assert.NoError(t, New(map[string]interface{}{"_A1": "x"}).Validate())
+	assert.Panics(t, func() { New(map[string]interface{}{"A": 1}) })
+}
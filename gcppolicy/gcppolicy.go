@@ -0,0 +1,289 @@
+// Package gcppolicy provides a helper for generating Google Cloud IAM
+// policy data, such as is supplied to the PolicyData field of the
+// pulumi-gcp projects.IAMPolicy resource and its equivalents for other
+// resource types.
+//
+// As with the policy package, members may be supplied as strings, string
+// slices, Pulumi StringOutputs or StringArrayOutputs, which are flattened
+// into a single list once they've resolved.
+//
+//	projects.NewIAMPolicy(ctx, "project-policy", &projects.IAMPolicyArgs{
+//	    Project: pulumi.String("my-project"),
+//	    PolicyData: gcppolicy.New(
+//	        gcppolicy.Binding("roles/storage.objectViewer",
+//	            gcppolicy.Member(gcppolicy.ServiceAccount(sa.Email)),
+//	            gcppolicy.Member("group:readers@example.com"),
+//	        ),
+//	    ).ToStringOutput(),
+//	})
+package gcppolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Package errors returned during validation of policies and bindings.
+var (
+	ErrInvalidPolicy  = errors.New("invalid policy")
+	ErrInvalidBinding = errors.New("invalid binding")
+)
+
+// Policy defines a Google Cloud IAM policy that can be converted to a JSON
+// StringOutput.
+type Policy struct {
+	Version  int                 `json:"version,omitempty"`
+	Bindings []PolicyBinding     `json:"bindings"`
+	Audit    []PolicyAuditConfig `json:"auditConfigs,omitempty"`
+}
+
+// PolicyBinding binds a role to a set of members.
+type PolicyBinding struct {
+	Role      string  `json:"role"`
+	Members   Members `json:"members"`
+	Condition *Expr   `json:"condition,omitempty"`
+}
+
+// Expr is a CEL condition expression attached to a binding.
+type Expr struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Expression  string `json:"expression"`
+}
+
+// PolicyAuditConfig enables audit logging for a service.
+type PolicyAuditConfig struct {
+	Service         string                 `json:"service"`
+	AuditLogConfigs []PolicyAuditLogConfig `json:"auditLogConfigs"`
+}
+
+// PolicyAuditLogConfig specifies a type of audit logging to enable.
+type PolicyAuditLogConfig struct {
+	LogType         string  `json:"logType"`
+	ExemptedMembers Members `json:"exemptedMembers,omitempty"`
+}
+
+// Opt is implemented by functions that can be passed to New.
+type Opt func(*Policy)
+
+// New creates a new Policy.  It should be supplied at least one Binding.
+func New(opts ...Opt) *Policy {
+	p := &Policy{Version: 1}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Validate performs a basic structural check of the Policy.
+func (p Policy) Validate() error {
+	for _, b := range p.Bindings {
+		if err := b.Validate(); err != nil {
+			return err
+		}
+		if b.Condition != nil && p.Version < 3 {
+			return fmt.Errorf("%w: bindings with conditions require policy version 3", ErrInvalidPolicy)
+		}
+	}
+	return nil
+}
+
+// ToStringOutput generates the policy as JSON policy data.
+func (p Policy) ToStringOutput() pulumi.StringOutput {
+	return p.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext generates the policy as JSON policy data.
+func (p Policy) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := p.Validate(); err != nil {
+		panic(err)
+	}
+	return pulumi.ToOutput(p).ApplyTWithContext(ctx, func(_ context.Context, data interface{}) (string, error) {
+		v, err := json.MarshalIndent(data, "", "    ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal json for policy: %w", err)
+		}
+		return string(v), nil
+	}).(pulumi.StringOutput)
+}
+
+// BindingsOutput resolves the policy's bindings into a list of maps in the
+// form used by binding arguments, with role, members and (optionally)
+// condition keys.
+func (p Policy) BindingsOutput() pulumi.ArrayOutput {
+	return p.ToStringOutput().ApplyT(func(js string) ([]interface{}, error) {
+		var resolved struct {
+			Bindings []interface{} `json:"bindings"`
+		}
+		if err := json.Unmarshal([]byte(js), &resolved); err != nil {
+			return nil, err
+		}
+		return resolved.Bindings, nil
+	}).(pulumi.ArrayOutput)
+}
+
+// memberPrefixes lists the valid prefixes of member identifiers.
+var memberPrefixes = []string{
+	"user:", "serviceAccount:", "group:", "domain:", "principal:", "principalSet:",
+	"deleted:", "projectOwner:", "projectEditor:", "projectViewer:",
+}
+
+// Validate checks the binding has a role and valid members.
+func (b PolicyBinding) Validate() error {
+	if b.Role == "" {
+		return fmt.Errorf("%w: binding has no role", ErrInvalidBinding)
+	}
+	if !strings.HasPrefix(b.Role, "roles/") && !strings.Contains(b.Role, "/roles/") {
+		return fmt.Errorf("%w: %q is not a valid role name", ErrInvalidBinding, b.Role)
+	}
+	if len(b.Members) == 0 {
+		return fmt.Errorf("%w: binding for role %q has no members", ErrInvalidBinding, b.Role)
+	}
+	for _, m := range b.Members.static() {
+		if !validMember(m) {
+			return fmt.Errorf("%w: invalid member %q for role %q", ErrInvalidBinding, m, b.Role)
+		}
+	}
+	if b.Condition != nil && (b.Condition.Title == "" || b.Condition.Expression == "") {
+		return fmt.Errorf("%w: condition for role %q requires a title and expression", ErrInvalidBinding, b.Role)
+	}
+	return nil
+}
+
+func validMember(m string) bool {
+	if m == "allUsers" || m == "allAuthenticatedUsers" {
+		return true
+	}
+	for _, prefix := range memberPrefixes {
+		if strings.HasPrefix(m, prefix) && len(m) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Members holds a list of member identifiers that may be strings, string
+// slices or their Pulumi input equivalents.  Unlike policy.Strings it always
+// marshals to a JSON array, as required by Google Cloud.
+type Members []interface{}
+
+// MarshalJSON implements json.Marshaler.
+func (m Members) MarshalJSON() ([]byte, error) {
+	out := make([]string, 0, len(m))
+	for _, el := range m {
+		switch v := el.(type) {
+		case string:
+			out = append(out, v)
+		case []string:
+			out = append(out, v...)
+		default:
+			return nil, fmt.Errorf("unexpected type in members: %T", el)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// static returns the members that are already known, skipping outputs.
+func (m Members) static() (out []string) {
+	for _, el := range m {
+		switch v := el.(type) {
+		case string:
+			out = append(out, v)
+		case []string:
+			out = append(out, v...)
+		}
+	}
+	return out
+}
+
+// BindingOpt is implemented by functions that can be passed to Binding.
+type BindingOpt func(*PolicyBinding)
+
+// Binding adds a binding of role to the policy.  Bindings for the same role
+// and condition are not merged.
+func Binding(role string, opts ...BindingOpt) Opt {
+	return func(p *Policy) {
+		b := PolicyBinding{Role: role}
+		for _, opt := range opts {
+			opt(&b)
+		}
+		if b.Condition != nil && p.Version < 3 {
+			p.Version = 3
+		}
+		p.Bindings = append(p.Bindings, b)
+	}
+}
+
+// Member adds one or more members to a binding.  Members must be fully
+// qualified, eg. "user:someone@example.com"; the User, ServiceAccount,
+// Group and Domain helpers can be used to add the prefix to outputs.
+//
+// member arguments may be string, []string, StringInput or StringArrayInput
+// slices and arrays will be flattened into a single list.
+func Member(member ...interface{}) BindingOpt {
+	return func(b *PolicyBinding) {
+		b.Members = append(b.Members, member...)
+	}
+}
+
+// Condition attaches a CEL condition to a binding.  Policies containing
+// conditional bindings are automatically set to version 3.
+func Condition(title, expression string) BindingOpt {
+	return ConditionWithDescription(title, "", expression)
+}
+
+// ConditionWithDescription attaches a CEL condition with a description to
+// a binding.
+func ConditionWithDescription(title, description, expression string) BindingOpt {
+	return func(b *PolicyBinding) {
+		b.Condition = &Expr{Title: title, Description: description, Expression: expression}
+	}
+}
+
+// AuditConfig enables the supplied log types (eg. "DATA_READ",
+// "DATA_WRITE", "ADMIN_READ") for a service, or "allServices".
+func AuditConfig(service string, logTypes ...string) Opt {
+	return func(p *Policy) {
+		sorted := append([]string(nil), logTypes...)
+		sort.Strings(sorted)
+		cfg := PolicyAuditConfig{Service: service}
+		for _, lt := range sorted {
+			cfg.AuditLogConfigs = append(cfg.AuditLogConfigs, PolicyAuditLogConfig{LogType: lt})
+		}
+		p.Audit = append(p.Audit, cfg)
+	}
+}
+
+// User returns a member identifier for a user's email address, which may
+// be a string or StringInput.
+func User(email interface{}) interface{} { return withPrefix("user:", email) }
+
+// ServiceAccount returns a member identifier for a service account's
+// email address, which may be a string or StringInput such as the Email
+// output of a serviceaccount.Account.
+func ServiceAccount(email interface{}) interface{} { return withPrefix("serviceAccount:", email) }
+
+// Group returns a member identifier for a group's email address, which may
+// be a string or StringInput.
+func Group(email interface{}) interface{} { return withPrefix("group:", email) }
+
+// Domain returns a member identifier for a G Suite domain, which may be a
+// string or StringInput.
+func Domain(domain interface{}) interface{} { return withPrefix("domain:", domain) }
+
+func withPrefix(prefix string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return prefix + v
+	case pulumi.StringInput:
+		return pulumi.Sprintf("%s%s", prefix, v)
+	default:
+		panic(fmt.Sprintf("unexpected type passed as a member: %T: %#v", v, v))
+	}
+}
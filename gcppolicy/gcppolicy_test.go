@@ -0,0 +1,130 @@
+package gcppolicy
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestPolicyJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New(
+			Binding("roles/storage.objectViewer",
+				Member(ServiceAccount(pulumi.String("sa@proj.iam.gserviceaccount.com").ToStringOutput())),
+				Member("group:readers@example.com"),
+			),
+			Binding("roles/storage.admin",
+				Member(pulumi.StringArray{pulumi.String("user:a@example.com"), pulumi.String("user:b@example.com")}),
+				Condition("expires", `request.time < timestamp("2030-01-01T00:00:00Z")`),
+			),
+			AuditConfig("allServices", "DATA_WRITE", "ADMIN_READ"),
+		)
+
+		expected := `{
+			"version": 3,
+			"bindings": [{
+				"role": "roles/storage.objectViewer",
+				"members": ["serviceAccount:sa@proj.iam.gserviceaccount.com", "group:readers@example.com"]
+			}, {
+				"role": "roles/storage.admin",
+				"members": ["user:a@example.com", "user:b@example.com"],
+				"condition": {"title": "expires", "expression": "request.time < timestamp(\"2030-01-01T00:00:00Z\")"}
+			}],
+			"auditConfigs": [{
+				"service": "allServices",
+				"auditLogConfigs": [{"logType": "ADMIN_READ"}, {"logType": "DATA_WRITE"}]
+			}]
+		}`
+		p.ToStringOutput().ApplyT(func(js string) int {
+			assert.JSONEq(expected, js)
+			wg.Done()
+			return 0
+		})
+		p.BindingsOutput().ApplyT(func(bindings []interface{}) int {
+			assert.Len(bindings, 2)
+			assert.Equal("roles/storage.admin", bindings[1].(map[string]interface{})["role"])
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestSingleMemberIsArray(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New(Binding("roles/viewer", Member(User("a@example.com"))))
+		p.ToStringOutput().ApplyT(func(js string) int {
+			assert.JSONEq(`{"version": 1, "bindings": [{"role": "roles/viewer", "members": ["user:a@example.com"]}]}`, js)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Opt
+		expected error
+	}{
+		{"ok", []Opt{Binding("roles/viewer", Member("allUsers"))}, nil},
+		{"custom-role", []Opt{Binding("projects/p/roles/custom", Member(Domain("example.com")))}, nil},
+		{"no-role", []Opt{Binding("", Member("allUsers"))}, ErrInvalidBinding},
+		{"bad-role", []Opt{Binding("viewer", Member("allUsers"))}, ErrInvalidBinding},
+		{"no-members", []Opt{Binding("roles/viewer")}, ErrInvalidBinding},
+		{"bad-member", []Opt{Binding("roles/viewer", Member("someone@example.com"))}, ErrInvalidBinding},
+		{"empty-condition", []Opt{Binding("roles/viewer", Member("allUsers"), Condition("", ""))}, ErrInvalidBinding},
+		{"output-member", []Opt{Binding("roles/viewer", Member(pulumi.String("anything").ToStringOutput()))}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := New(test.opts...).Validate()
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestConditionRequiresVersion3(t *testing.T) {
+	p := New(Binding("roles/viewer", Member("allUsers"), Condition("t", "true")))
+	assert.Equal(t, 3, p.Version)
+
+	p.Version = 1
+	assert.True(t, errors.Is(p.Validate(), ErrInvalidPolicy))
+}
+
+func TestAuditConfigLeavesArgs(t *testing.T) {
+	types := []string{"DATA_WRITE", "ADMIN_READ"}
+	p := New(AuditConfig("allServices", types...))
+	assert.Equal(t, []string{"DATA_WRITE", "ADMIN_READ"}, types)
+	assert.Equal(t, "ADMIN_READ", p.Audit[0].AuditLogConfigs[0].LogType)
+}
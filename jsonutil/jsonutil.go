@@ -0,0 +1,330 @@
+// Package jsonutil marshals arbitrary values containing Pulumi inputs to
+// JSON, once those inputs have resolved, such as ECS container
+// definitions, Step Functions state machines or CloudWatch dashboards
+// built from resource outputs.
+//
+//	type container struct {
+//		Name  string             `json:"name"`
+//		Image pulumi.StringInput `json:"image"`
+//		Ports []int              `json:"ports,omitempty"`
+//	}
+//
+//	defs := jsonutil.ToJSONOutput([]container{{
+//		Name:  "app",
+//		Image: pulumi.Sprintf("%s:latest", repo.RepositoryUrl),
+//	}})
+//
+// Inputs may be held at any depth within structs, maps, slices, arrays,
+// pointers and interfaces.  Struct fields are marshaled as by
+// encoding/json, honoring json tags, including omitempty, which is
+// evaluated against an input's resolved value.
+package jsonutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var inputType = reflect.TypeOf((*pulumi.Input)(nil)).Elem()
+
+// maxDepth bounds recursion through self-referencing structures.
+const maxDepth = 32
+
+// ToJSONOutput marshals v to JSON once the inputs it contains have
+// resolved.  The output is secret if any of the inputs are secret.
+func ToJSONOutput(v interface{}) pulumi.StringOutput {
+	return ToJSONOutputWithContext(context.Background(), v)
+}
+
+// ToJSONOutputWithContext marshals v to JSON once the inputs it contains
+// have resolved.  The output is secret if any of the inputs are secret.
+func ToJSONOutputWithContext(ctx context.Context, v interface{}) pulumi.StringOutput {
+	return marshal(ctx, v, "")
+}
+
+// ToJSONIndentOutput is equivalent to ToJSONOutput, but indents the JSON
+// as with json.MarshalIndent.
+func ToJSONIndentOutput(v interface{}, indent string) pulumi.StringOutput {
+	return marshal(context.Background(), v, indent)
+}
+
+func marshal(ctx context.Context, v interface{}, indent string) pulumi.StringOutput {
+	var inputs []interface{}
+	tree := convert(reflect.ValueOf(v), &inputs, 0)
+	return pulumi.AllWithContext(ctx, inputs...).ApplyTWithContext(ctx, func(_ context.Context, resolved []interface{}) (string, error) {
+		var (
+			data []byte
+			err  error
+		)
+		if indent != "" {
+			data, err = json.MarshalIndent(substitute(tree, resolved), "", indent)
+		} else {
+			data, err = json.Marshal(substitute(tree, resolved))
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal json: %w", err)
+		}
+		return string(data), nil
+	}).(pulumi.StringOutput)
+}
+
+// leaf marks the position of an input within a converted value, as an
+// index into the inputs supplied to pulumi.All.
+type leaf int
+
+// array is a converted slice or array.
+type array []interface{}
+
+// object is a converted struct or map, whose fields are marshaled in
+// order.
+type object []field
+
+type field struct {
+	name      string
+	value     interface{}
+	omitEmpty bool
+}
+
+// convert returns a copy of v in which every input is replaced by a leaf,
+// appending the inputs to inputs.  Values that don't contain inputs are
+// returned unchanged, to be marshaled by encoding/json.
+func convert(v reflect.Value, inputs *[]interface{}, depth int) interface{} {
+	if !v.IsValid() || isNil(v) {
+		return nil
+	}
+	if v.Type().Implements(inputType) {
+		if v.IsZero() {
+			// an unset output would never resolve
+			return nil
+		}
+		*inputs = append(*inputs, v.Interface())
+		return leaf(len(*inputs) - 1)
+	}
+	if !containsInput(v, depth) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return convert(v.Elem(), inputs, depth+1)
+	case reflect.Map:
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			keys = append(keys, k)
+			values[k] = iter.Value()
+		}
+		sort.Strings(keys)
+		out := make(object, len(keys))
+		for i, k := range keys {
+			out[i] = field{name: k, value: convert(values[k], inputs, depth+1)}
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make(array, v.Len())
+		for i := range out {
+			out[i] = convert(v.Index(i), inputs, depth+1)
+		}
+		return out
+	case reflect.Struct:
+		return convertStruct(v, inputs, depth)
+	}
+	return v.Interface()
+}
+
+// convertStruct converts a struct's exported fields as named by their json
+// tags.  The fields of exported embedded structs without a json name are
+// promoted, unless they'd conflict with a field of the outer struct.
+func convertStruct(v reflect.Value, inputs *[]interface{}, depth int) object {
+	type entry struct {
+		field    field
+		embedded object
+	}
+	var entries []entry
+	seen := make(map[string]bool)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if f.Anonymous && name == "" {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				entries = append(entries, entry{embedded: convertStruct(fv, inputs, depth+1)})
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		seen[name] = true
+		entries = append(entries, entry{field: field{
+			name:      name,
+			value:     convert(fv, inputs, depth+1),
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		}})
+	}
+
+	var out object
+	for _, e := range entries {
+		if e.embedded == nil {
+			out = append(out, e.field)
+			continue
+		}
+		for _, f := range e.embedded {
+			if !seen[f.name] {
+				seen[f.name] = true
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
+// substitute replaces the leaves of a converted value with their resolved
+// values.
+func substitute(v interface{}, resolved []interface{}) interface{} {
+	switch v := v.(type) {
+	case leaf:
+		return resolved[v]
+	case array:
+		out := make([]interface{}, len(v))
+		for i, el := range v {
+			out[i] = substitute(el, resolved)
+		}
+		return out
+	case object:
+		out := make(orderedObject, 0, len(v))
+		for _, f := range v {
+			value := substitute(f.value, resolved)
+			if f.omitEmpty && isEmpty(value) {
+				continue
+			}
+			out = append(out, orderedField{name: f.name, value: value})
+		}
+		return out
+	}
+	return v
+}
+
+type orderedField struct {
+	name  string
+	value interface{}
+}
+
+// orderedObject marshals to a JSON object with its fields in order.
+type orderedObject []orderedField
+
+// MarshalJSON implements json.Marshaler.
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func containsInput(v reflect.Value, depth int) bool {
+	if !v.IsValid() || depth > maxDepth || isNil(v) {
+		return false
+	}
+	if v.Type().Implements(inputType) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return containsInput(v.Elem(), depth+1)
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if containsInput(iter.Value(), depth+1) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if containsInput(v.Index(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath == "" && containsInput(v.Field(i), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isEmpty reports whether v is empty, as defined by encoding/json's
+// omitempty option.
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	if _, ok := v.(orderedObject); ok {
+		// converted structs are never empty
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
+}
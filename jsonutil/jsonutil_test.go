@@ -0,0 +1,117 @@
+package jsonutil
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+type portMapping struct {
+	ContainerPort pulumi.IntInput `json:"containerPort"`
+	Protocol      string          `json:"protocol,omitempty"`
+}
+
+type Common struct {
+	Essential bool              `json:"essential"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+type container struct {
+	Common
+	Name         string                  `json:"name"`
+	Image        pulumi.StringInput      `json:"image"`
+	Command      pulumi.StringArrayInput `json:"command,omitempty"`
+	Environment  map[string]interface{}  `json:"environment,omitempty"`
+	PortMappings []portMapping           `json:"portMappings,omitempty"`
+	Memory       pulumi.IntInput         `json:"memory,omitempty"`
+	Secret       *portMapping            `json:"secret,omitempty"`
+	Ignored      pulumi.StringInput      `json:"-"`
+	Untagged     pulumi.StringInput
+	internal     pulumi.StringInput
+}
+
+func render(t *testing.T, v interface{}, indent string) string {
+	var result string
+	var wg sync.WaitGroup
+	wg.Add(1)
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var out pulumi.StringOutput
+		if indent != "" {
+			out = ToJSONIndentOutput(v, indent)
+		} else {
+			out = ToJSONOutput(v)
+		}
+		out.ApplyT(func(s string) string {
+			defer wg.Done()
+			result = s
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	return result
+}
+
+func TestToJSONOutput(t *testing.T) {
+	defs := []container{{
+		Common:  Common{Essential: true},
+		Name:    "app",
+		Image:   pulumi.Sprintf("%s:latest", pulumi.String("repo").ToStringOutput()),
+		Command: pulumi.StringArray{pulumi.String("run"), pulumi.String("--port").ToStringOutput()},
+		Environment: map[string]interface{}{
+			"DB_HOST": pulumi.String("db.internal").ToStringOutput(),
+			"DEBUG":   "false",
+		},
+		PortMappings: []portMapping{{ContainerPort: pulumi.Int(8080).ToIntOutput(), Protocol: "tcp"}},
+		Memory:       pulumi.Int(0).ToIntOutput(),
+		Ignored:      pulumi.String("ignored"),
+		Untagged:     pulumi.String("untagged"),
+		internal:     pulumi.String("internal"),
+	}}
+	expected := `[{` +
+		`"essential":true,` +
+		`"name":"app",` +
+		`"image":"repo:latest",` +
+		`"command":["run","--port"],` +
+		`"environment":{"DB_HOST":"db.internal","DEBUG":"false"},` +
+		`"portMappings":[{"containerPort":8080,"protocol":"tcp"}],` +
+		`"Untagged":"untagged"` +
+		`}]`
+	assert.Equal(t, expected, render(t, defs, ""))
+}
+
+func TestToJSONOutputStatic(t *testing.T) {
+	v := map[string]interface{}{
+		"b": []int{1, 2},
+		"a": portMapping{Protocol: "udp"},
+	}
+	assert.Equal(t, `{"a":{"containerPort":null,"protocol":"udp"},"b":[1,2]}`, render(t, v, ""))
+	assert.Equal(t, "null", render(t, nil, ""))
+}
+
+func TestToJSONIndentOutput(t *testing.T) {
+	v := map[string]interface{}{"name": pulumi.String("x").ToStringOutput()}
+	assert.Equal(t, "{\n  \"name\": \"x\"\n}", render(t, v, "  "))
+}
+
+func TestUnsetOutput(t *testing.T) {
+	v := struct {
+		Name pulumi.StringOutput `json:"name"`
+		ID   pulumi.StringInput  `json:"id"`
+	}{ID: pulumi.String("1")}
+	assert.Equal(t, `{"name":null,"id":"1"}`, render(t, v, ""))
+}
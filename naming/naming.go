@@ -0,0 +1,166 @@
+// Package naming provides a helper for generating resource names that
+// follow a consistent convention, such as "acme-shop-prod-orders", while
+// respecting the length limits imposed by each type of resource.
+//
+// Names that would exceed a resource's limit are truncated and suffixed
+// with a hash of the full name, so they remain unique and stable between
+// deployments.
+//
+//	n := naming.New(naming.Org("acme"), naming.Project(ctx.Project()), naming.Env(ctx.Stack()))
+//	s3.NewBucket(ctx, "orders", &s3.BucketArgs{
+//		Bucket: pulumi.String(n.NameFor(naming.S3Bucket, "orders")),
+//	})
+//	iam.NewRole(ctx, "worker", &iam.RoleArgs{
+//		Name: n.Output(naming.IAMRole, cluster.Name, "worker"),
+//	})
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// DefaultSeparator joins the components of a name unless the Separator
+// option is supplied.
+const DefaultSeparator = "-"
+
+// hashLength is the number of hex characters of the name's hash appended
+// to truncated names.
+const hashLength = 8
+
+// Limit describes the constraints on the name of a type of resource.  The
+// zero Limit imposes no constraints.
+type Limit struct {
+	// MaxLength is the maximum length of the name, or zero for no limit.
+	MaxLength int
+
+	// Lowercase causes the name to be converted to lower case.
+	Lowercase bool
+}
+
+// Limits for commonly named AWS resources.
+var (
+	S3Bucket       = Limit{MaxLength: 63, Lowercase: true}
+	IAMRole        = Limit{MaxLength: 64}
+	IAMUser        = Limit{MaxLength: 64}
+	IAMPolicy      = Limit{MaxLength: 128}
+	LoadBalancer   = Limit{MaxLength: 32}
+	TargetGroup    = Limit{MaxLength: 32}
+	LambdaFunction = Limit{MaxLength: 64}
+	SQSQueue       = Limit{MaxLength: 80}
+	SNSTopic       = Limit{MaxLength: 256}
+	ECRRepository  = Limit{MaxLength: 256, Lowercase: true}
+	RDSInstance    = Limit{MaxLength: 63, Lowercase: true}
+)
+
+// Namer generates names prefixed with a fixed set of components, such as
+// the organization, project and environment.
+type Namer struct {
+	prefix []string
+	sep    string
+}
+
+// Opt is implemented by functions that can be passed to New.
+type Opt func(*Namer)
+
+// New creates a Namer.  Prefix components are added in the order the
+// options are supplied; empty components are skipped.
+func New(opts ...Opt) *Namer {
+	n := &Namer{sep: DefaultSeparator}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Prefix adds components to the start of every name.
+func Prefix(components ...string) Opt {
+	return func(n *Namer) {
+		for _, c := range components {
+			if c != "" {
+				n.prefix = append(n.prefix, c)
+			}
+		}
+	}
+}
+
+// Org adds the organization to the prefix of every name.
+func Org(org string) Opt { return Prefix(org) }
+
+// Project adds the project to the prefix of every name.
+func Project(project string) Opt { return Prefix(project) }
+
+// Env adds the environment, such as the stack name, to the prefix of
+// every name.
+func Env(env string) Opt { return Prefix(env) }
+
+// Separator sets the string used to join the components of a name.
+func Separator(sep string) Opt {
+	return func(n *Namer) {
+		n.sep = sep
+	}
+}
+
+// Name returns the prefix joined with parts, without any limit applied.
+func (n *Namer) Name(parts ...string) string {
+	return n.NameFor(Limit{}, parts...)
+}
+
+// NameFor returns the prefix joined with parts, conforming to limit.
+func (n *Namer) NameFor(limit Limit, parts ...string) string {
+	components := append([]string{}, n.prefix...)
+	for _, p := range parts {
+		if p != "" {
+			components = append(components, p)
+		}
+	}
+	return limit.apply(strings.Join(components, n.sep), n.sep)
+}
+
+// Output is equivalent to NameFor, but parts may be strings or
+// StringInputs, such as the name of another resource.
+func (n *Namer) Output(limit Limit, parts ...interface{}) pulumi.StringOutput {
+	inputs := make([]interface{}, len(parts))
+	for i, p := range parts {
+		switch v := p.(type) {
+		case string, pulumi.StringInput:
+			inputs[i] = v
+		default:
+			panic(fmt.Sprintf("unexpected type passed to Output: %T: %#v", p, p))
+		}
+	}
+	return pulumi.All(inputs...).ApplyT(func(args []interface{}) string {
+		strs := make([]string, len(args))
+		for i, s := range args {
+			strs[i] = s.(string)
+		}
+		return n.NameFor(limit, strs...)
+	}).(pulumi.StringOutput)
+}
+
+// apply converts name to conform to the limit.  Names that are too long
+// are truncated and suffixed with a hash of the full name.
+func (l Limit) apply(name, sep string) string {
+	if l.Lowercase {
+		name = strings.ToLower(name)
+	}
+	if l.MaxLength <= 0 || len(name) <= l.MaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+	keep := l.MaxLength - len(sep) - len(hash)
+	if keep <= 0 {
+		if l.MaxLength < len(hash) {
+			return hash[:l.MaxLength]
+		}
+		return hash
+	}
+	// avoid leaving a dangling separator before the hash
+	head := strings.TrimRight(name[:keep], sep)
+	return head + sep + hash
+}
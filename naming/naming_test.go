@@ -0,0 +1,87 @@
+package naming
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestName(t *testing.T) {
+	assert := assert.New(t)
+	n := New(Org("acme"), Project("shop"), Env(""), Env("prod"))
+	assert.Equal("acme-shop-prod-orders-db", n.Name("orders", "", "db"))
+	assert.Equal("acme-shop-prod", n.Name())
+	assert.Equal("acme.shop", New(Prefix("acme", "shop"), Separator(".")).Name())
+}
+
+func TestNameFor(t *testing.T) {
+	n := New(Org("Acme"), Project("shop"), Env("prod"))
+	long := strings.Repeat("x", 40)
+
+	tests := []struct {
+		name     string
+		limit    Limit
+		parts    []string
+		expected string
+	}{
+		{name: "fits", limit: IAMRole, parts: []string{"Worker"}, expected: "Acme-shop-prod-Worker"},
+		{name: "lowercase", limit: S3Bucket, parts: []string{"Logs"}, expected: "acme-shop-prod-logs"},
+		{name: "unlimited", limit: Limit{}, parts: []string{long}, expected: "Acme-shop-prod-" + long},
+		{name: "exact", limit: Limit{MaxLength: 19}, parts: []string{"abcd"}, expected: "Acme-shop-prod-abcd"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, n.NameFor(test.limit, test.parts...), test.name)
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	assert := assert.New(t)
+	n := New(Org("acme"), Project("shop"), Env("prod"))
+
+	a := n.NameFor(LoadBalancer, "internal-api-gateway-a")
+	b := n.NameFor(LoadBalancer, "internal-api-gateway-b")
+	assert.Len(a, LoadBalancer.MaxLength)
+	assert.True(strings.HasPrefix(a, "acme-shop-prod-internal-"), a)
+	assert.NotEqual(a, b, "names differing after the limit should remain unique")
+	assert.Equal(a, n.NameFor(LoadBalancer, "internal-api-gateway-a"), "truncation should be deterministic")
+
+	// the separator is not repeated if the cut falls on one
+	cut := New().NameFor(Limit{MaxLength: 14}, "abcd", "efgh")
+	assert.Equal("abcd-", cut[:5])
+	assert.NotContains(cut, "--")
+
+	assert.Len(New().NameFor(Limit{MaxLength: 4}, "abcdefgh"), 4)
+}
+
+func TestOutput(t *testing.T) {
+	assert := assert.New(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		n := New(Org("acme"), Env("prod"))
+		n.Output(S3Bucket, pulumi.String("Cluster").ToStringOutput(), "logs").ApplyT(func(s string) string {
+			defer wg.Done()
+			assert.Equal("acme-prod-cluster-logs", s)
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(err)
+
+	assert.Panics(func() { New().Output(Limit{}, 42) })
+}
@@ -0,0 +1,506 @@
+// Package openapi renders OpenAPI documents for use as the Body of an API
+// Gateway REST API, injecting integration URIs, authorizer ARNs and other
+// values from Pulumi outputs at JSONPath locations within the document,
+// rather than splicing them in with string templates.
+//
+// The document may be supplied as JSON or YAML; it's rendered as JSON.
+//
+//	spec, err := openapi.ParseFile("api.yaml",
+//		openapi.LambdaIntegration("/pets", "GET", listPets.InvokeArn),
+//		openapi.LambdaIntegration("/pets/{id}", "ANY", petHandler.InvokeArn),
+//		openapi.AuthorizerURI("token", authorizer.InvokeArn),
+//		openapi.Set("$.info.version", version),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	apigateway.NewRestApi(ctx, "pets", &apigateway.RestApiArgs{
+//		Body: spec.ToStringOutput(),
+//	})
+//
+// The rendered document is checked to ensure that each integration has a
+// URI and that operations only reference security schemes that are
+// defined.
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"gopkg.in/yaml.v3"
+)
+
+// Package errors returned while parsing and rendering documents.
+var (
+	ErrInvalidSpec = errors.New("invalid OpenAPI document")
+	ErrInvalidPath = errors.New("invalid JSONPath")
+)
+
+// Extension names used by API Gateway.
+const (
+	IntegrationExtension = "x-amazon-apigateway-integration"
+	AuthorizerExtension  = "x-amazon-apigateway-authorizer"
+	AnyMethodExtension   = "x-amazon-apigateway-any-method"
+)
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace", AnyMethodExtension}
+
+// Spec holds a parsed OpenAPI document and the values to be injected into
+// it.
+type Spec struct {
+	doc        map[string]interface{}
+	injections []injection
+	err        error
+}
+
+type injection struct {
+	path  string
+	segs  []interface{}
+	value interface{}
+}
+
+// Opt is implemented by functions that can be passed to Parse.
+type Opt func(*Spec)
+
+// Parse parses an OpenAPI 3 or Swagger 2 document from JSON or YAML text.
+func Parse(text string, opts ...Opt) (*Spec, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal([]byte(text), &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	doc, ok := normalize(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: document is not an object", ErrInvalidSpec)
+	}
+	if doc["openapi"] == nil && doc["swagger"] == nil {
+		return nil, fmt.Errorf("%w: missing openapi or swagger version field", ErrInvalidSpec)
+	}
+	if _, ok := doc["paths"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%w: missing paths object", ErrInvalidSpec)
+	}
+	s := &Spec{doc: doc}
+	for _, opt := range opts {
+		opt(s)
+		if s.err != nil {
+			return nil, s.err
+		}
+	}
+	return s, nil
+}
+
+// ParseFile parses an OpenAPI document from a JSON or YAML file.
+func ParseFile(filename string, opts ...Opt) (*Spec, error) {
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(text), opts...)
+}
+
+// Set sets the value at path, a JSONPath expression such as
+// "$.paths['/pets'].get.x-amazon-apigateway-integration.uri", creating
+// any missing objects along the way.  value may be a string, string slice,
+// StringInput or StringArrayInput.
+func Set(path string, value interface{}) Opt {
+	return func(s *Spec) {
+		segs, err := parsePath(path)
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.set(path, segs, value)
+	}
+}
+
+// LambdaIntegration integrates the operation for method on path with a
+// Lambda function using proxy integration.  invokeARN is the function's
+// InvokeArn.  method may be "ANY" to handle all methods.
+func LambdaIntegration(path, method string, invokeARN interface{}) Opt {
+	return integration(path, method, "aws_proxy", "POST", invokeARN)
+}
+
+// HTTPProxyIntegration integrates the operation for method on path with
+// an HTTP endpoint, passing the request through to uri.
+func HTTPProxyIntegration(path, method string, uri interface{}) Opt {
+	return integration(path, method, "http_proxy", strings.ToUpper(method), uri)
+}
+
+func integration(path, method, typ, httpMethod string, uri interface{}) Opt {
+	return func(s *Spec) {
+		key := methodKey(method)
+		paths := s.doc["paths"].(map[string]interface{})
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil || (item[key] == nil && key != AnyMethodExtension) {
+			s.err = fmt.Errorf("%w: no operation %s %s", ErrInvalidSpec, strings.ToUpper(method), path)
+			return
+		}
+		segs := []interface{}{"paths", path, key, IntegrationExtension}
+		for _, field := range []struct {
+			name  string
+			value interface{}
+		}{{"type", typ}, {"httpMethod", httpMethod}, {"uri", uri}} {
+			fieldSegs := append(segs[:len(segs):len(segs)], field.name)
+			s.set(formatPath(fieldSegs), fieldSegs, field.value)
+		}
+	}
+}
+
+// AuthorizerURI sets the URI of the Lambda authorizer defined by the
+// named security scheme.  uri is the authorizer function's InvokeArn.
+func AuthorizerURI(name string, uri interface{}) Opt {
+	return authorizer(name, "authorizerUri", uri)
+}
+
+// AuthorizerCredentials sets the ARN of the role API Gateway assumes to
+// invoke the authorizer defined by the named security scheme.
+func AuthorizerCredentials(name string, roleARN interface{}) Opt {
+	return authorizer(name, "authorizerCredentials", roleARN)
+}
+
+// AuthorizerProviderARNs sets the ARNs of the Cognito user pools used by
+// the authorizer defined by the named security scheme.  arns may be a
+// string slice or StringArrayInput.
+func AuthorizerProviderARNs(name string, arns interface{}) Opt {
+	return authorizer(name, "providerARNs", arns)
+}
+
+func authorizer(name, field string, value interface{}) Opt {
+	return func(s *Spec) {
+		segs := s.schemePath(name)
+		if _, err := lookup(s.doc, segs); err != nil {
+			s.err = fmt.Errorf("%w: no security scheme named %q", ErrInvalidSpec, name)
+			return
+		}
+		segs = append(segs, AuthorizerExtension, field)
+		s.set(formatPath(segs), segs, value)
+	}
+}
+
+// schemePath returns the location of the named security scheme, which
+// depends on the version of the document.
+func (s *Spec) schemePath(name string) []interface{} {
+	if s.doc["swagger"] != nil {
+		return []interface{}{"securityDefinitions", name}
+	}
+	return []interface{}{"components", "securitySchemes", name}
+}
+
+func (s *Spec) set(path string, segs []interface{}, value interface{}) {
+	switch v := value.(type) {
+	case string, pulumi.StringInput, pulumi.StringArrayInput:
+	case []string:
+		value = pulumi.ToStringArray(v)
+	default:
+		panic(fmt.Sprintf("unexpected type passed to Set: %T: %#v", value, value))
+	}
+	s.injections = append(s.injections, injection{path: path, segs: append([]interface{}(nil), segs...), value: value})
+}
+
+// ToStringOutput renders the document as JSON once all injected values
+// have resolved.  The output resolves to an error wrapping ErrInvalidSpec
+// if the rendered document fails validation.
+func (s *Spec) ToStringOutput() pulumi.StringOutput {
+	return s.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext renders the document as JSON once all injected
+// values have resolved.
+func (s *Spec) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	values := make([]interface{}, len(s.injections))
+	for i, inj := range s.injections {
+		values[i] = inj.value
+	}
+	return pulumi.AllWithContext(ctx, values...).ApplyTWithContext(ctx, func(_ context.Context, resolved []interface{}) (string, error) {
+		doc := deepCopy(s.doc).(map[string]interface{})
+		for i, inj := range s.injections {
+			value := resolved[i]
+			if strs, ok := value.([]string); ok {
+				items := make([]interface{}, len(strs))
+				for j, str := range strs {
+					items[j] = str
+				}
+				value = items
+			}
+			if err := assign(doc, inj.segs, value); err != nil {
+				return "", fmt.Errorf("%s: %w", inj.path, err)
+			}
+		}
+		if err := validate(doc); err != nil {
+			return "", err
+		}
+		js, err := json.Marshal(doc)
+		return string(js), err
+	}).(pulumi.StringOutput)
+}
+
+// validate checks that integrations are complete and that security
+// requirements reference defined schemes.
+func validate(doc map[string]interface{}) error {
+	schemes, _ := doc["securityDefinitions"].(map[string]interface{})
+	if doc["openapi"] != nil {
+		schemes, _ = lookupMap(doc, "components", "securitySchemes")
+	}
+	if err := checkSecurity(doc["security"], schemes, "document"); err != nil {
+		return err
+	}
+
+	paths := doc["paths"].(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w: path %s is not an object", ErrInvalidSpec, path)
+		}
+		for _, method := range methods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name := fmt.Sprintf("operation %s %s", strings.ToUpper(method), path)
+			if integ, ok := op[IntegrationExtension].(map[string]interface{}); ok {
+				typ, _ := integ["type"].(string)
+				if typ == "" {
+					return fmt.Errorf("%w: %s integration has no type", ErrInvalidSpec, name)
+				}
+				if uri, _ := integ["uri"].(string); uri == "" && !strings.EqualFold(typ, "mock") {
+					return fmt.Errorf("%w: %s integration has no uri", ErrInvalidSpec, name)
+				}
+			}
+			if err := checkSecurity(op["security"], schemes, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(schemes) {
+		scheme, _ := schemes[name].(map[string]interface{})
+		auth, ok := scheme[AuthorizerExtension].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if typ, _ := auth["type"].(string); strings.EqualFold(typ, "cognito_user_pools") {
+			if arns, _ := auth["providerARNs"].([]interface{}); len(arns) == 0 {
+				return fmt.Errorf("%w: authorizer %s has no providerARNs", ErrInvalidSpec, name)
+			}
+		} else if uri, _ := auth["authorizerUri"].(string); uri == "" {
+			return fmt.Errorf("%w: authorizer %s has no authorizerUri", ErrInvalidSpec, name)
+		}
+	}
+	return nil
+}
+
+func checkSecurity(security interface{}, schemes map[string]interface{}, name string) error {
+	reqs, _ := security.([]interface{})
+	for _, r := range reqs {
+		req, ok := r.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w: %s has an invalid security requirement %v", ErrInvalidSpec, name, r)
+		}
+		for _, scheme := range sortedKeys(req) {
+			if _, ok := schemes[scheme]; !ok {
+				return fmt.Errorf("%w: %s references undefined security scheme %q", ErrInvalidSpec, name, scheme)
+			}
+		}
+	}
+	return nil
+}
+
+func methodKey(method string) string {
+	if strings.EqualFold(method, "ANY") {
+		return AnyMethodExtension
+	}
+	return strings.ToLower(method)
+}
+
+// parsePath parses a JSONPath expression consisting of dotted names,
+// quoted names in brackets and array indexes, eg. $.a['b/c'][0].d
+func parsePath(path string) ([]interface{}, error) {
+	p := strings.TrimPrefix(path, "$")
+	var segs []interface{}
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			end := strings.IndexAny(p[1:], ".[")
+			if end < 0 {
+				end = len(p) - 1
+			}
+			name := p[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("%w: empty name in %q", ErrInvalidPath, path)
+			}
+			segs = append(segs, name)
+			p = p[end+1:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if len(p) > 1 && (p[1] == '\'' || p[1] == '"') {
+				quote := p[1]
+				close := strings.IndexByte(p[2:], quote)
+				if close < 0 || len(p) < close+4 || p[close+3] != ']' {
+					return nil, fmt.Errorf("%w: unterminated name in %q", ErrInvalidPath, path)
+				}
+				segs = append(segs, p[2:close+2])
+				p = p[close+4:]
+				continue
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated index in %q", ErrInvalidPath, path)
+			}
+			idx, err := strconv.Atoi(p[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("%w: invalid index in %q", ErrInvalidPath, path)
+			}
+			segs = append(segs, idx)
+			p = p[end+1:]
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q in %q", ErrInvalidPath, p[0], path)
+		}
+	}
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("%w: %q selects the whole document", ErrInvalidPath, path)
+	}
+	return segs, nil
+}
+
+// formatPath formats path segments as a JSONPath expression.
+func formatPath(segs []interface{}) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range segs {
+		switch seg := seg.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", seg)
+		case string:
+			if strings.ContainsAny(seg, ".[]'\"/{}") {
+				fmt.Fprintf(&b, "[%q]", seg)
+			} else {
+				b.WriteString("." + seg)
+			}
+		}
+	}
+	return b.String()
+}
+
+// assign sets the value at segs, creating intermediate objects as needed.
+func assign(doc interface{}, segs []interface{}, value interface{}) error {
+	cur := doc
+	for i, seg := range segs {
+		last := i == len(segs)-1
+		switch seg := seg.(type) {
+		case string:
+			m, ok := cur.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%w: %s is not an object", ErrInvalidPath, formatPath(segs[:i]))
+			}
+			if last {
+				m[seg] = value
+				return nil
+			}
+			if m[seg] == nil {
+				m[seg] = make(map[string]interface{})
+			}
+			cur = m[seg]
+		case int:
+			a, ok := cur.([]interface{})
+			if !ok || seg >= len(a) {
+				return fmt.Errorf("%w: %s has no element %d", ErrInvalidPath, formatPath(segs[:i]), seg)
+			}
+			if last {
+				a[seg] = value
+				return nil
+			}
+			cur = a[seg]
+		}
+	}
+	return nil
+}
+
+func lookup(doc interface{}, segs []interface{}) (interface{}, error) {
+	cur := doc
+	for i, seg := range segs {
+		var ok bool
+		switch seg := seg.(type) {
+		case string:
+			var m map[string]interface{}
+			if m, ok = cur.(map[string]interface{}); ok {
+				cur, ok = m[seg]
+			}
+		case int:
+			var a []interface{}
+			if a, ok = cur.([]interface{}); ok && seg < len(a) {
+				cur = a[seg]
+			} else {
+				ok = false
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %s not found", ErrInvalidPath, formatPath(segs[:i+1]))
+		}
+	}
+	return cur, nil
+}
+
+func lookupMap(doc map[string]interface{}, segs ...interface{}) (map[string]interface{}, bool) {
+	v, err := lookup(doc, segs)
+	if err != nil {
+		return nil, false
+	}
+	m, ok := v.(map[string]interface{})
+	return m, ok
+}
+
+// normalize converts the maps produced by the YAML decoder, whose keys
+// may be numbers, eg. response codes, to maps with string keys.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalize(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+		return v
+	}
+	return v
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = deepCopy(e)
+		}
+		return a
+	}
+	return v
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
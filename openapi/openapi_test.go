@@ -0,0 +1,209 @@
+package openapi
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+const petsYAML = `
+openapi: "3.0.1"
+info:
+  title: pets
+  version: "1"
+paths:
+  /pets:
+    get:
+      security:
+        - token: []
+      responses:
+        200:
+          description: ok
+  /pets/{id}:
+    get:
+      responses:
+        200:
+          description: ok
+components:
+  securitySchemes:
+    token:
+      type: apiKey
+      name: Authorization
+      in: header
+      x-amazon-apigateway-authtype: custom
+      x-amazon-apigateway-authorizer:
+        type: token
+`
+
+func TestToStringOutput(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		spec, err := Parse(petsYAML,
+			LambdaIntegration("/pets", "GET", pulumi.String("arn:list").ToStringOutput()),
+			LambdaIntegration("/pets/{id}", "ANY", pulumi.String("arn:pet").ToStringOutput()),
+			AuthorizerURI("token", pulumi.String("arn:auth").ToStringOutput()),
+			Set("$.info.version", pulumi.String("2").ToStringOutput()),
+			Set("$.paths['/pets'].get.tags", []string{"pets"}),
+		)
+		assert.NoError(t, err)
+		expected := `{
+			"openapi": "3.0.1",
+			"info": {"title": "pets", "version": "2"},
+			"paths": {
+				"/pets": {
+					"get": {
+						"security": [{"token": []}],
+						"responses": {"200": {"description": "ok"}},
+						"tags": ["pets"],
+						"x-amazon-apigateway-integration": {"type": "aws_proxy", "httpMethod": "POST", "uri": "arn:list"}
+					}
+				},
+				"/pets/{id}": {
+					"get": {"responses": {"200": {"description": "ok"}}},
+					"x-amazon-apigateway-any-method": {
+						"x-amazon-apigateway-integration": {"type": "aws_proxy", "httpMethod": "POST", "uri": "arn:pet"}
+					}
+				}
+			},
+			"components": {
+				"securitySchemes": {
+					"token": {
+						"type": "apiKey",
+						"name": "Authorization",
+						"in": "header",
+						"x-amazon-apigateway-authtype": "custom",
+						"x-amazon-apigateway-authorizer": {"type": "token", "authorizerUri": "arn:auth"}
+					}
+				}
+			}
+		}`
+		assert.JSONEq(t, expected, testutil.AwaitOutput[string](t, spec.ToStringOutput()))
+		return nil
+	})
+}
+
+func TestSwagger(t *testing.T) {
+	doc := `{
+		"swagger": "2.0",
+		"paths": {"/": {"post": {}}},
+		"securityDefinitions": {
+			"cognito": {"x-amazon-apigateway-authorizer": {"type": "cognito_user_pools"}}
+		}
+	}`
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		spec, err := Parse(doc,
+			HTTPProxyIntegration("/", "post", "https://example.com/"),
+			AuthorizerProviderARNs("cognito", pulumi.ToStringArray([]string{"arn:pool"}).ToStringArrayOutput()),
+		)
+		assert.NoError(t, err)
+		expected := `{
+			"swagger": "2.0",
+			"paths": {"/": {"post": {
+				"x-amazon-apigateway-integration": {"type": "http_proxy", "httpMethod": "POST", "uri": "https://example.com/"}
+			}}},
+			"securityDefinitions": {
+				"cognito": {"x-amazon-apigateway-authorizer": {"type": "cognito_user_pools", "providerARNs": ["arn:pool"]}}
+			}
+		}`
+		assert.JSONEq(t, expected, testutil.AwaitOutput[string](t, spec.ToStringOutput()))
+		return nil
+	})
+}
+
+func TestParseFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "api.yaml")
+	assert.NoError(t, os.WriteFile(fn, []byte(petsYAML), 0o644))
+	_, err := ParseFile(fn)
+	assert.NoError(t, err)
+	_, err = ParseFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		opts     []Opt
+		expected error
+	}{
+		{"not-object", `[1]`, nil, ErrInvalidSpec},
+		{"no-version", `{"paths": {}}`, nil, ErrInvalidSpec},
+		{"no-paths", `{"openapi": "3.0.1"}`, nil, ErrInvalidSpec},
+		{"bad-syntax", `{`, nil, ErrInvalidSpec},
+		{"no-operation", petsYAML, []Opt{LambdaIntegration("/pets", "POST", "arn")}, ErrInvalidSpec},
+		{"no-path", petsYAML, []Opt{LambdaIntegration("/cats", "GET", "arn")}, ErrInvalidSpec},
+		{"no-scheme", petsYAML, []Opt{AuthorizerURI("missing", "arn")}, ErrInvalidSpec},
+		{"bad-path", petsYAML, []Opt{Set("$.info[", "x")}, ErrInvalidPath},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.doc, test.opts...)
+			assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+		})
+	}
+	assert.Panics(t, func() { Parse(petsYAML, Set("$.info.version", 2)) })
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Opt
+		expected error
+	}{
+		{"missing-authorizer-uri", []Opt{LambdaIntegration("/pets", "GET", "arn")}, ErrInvalidSpec},
+		{"empty-uri", []Opt{
+			LambdaIntegration("/pets", "GET", pulumi.String("").ToStringOutput()),
+			AuthorizerURI("token", "arn"),
+		}, ErrInvalidSpec},
+		{"invalid-requirement", []Opt{
+			AuthorizerURI("token", "arn"),
+			Set("$.paths['/pets/{id}'].get.security", []string{"x"}),
+		}, ErrInvalidSpec},
+		{"not-object", []Opt{
+			AuthorizerURI("token", "arn"),
+			Set("$.info.title.x", "y"),
+		}, ErrInvalidPath},
+		{"valid", []Opt{AuthorizerURI("token", "arn")}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.Run(t, func(ctx *pulumi.Context) error {
+				spec, err := Parse(petsYAML, test.opts...)
+				assert.NoError(t, err)
+				err = testutil.AwaitErr(t, spec.ToStringOutput())
+				if test.expected == nil {
+					assert.NoError(t, err)
+				} else {
+					assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestUndefinedScheme(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		spec, err := Parse(`{"openapi": "3.0.1", "paths": {"/": {"get": {"security": [{"missing": []}]}}}}`)
+		assert.NoError(t, err)
+		err = testutil.AwaitErr(t, spec.ToStringOutput())
+		assert.True(t, errors.Is(err, ErrInvalidSpec), "unexpected error %v", err)
+		return nil
+	})
+}
+
+func TestParsePath(t *testing.T) {
+	segs, err := parsePath(`$.paths['/pets/{id}']["get"].tags[1]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"paths", "/pets/{id}", "get", "tags", 1}, segs)
+	assert.Equal(t, `$.paths["/pets/{id}"].get.tags[1]`, formatPath(segs))
+
+	for _, bad := range []string{"$", "$..a", "$.a[x]", "$.a['b", "a"} {
+		_, err := parsePath(bad)
+		assert.True(t, errors.Is(err, ErrInvalidPath), "expected error for %q", bad)
+	}
+}
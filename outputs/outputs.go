@@ -0,0 +1,172 @@
+// Package outputs provides type safe helpers for combining Pulumi inputs
+// and outputs, avoiding the interface{} assertions and index arithmetic
+// required by pulumi.All.
+//
+//	url := outputs.Apply2(lb.DnsName, listener.Port, func(host string, port int) string {
+//		return fmt.Sprintf("https://%s:%d", host, port)
+//	}).(pulumi.StringOutput)
+//
+// Each helper returns the output type registered for its result, such as
+// a StringOutput for a string, or an AnyOutput for types without one.
+// Inputs are resolved using pulumi.All, so they may be outputs, input
+// types such as pulumi.String or plain values; each helper panics if a
+// resolved value doesn't have the type expected by its callback.
+package outputs
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Pair holds the values of two inputs combined by Zip.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// as asserts v is a T, panicking with a descriptive error if it isn't.
+func as[T any](v interface{}) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	t, ok := v.(T)
+	if !ok {
+		var zero T
+		panic(fmt.Sprintf("outputs: resolved value has type %T, expected %T", v, zero))
+	}
+	return t
+}
+
+// Apply calls f with the resolved value of in.
+func Apply[T, U any](in interface{}, f func(T) U) pulumi.Output {
+	return pulumi.All(in).ApplyT(func(args []interface{}) U {
+		return f(as[T](args[0]))
+	})
+}
+
+// ApplyErr calls f with the resolved value of in.  If f returns an error,
+// the returned output resolves to that error.
+func ApplyErr[T, U any](in interface{}, f func(T) (U, error)) pulumi.Output {
+	return pulumi.All(in).ApplyT(func(args []interface{}) (U, error) {
+		return f(as[T](args[0]))
+	})
+}
+
+// Apply2 calls f with the resolved values of a and b, which may have
+// different types.
+func Apply2[A, B, R any](a, b interface{}, f func(A, B) R) pulumi.Output {
+	return pulumi.All(a, b).ApplyT(func(args []interface{}) R {
+		return f(as[A](args[0]), as[B](args[1]))
+	})
+}
+
+// Apply3 calls f with the resolved values of a, b and c.
+func Apply3[A, B, C, R any](a, b, c interface{}, f func(A, B, C) R) pulumi.Output {
+	return pulumi.All(a, b, c).ApplyT(func(args []interface{}) R {
+		return f(as[A](args[0]), as[B](args[1]), as[C](args[2]))
+	})
+}
+
+// Apply4 calls f with the resolved values of a, b, c and d.
+func Apply4[A, B, C, D, R any](a, b, c, d interface{}, f func(A, B, C, D) R) pulumi.Output {
+	return pulumi.All(a, b, c, d).ApplyT(func(args []interface{}) R {
+		return f(as[A](args[0]), as[B](args[1]), as[C](args[2]), as[D](args[3]))
+	})
+}
+
+// All2 is an alias for Apply2.
+func All2[A, B, R any](a, b interface{}, f func(A, B) R) pulumi.Output {
+	return Apply2(a, b, f)
+}
+
+// All3 is an alias for Apply3.
+func All3[A, B, C, R any](a, b, c interface{}, f func(A, B, C) R) pulumi.Output {
+	return Apply3(a, b, c, f)
+}
+
+// Zip combines the resolved values of a and b into a Pair.  The result
+// is an AnyOutput.
+func Zip[A, B any](a, b interface{}) pulumi.AnyOutput {
+	return Apply2(a, b, func(a A, b B) Pair[A, B] {
+		return Pair[A, B]{First: a, Second: b}
+	}).(pulumi.AnyOutput)
+}
+
+// Coalesce resolves to the first of inputs whose value isn't the zero
+// value of T, or the zero value if there isn't one.
+func Coalesce[T comparable](inputs ...interface{}) pulumi.Output {
+	return pulumi.All(inputs...).ApplyT(func(args []interface{}) T {
+		var zero T
+		for _, arg := range args {
+			if v := as[T](arg); v != zero {
+				return v
+			}
+		}
+		return zero
+	})
+}
+
+// Default resolves to the value of in, or def if it's the zero value of T.
+func Default[T comparable](in interface{}, def T) pulumi.Output {
+	return Coalesce[T](in, def)
+}
+
+// MapSlice calls f for each element of the resolved slice in, such as a
+// StringArrayOutput, resolving to the slice of results.
+func MapSlice[T, U any](in interface{}, f func(T) U) pulumi.Output {
+	return Apply(in, func(values []T) []U {
+		result := make([]U, len(values))
+		for i, v := range values {
+			result[i] = f(v)
+		}
+		return result
+	})
+}
+
+// WaitAll returns an output that resolves to true once all of deps are
+// ready.  deps may be resources, which are ready once created, or inputs
+// and outputs, which are ready once resolved.  Resources passed to
+// WaitAll become dependencies of any resource the result is passed to.
+//
+// During a preview, the result is unknown until every resource exists.
+func WaitAll(deps ...interface{}) pulumi.BoolOutput {
+	return pulumi.All(readiness(deps)...).ApplyT(func([]interface{}) bool {
+		return true
+	}).(pulumi.BoolOutput)
+}
+
+// After resolves to the value of value once dep is ready, making dep a
+// dependency of any resource the result is passed to.  This can be used
+// where the DependsOn resource option doesn't fit, such as values that
+// are rendered into templates or passed through components.  dep may be
+// anything accepted by WaitAll.
+//
+//	name := outputs.After[string](policyAttachment, role.Name).(pulumi.StringOutput)
+func After[T any](dep, value interface{}) pulumi.Output {
+	return pulumi.All(append([]interface{}{value}, readiness([]interface{}{dep})...)...).ApplyT(func(args []interface{}) T {
+		return as[T](args[0])
+	})
+}
+
+// readiness maps resources to the outputs that resolve once they've been
+// created.
+func readiness(deps []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(deps))
+	for _, dep := range deps {
+		switch dep := dep.(type) {
+		case pulumi.CustomResource:
+			result = append(result, dep.ID())
+		case pulumi.Resource:
+			result = append(result, dep.URN())
+		case []pulumi.Resource:
+			for _, r := range dep {
+				result = append(result, readiness([]interface{}{r})...)
+			}
+		default:
+			result = append(result, dep)
+		}
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+package outputs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+// await runs f within a Pulumi program and returns the resolved values of
+// the outputs it returns.
+func await(t *testing.T, f func() []pulumi.Output) []interface{} {
+	var results []interface{}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		outs := f()
+		results = make([]interface{}, len(outs))
+		var wg sync.WaitGroup
+		wg.Add(len(outs))
+		for i, o := range outs {
+			i := i
+			o.ApplyT(func(v interface{}) interface{} {
+				defer wg.Done()
+				results[i] = v
+				return v
+			})
+		}
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	return results
+}
+
+func TestApply(t *testing.T) {
+	results := await(t, func() []pulumi.Output {
+		port := pulumi.Int(8080).ToIntOutput()
+		s := Apply(port, strconv.Itoa)
+		assert.IsType(t, pulumi.StringOutput{}, s)
+		return []pulumi.Output{
+			s,
+			Apply(pulumi.String("abc"), strings.ToUpper),
+			Apply(2, func(i int) int { return i * 2 }),
+		}
+	})
+	assert.Equal(t, []interface{}{"8080", "ABC", 4}, results)
+}
+
+func TestApplyErr(t *testing.T) {
+	errBad := errors.New("bad")
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		ctx.Export("result", ApplyErr(pulumi.String("x"), func(s string) (string, error) {
+			return "", errBad
+		}))
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.True(t, errors.Is(err, errBad), "unexpected error %v", err)
+}
+
+func TestAll(t *testing.T) {
+	results := await(t, func() []pulumi.Output {
+		return []pulumi.Output{
+			All2(pulumi.String("host").ToStringOutput(), pulumi.Int(443), func(host string, port int) string {
+				return host + ":" + strconv.Itoa(port)
+			}),
+			All3("a", pulumi.Bool(true).ToBoolOutput(), 1.5, func(a string, b bool, c float64) string {
+				return a + strconv.FormatBool(b) + strconv.FormatFloat(c, 'f', 1, 64)
+			}),
+		}
+	})
+	assert.Equal(t, []interface{}{"host:443", "atrue1.5"}, results)
+}
+
+func TestApplyN(t *testing.T) {
+	results := await(t, func() []pulumi.Output {
+		host := pulumi.String("host").ToStringOutput()
+		port := pulumi.Int(443).ToIntOutput()
+		return []pulumi.Output{
+			Apply2(host, port, func(host string, port int) string {
+				return host + ":" + strconv.Itoa(port)
+			}),
+			Apply3(host, port, pulumi.Bool(true), func(host string, port int, tls bool) bool {
+				return tls && port == 443
+			}),
+			Apply4(host, port, "/path", pulumi.StringArray{pulumi.String("a=1")}.ToStringArrayOutput(),
+				func(host string, port int, path string, query []string) string {
+					return host + ":" + strconv.Itoa(port) + path + "?" + strings.Join(query, "&")
+				}),
+		}
+	})
+	assert.Equal(t, []interface{}{"host:443", true, "host:443/path?a=1"}, results)
+}
+
+func TestZip(t *testing.T) {
+	results := await(t, func() []pulumi.Output {
+		return []pulumi.Output{Zip[string, int](pulumi.String("a").ToStringOutput(), 1)}
+	})
+	assert.Equal(t, []interface{}{Pair[string, int]{First: "a", Second: 1}}, results)
+}
+
+func TestCoalesce(t *testing.T) {
+	results := await(t, func() []pulumi.Output {
+		return []pulumi.Output{
+			Coalesce[string](pulumi.String("").ToStringOutput(), "", pulumi.String("second"), "third"),
+			Coalesce[int](0, pulumi.Int(0)),
+			Default(pulumi.String("").ToStringOutput(), "default"),
+			Default(pulumi.String("set").ToStringOutput(), "default"),
+		}
+	})
+	assert.Equal(t, []interface{}{"second", 0, "default", "set"}, results)
+}
+
+func TestMapSlice(t *testing.T) {
+	results := await(t, func() []pulumi.Output {
+		subnets := pulumi.StringArray{pulumi.String("a"), pulumi.String("b")}.ToStringArrayOutput()
+		return []pulumi.Output{
+			MapSlice(subnets, strings.ToUpper),
+			MapSlice([]int{1, 2}, func(i int) int { return i * 10 }),
+		}
+	})
+	assert.Equal(t, []interface{}{[]string{"A", "B"}, []int{10, 20}}, results)
+}
+
+func TestTypeMismatch(t *testing.T) {
+	assert.Panics(t, func() { as[string](42) })
+	assert.Equal(t, "", as[string](nil))
+}
+
+func TestWaitAll(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		bucket, err := s3.NewBucket(ctx, "bucket", nil)
+		if err != nil {
+			return err
+		}
+		assert.True(t, testutil.AwaitOutput[bool](t, WaitAll(bucket, pulumi.String("x").ToStringOutput(), "static")))
+		assert.True(t, testutil.AwaitOutput[bool](t, WaitAll([]pulumi.Resource{bucket})))
+		assert.True(t, testutil.AwaitOutput[bool](t, WaitAll()))
+		return nil
+	})
+}
+
+func TestAfter(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		bucket, err := s3.NewBucket(ctx, "bucket", nil)
+		if err != nil {
+			return err
+		}
+		name := After[string](bucket, pulumi.String("name").ToStringOutput())
+		assert.IsType(t, pulumi.StringOutput{}, name)
+		assert.Equal(t, "name", testutil.AwaitOutput[string](t, name))
+
+		port := After[int](WaitAll(bucket), 8080)
+		assert.Equal(t, 8080, testutil.AwaitOutput[int](t, port))
+		return nil
+	})
+}
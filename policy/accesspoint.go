@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// maxAccessPointSize is the maximum size of an S3 access point policy, in
+// characters.
+const maxAccessPointSize = 20480
+
+var accessPointKind = &kind{
+	name:     "S3 access point policy",
+	maxSize:  maxAccessPointSize,
+	validate: validateAccessPoint,
+}
+
+var accessPointArnRe = regexp.MustCompile(`^arn:[^:]+:s3:[^:]+:[0-9]{12}:accesspoint/[^/]+(/object/.+)?$`)
+
+// KindS3AccessPoint marks the policy as an S3 access point policy.
+//
+// In addition to the regular checks, Validate will ensure that every
+// statement names a Principal or NotPrincipal and that any Resource entries
+// that are plain strings are access point ARNs, either of the access point
+// itself or of objects accessed through it
+// (arn:aws:s3:region:account:accesspoint/name/object/key).  Resources
+// supplied as outputs are not checked.
+//
+// See https://docs.aws.amazon.com/AmazonS3/latest/userguide/access-points-policies.html
+func KindS3AccessPoint() Opt {
+	return func(p *Policy) {
+		p.kind = accessPointKind
+	}
+}
+
+func validateAccessPoint(p Policy) error {
+	for _, s := range p.Statement {
+		if len(s.Principal) == 0 && len(s.NotPrincipal) == 0 {
+			return s.withSource(fmt.Errorf("%w: no Principal or NotPrincipal specified for statement %q",
+				ErrInvalidStatement, s.Sid))
+		}
+		for _, r := range append(staticStrings(s.Resource), staticStrings(s.NotResource)...) {
+			if !accessPointArnRe.MatchString(r) {
+				return s.withSource(fmt.Errorf("%w: resource %q in statement %q is not an access point ARN",
+					ErrInvalidStatement, r, s.Sid))
+			}
+		}
+	}
+	return nil
+}
+
+// AccessPointObjects returns the resource ARN matching every object
+// accessed through the supplied access point.
+//
+// accessPointArn may be a string or a StringInput, such as the Arn output
+// of an s3.AccessPoint.
+func AccessPointObjects(accessPointArn interface{}) interface{} {
+	return withSuffix(accessPointArn, "/object/*")
+}
+
+// withSuffix appends suffix to v, which may be a string or StringInput.
+func withSuffix(v interface{}, suffix string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return v + suffix
+	case pulumi.StringInput:
+		return pulumi.Sprintf("%s%s", v, suffix)
+	default:
+		panic(fmt.Sprintf("unexpected type passed as an ARN: %T: %#v", v, v))
+	}
+}
+
+// DataAccessPointAccount adds a condition to a Statement that limits it to
+// requests made through access points owned by the supplied account.
+//
+// accountID may be a string or a StringInput.
+func DataAccessPointAccount(accountID interface{}) StatementOpt {
+	return Condition("StringEquals", "s3:DataAccessPointAccount", accountID)
+}
+
+// CalledVia adds a condition to a Statement that limits it to requests
+// made on the principal's behalf by one of the supplied services, such as
+// "s3-object-lambda.amazonaws.com".
+func CalledVia(service ...interface{}) StatementOpt {
+	return Condition("ForAnyValue:StringEquals", "aws:CalledVia", service...)
+}
+
+// DelegateToAccessPoints generates a bucket policy that delegates access
+// control for the bucket to the access points owned by accountID, which is
+// the pattern AWS recommends when access points are used to manage access
+// to a bucket.
+//
+// bucketArn and accountID may be strings or StringInputs.
+func DelegateToAccessPoints(id string, bucketArn, accountID interface{}) *Policy {
+	return New(id,
+		Statement("DelegateToAccessPoints",
+			Effect(Allow),
+			Principal("AWS", "*"),
+			Action("*"),
+			Resource(bucketArn, withSuffix(bucketArn, "/*")),
+			DataAccessPointAccount(accountID),
+		),
+	)
+}
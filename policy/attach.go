@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Attachment holds the resources created to attach a policy to a role,
+// user or group.
+type Attachment struct {
+	// Inline is the iam.RolePolicy, iam.UserPolicy or iam.GroupPolicy
+	// holding the policy, if it was attached inline.
+	Inline pulumi.CustomResource
+	// Policy is the managed policy holding the policy, if it was attached
+	// as a managed policy.
+	Policy *iam.Policy
+	// Attachment is the iam.RolePolicyAttachment, iam.UserPolicyAttachment
+	// or iam.GroupPolicyAttachment attaching Policy, if it was attached as
+	// a managed policy.
+	Attachment pulumi.CustomResource
+}
+
+type attachConfig struct {
+	managed bool
+	opts    []pulumi.ResourceOption
+}
+
+// AttachOpt is implemented by functions that can be passed to AttachToRole,
+// AttachToUser and AttachToGroup.
+type AttachOpt func(*attachConfig)
+
+// Managed creates the policy as a customer managed policy, with
+// NewManagedPolicy, and attaches it rather than creating an inline policy.
+func Managed() AttachOpt {
+	return func(cfg *attachConfig) {
+		cfg.managed = true
+	}
+}
+
+// ResourceOptions supplies options to apply to each resource created.
+func ResourceOptions(opts ...pulumi.ResourceOption) AttachOpt {
+	return func(cfg *attachConfig) {
+		cfg.opts = append(cfg.opts, opts...)
+	}
+}
+
+// AttachToRole creates an inline policy for role holding the policy, or a
+// managed policy and its attachment if the Managed option is supplied.
+// Each resource is given the supplied name.
+func (p Policy) AttachToRole(ctx *pulumi.Context, name string, role *iam.Role, opts ...AttachOpt) (*Attachment, error) {
+	return p.attach(ctx, name, opts,
+		func(doc pulumi.StringOutput, ropts []pulumi.ResourceOption) (pulumi.CustomResource, error) {
+			return iam.NewRolePolicy(ctx, name, &iam.RolePolicyArgs{Role: role.Name, Policy: doc}, ropts...)
+		},
+		func(arn pulumi.StringOutput, ropts []pulumi.ResourceOption) (pulumi.CustomResource, error) {
+			return iam.NewRolePolicyAttachment(ctx, name, &iam.RolePolicyAttachmentArgs{Role: role.Name, PolicyArn: arn}, ropts...)
+		},
+	)
+}
+
+// AttachToUser creates an inline policy for user holding the policy, or a
+// managed policy and its attachment if the Managed option is supplied.
+// Each resource is given the supplied name.
+func (p Policy) AttachToUser(ctx *pulumi.Context, name string, user *iam.User, opts ...AttachOpt) (*Attachment, error) {
+	return p.attach(ctx, name, opts,
+		func(doc pulumi.StringOutput, ropts []pulumi.ResourceOption) (pulumi.CustomResource, error) {
+			return iam.NewUserPolicy(ctx, name, &iam.UserPolicyArgs{User: user.Name, Policy: doc}, ropts...)
+		},
+		func(arn pulumi.StringOutput, ropts []pulumi.ResourceOption) (pulumi.CustomResource, error) {
+			return iam.NewUserPolicyAttachment(ctx, name, &iam.UserPolicyAttachmentArgs{User: user.Name, PolicyArn: arn}, ropts...)
+		},
+	)
+}
+
+// AttachToGroup creates an inline policy for group holding the policy, or
+// a managed policy and its attachment if the Managed option is supplied.
+// Each resource is given the supplied name.
+func (p Policy) AttachToGroup(ctx *pulumi.Context, name string, group *iam.Group, opts ...AttachOpt) (*Attachment, error) {
+	return p.attach(ctx, name, opts,
+		func(doc pulumi.StringOutput, ropts []pulumi.ResourceOption) (pulumi.CustomResource, error) {
+			return iam.NewGroupPolicy(ctx, name, &iam.GroupPolicyArgs{Group: group.Name, Policy: doc}, ropts...)
+		},
+		func(arn pulumi.StringOutput, ropts []pulumi.ResourceOption) (pulumi.CustomResource, error) {
+			return iam.NewGroupPolicyAttachment(ctx, name, &iam.GroupPolicyAttachmentArgs{Group: group.Name, PolicyArn: arn}, ropts...)
+		},
+	)
+}
+
+type createFunc func(v pulumi.StringOutput, opts []pulumi.ResourceOption) (pulumi.CustomResource, error)
+
+func (p Policy) attach(ctx *pulumi.Context, name string, opts []AttachOpt, inline, attachment createFunc) (*Attachment, error) {
+	var cfg attachConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if !cfg.managed {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		res, err := inline(p.ToStringOutput(), cfg.opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create inline policy %q: %w", name, err)
+		}
+		return &Attachment{Inline: res}, nil
+	}
+
+	mp, err := NewManagedPolicy(ctx, name, &p, cfg.opts...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := attachment(mp.Arn, cfg.opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach managed policy %q: %w", name, err)
+	}
+	return &Attachment{Policy: mp, Attachment: res}, nil
+}
@@ -0,0 +1,204 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// AuditAccess lists the distinct principals, actions and resources named
+// by the statements of a policy with the same effect.  Principals are
+// prefixed with their type, eg. "AWS:arn:aws:iam::123456789012:root".
+type AuditAccess struct {
+	Principals    []string `json:"principals,omitempty"`
+	NotPrincipals []string `json:"notPrincipals,omitempty"`
+	Actions       []string `json:"actions,omitempty"`
+	NotActions    []string `json:"notActions,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	NotResources  []string `json:"notResources,omitempty"`
+}
+
+// AuditEntry summarizes the access described by a single policy.
+type AuditEntry struct {
+	PolicyID string      `json:"policyId"`
+	Allow    AuditAccess `json:"allow"`
+	Deny     AuditAccess `json:"deny"`
+}
+
+// AuditLog records the policies rendered while it's enabled, to produce a
+// consolidated report of the access they describe for security review.
+//
+//	audit := policy.EnableAudit()
+//	defer policy.DisableAudit()
+//	... build resources using policies ...
+//	audit.Export(ctx, "policyAudit")
+//
+// Policies are recorded when ToStringOutput or ToMapOutput is called.
+type AuditLog struct {
+	mu       sync.Mutex
+	policies []Policy
+}
+
+var (
+	auditMu     sync.Mutex
+	activeAudit *AuditLog
+)
+
+// EnableAudit starts recording every policy rendered by the package into a
+// new AuditLog, replacing any previously enabled log.
+func EnableAudit() *AuditLog {
+	a := &AuditLog{}
+	auditMu.Lock()
+	activeAudit = a
+	auditMu.Unlock()
+	return a
+}
+
+// DisableAudit stops recording policies.
+func DisableAudit() {
+	auditMu.Lock()
+	activeAudit = nil
+	auditMu.Unlock()
+}
+
+func recordAudit(p Policy) {
+	auditMu.Lock()
+	a := activeAudit
+	auditMu.Unlock()
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.policies = append(a.policies, p)
+	a.mu.Unlock()
+}
+
+// Policies returns the policies recorded so far, in the order they were
+// rendered.
+func (a *AuditLog) Policies() []Policy {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Policy(nil), a.policies...)
+}
+
+// Entries summarizes the recorded policies once all of their inputs have
+// resolved.  The output resolves to a []AuditEntry.
+func (a *AuditLog) Entries() pulumi.AnyOutput {
+	return a.resolved().ApplyT(func(resolved []interface{}) interface{} {
+		return auditEntries(resolved)
+	}).(pulumi.AnyOutput)
+}
+
+func (a *AuditLog) resolved() pulumi.ArrayOutput {
+	policies := a.Policies()
+	values := make([]interface{}, len(policies))
+	for i, p := range policies {
+		values[i] = p
+	}
+	return pulumi.All(values...)
+}
+
+func auditEntries(resolved []interface{}) []AuditEntry {
+	entries := make([]AuditEntry, len(resolved))
+	for i, r := range resolved {
+		entries[i] = r.(Policy).auditEntry()
+	}
+	return entries
+}
+
+// ToStringOutput renders the audit report as a JSON array of AuditEntry
+// values.
+func (a *AuditLog) ToStringOutput() pulumi.StringOutput {
+	return a.resolved().ApplyT(func(resolved []interface{}) (string, error) {
+		js, err := json.MarshalIndent(auditEntries(resolved), "", "    ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal audit report: %w", err)
+		}
+		return string(js), nil
+	}).(pulumi.StringOutput)
+}
+
+// Export exports the audit report from the stack under name.
+func (a *AuditLog) Export(ctx *pulumi.Context, name string) {
+	ctx.Export(name, a.ToStringOutput().ApplyT(func(js string) ([]interface{}, error) {
+		var v []interface{}
+		err := json.Unmarshal([]byte(js), &v)
+		return v, err
+	}).(pulumi.ArrayOutput))
+}
+
+// WriteFile writes the audit report as JSON to the local file path once
+// all of the recorded policies' inputs have resolved.  The returned output
+// resolves to path after the file has been written, or fails if it could
+// not be.
+func (a *AuditLog) WriteFile(path string) pulumi.StringOutput {
+	return a.ToStringOutput().ApplyT(func(js string) (string, error) {
+		if err := os.WriteFile(path, []byte(js+"\n"), 0o644); err != nil {
+			return "", fmt.Errorf("failed to write audit report: %w", err)
+		}
+		return path, nil
+	}).(pulumi.StringOutput)
+}
+
+func (p Policy) auditEntry() AuditEntry {
+	e := AuditEntry{PolicyID: p.ID}
+	allow, deny := newAuditSets(), newAuditSets()
+	for _, s := range p.Statement {
+		sets := allow
+		if s.Effect == Deny {
+			sets = deny
+		}
+		for pt, ids := range s.Principal {
+			for _, id := range staticStrings(ids) {
+				sets[0][pt+":"+id] = true
+			}
+		}
+		for pt, ids := range s.NotPrincipal {
+			for _, id := range staticStrings(ids) {
+				sets[1][pt+":"+id] = true
+			}
+		}
+		for i, el := range []Strings{s.Action, s.NotAction, s.Resource, s.NotResource} {
+			for _, v := range staticStrings(el) {
+				sets[i+2][v] = true
+			}
+		}
+	}
+	e.Allow = allow.access()
+	e.Deny = deny.access()
+	return e
+}
+
+// auditSets holds the principals, not principals, actions, not actions,
+// resources and not resources of an AuditAccess, in that order.
+type auditSets [6]map[string]bool
+
+func newAuditSets() auditSets {
+	var s auditSets
+	for i := range s {
+		s[i] = make(map[string]bool)
+	}
+	return s
+}
+
+func (s auditSets) access() AuditAccess {
+	lists := make([][]string, len(s))
+	for i, set := range s {
+		for v := range set {
+			lists[i] = append(lists[i], v)
+		}
+		sort.Strings(lists[i])
+	}
+	return AuditAccess{
+		Principals:    lists[0],
+		NotPrincipals: lists[1],
+		Actions:       lists[2],
+		NotActions:    lists[3],
+		Resources:     lists[4],
+		NotResources:  lists[5],
+	}
+}
@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+)
+
+// FromPolicyDocumentArgs builds a Policy from the arguments used with the
+// iam.GetPolicyDocument data source, allowing existing statement
+// definitions to be mixed with those built by this package.  Additional
+// options, such as further statements, may be supplied in opts.
+//
+// As with the data source, statements default to an Allow effect.  The
+// source and override document fields are not supported and will cause a
+// panic if set.
+func FromPolicyDocumentArgs(id string, args *iam.GetPolicyDocumentArgs, opts ...Opt) *Policy {
+	if args.OverrideJson != nil || args.SourceJson != nil ||
+		len(args.OverridePolicyDocuments) > 0 || len(args.SourcePolicyDocuments) > 0 {
+		panic(fmt.Sprintf("policy %q: source and override documents are not supported", id))
+	}
+	if args.PolicyId != nil && id == "" {
+		id = *args.PolicyId
+	}
+	stmts := make([]Opt, 0, len(args.Statements)+len(opts))
+	for _, s := range args.Statements {
+		stmts = append(stmts, FromPolicyDocumentStatement(s))
+	}
+	p := New(id, append(stmts, opts...)...)
+	if args.Version != nil {
+		p.Version = *args.Version
+	}
+	return p
+}
+
+// FromPolicyDocumentStatement converts a statement defined for use with
+// the iam.GetPolicyDocument data source into a Statement option for New.
+func FromPolicyDocumentStatement(s iam.GetPolicyDocumentStatement) Opt {
+	var sid string
+	if s.Sid != nil {
+		sid = *s.Sid
+	}
+	effect := Allow
+	if s.Effect != nil {
+		effect = EffectType(*s.Effect)
+	}
+
+	opts := []StatementOpt{Effect(effect)}
+	if len(s.Actions) > 0 {
+		opts = append(opts, Action(s.Actions))
+	}
+	if len(s.NotActions) > 0 {
+		opts = append(opts, NotAction(s.NotActions))
+	}
+	if len(s.Resources) > 0 {
+		opts = append(opts, Resource(s.Resources))
+	}
+	if len(s.NotResources) > 0 {
+		opts = append(opts, NotResource(s.NotResources))
+	}
+	for _, pr := range s.Principals {
+		opts = append(opts, Principal(pr.Type, pr.Identifiers))
+	}
+	for _, pr := range s.NotPrincipals {
+		opts = append(opts, NotPrincipal(pr.Type, pr.Identifiers))
+	}
+	for _, c := range s.Conditions {
+		c := c
+		opts = append(opts, func(s *Stmt) {
+			// multiple conditions may share a test and variable
+			existing := s.Condition[c.Test][c.Variable]
+			Condition(c.Test, c.Variable, append(existing, c.Values)...)(s)
+		})
+	}
+	return Statement(sid, opts...)
+}
+
+// ToPolicyDocumentArgs converts the policy into arguments for the
+// iam.GetPolicyDocument data source.  An error wrapping ErrUnresolved is
+// returned if the policy contains outputs.
+func (p Policy) ToPolicyDocumentArgs() (*iam.GetPolicyDocumentArgs, error) {
+	args := &iam.GetPolicyDocumentArgs{
+		Version: stringPtr(p.Version),
+	}
+	if p.ID != "" {
+		args.PolicyId = stringPtr(p.ID)
+	}
+	for _, s := range p.Statement {
+		st, err := s.ToPolicyDocumentStatement()
+		if err != nil {
+			return nil, fmt.Errorf("policy %q: %w", p.ID, err)
+		}
+		args.Statements = append(args.Statements, st)
+	}
+	return args, nil
+}
+
+// ToPolicyDocumentStatement converts the statement into the form used by
+// the iam.GetPolicyDocument data source.  An error wrapping ErrUnresolved
+// is returned if the statement contains outputs.
+func (s Stmt) ToPolicyDocumentStatement() (iam.GetPolicyDocumentStatement, error) {
+	if !s.isStatic() {
+		return iam.GetPolicyDocumentStatement{}, fmt.Errorf("%w: statement %q", ErrUnresolved, s.Sid)
+	}
+	st := iam.GetPolicyDocumentStatement{
+		Effect:       stringPtr(string(s.Effect)),
+		Actions:      staticStrings(s.Action),
+		NotActions:   staticStrings(s.NotAction),
+		Resources:    staticStrings(s.Resource),
+		NotResources: staticStrings(s.NotResource),
+	}
+	if s.Sid != "" {
+		st.Sid = stringPtr(s.Sid)
+	}
+	for _, pt := range sortedKeys(s.Principal) {
+		st.Principals = append(st.Principals, iam.GetPolicyDocumentStatementPrincipal{
+			Type:        pt,
+			Identifiers: staticStrings(s.Principal[pt]),
+		})
+	}
+	for _, pt := range sortedKeys(s.NotPrincipal) {
+		st.NotPrincipals = append(st.NotPrincipals, iam.GetPolicyDocumentStatementNotPrincipal{
+			Type:        pt,
+			Identifiers: staticStrings(s.NotPrincipal[pt]),
+		})
+	}
+	for _, op := range sortedKeys(s.Condition) {
+		for _, key := range sortedKeys(s.Condition[op]) {
+			st.Conditions = append(st.Conditions, iam.GetPolicyDocumentStatementCondition{
+				Test:     op,
+				Variable: key,
+				Values:   staticStrings(s.Condition[op][key]),
+			})
+		}
+	}
+	return st, nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+)
+
+// FromPolicyVersion builds a Policy from a policy version returned by the
+// AWS SDK's iam GetPolicyVersion or GetAccountAuthorizationDetails calls,
+// whose documents are URL encoded.
+func FromPolicyVersion(v *types.PolicyVersion) (*Policy, error) {
+	if v == nil || v.Document == nil {
+		return nil, fmt.Errorf("%w: policy version has no document", ErrInvalidPolicy)
+	}
+	return FromURLEncoded(*v.Document)
+}
+
+// FromURLEncoded builds a Policy from a URL encoded JSON policy document,
+// as returned by a number of IAM APIs.
+func FromURLEncoded(doc string) (*Policy, error) {
+	decoded, err := url.QueryUnescape(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode policy: %v", ErrInvalidPolicy, err)
+	}
+	return Parse(decoded)
+}
+
+// SmithyDocument is implemented by the document types used by AWS SDK
+// clients generated with smithy, such as document.Interface.
+type SmithyDocument interface {
+	UnmarshalSmithyDocument(v interface{}) error
+}
+
+// FromSmithyDocument builds a Policy from a smithy document type returned
+// by an AWS SDK client.
+func FromSmithyDocument(d SmithyDocument) (*Policy, error) {
+	var m map[string]interface{}
+	if err := d.UnmarshalSmithyDocument(&m); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode policy document: %v", ErrInvalidPolicy, err)
+	}
+	js, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to encode policy document: %v", ErrInvalidPolicy, err)
+	}
+	return Parse(string(js))
+}
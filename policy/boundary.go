@@ -0,0 +1,75 @@
+package policy
+
+import "fmt"
+
+// PermissionsBoundary generates a permissions boundary policy suitable for
+// attaching to delegated roles and users.
+//
+// The policy allows all actions for the supplied service prefixes (eg. "s3",
+// "dynamodb"), denies creating IAM roles and users, or changing their
+// boundaries or permissions, unless they have boundaryArn set as their own
+// permissions boundary, and denies any attempt to modify, detach or delete
+// the boundary policy itself.  Including "iam" in services therefore allows
+// principals to be managed only within the same boundary.
+//
+// boundaryArn should be the ARN of the managed policy the document will be
+// stored in and may be a string or a StringInput, such as the Arn output
+// of an iam.Policy.  As the ARN of the policy must be known before the
+// policy is created, it is usually built with pulumi.Sprintf from the
+// account ID and the policy name.
+//
+// Will panic if no services are supplied.
+func PermissionsBoundary(id string, boundaryArn interface{}, services ...string) *Policy {
+	if len(services) == 0 {
+		panic(fmt.Sprintf("permissions boundary %q allows no services", id))
+	}
+	actions := make([]string, 0, len(services))
+	for _, svc := range services {
+		actions = append(actions, svc+":*")
+	}
+
+	return New(id,
+		Statement("AllowServices",
+			Effect(Allow),
+			Action(actions),
+			Resource("*"),
+		),
+		Statement("DenyPrincipalsWithoutBoundary",
+			Effect(Deny),
+			Action(
+				"iam:AttachRolePolicy",
+				"iam:AttachUserPolicy",
+				"iam:CreateRole",
+				"iam:CreateUser",
+				"iam:DeleteRolePolicy",
+				"iam:DeleteUserPolicy",
+				"iam:DetachRolePolicy",
+				"iam:DetachUserPolicy",
+				"iam:PutRolePermissionsBoundary",
+				"iam:PutRolePolicy",
+				"iam:PutUserPermissionsBoundary",
+				"iam:PutUserPolicy",
+			),
+			Resource("*"),
+			Condition("StringNotEquals", "iam:PermissionsBoundary", boundaryArn),
+		),
+		Statement("DenyBoundaryRemoval",
+			Effect(Deny),
+			Action(
+				"iam:DeleteRolePermissionsBoundary",
+				"iam:DeleteUserPermissionsBoundary",
+			),
+			Resource("*"),
+		),
+		Statement("DenyBoundaryModification",
+			Effect(Deny),
+			Action(
+				"iam:CreatePolicyVersion",
+				"iam:DeletePolicy",
+				"iam:DeletePolicyVersion",
+				"iam:SetDefaultPolicyVersion",
+			),
+			Resource(boundaryArn),
+		),
+	)
+}
@@ -0,0 +1,250 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Errors returned by Validate when checking policies against a catalog.
+var (
+	// ErrUnknownAction is returned by Validate if the ValidateActions
+	// option is in use and an Action or NotAction entry isn't in the
+	// catalog.
+	ErrUnknownAction = errors.New("unknown action")
+
+	// ErrUnknownConditionKey is returned by Validate if the
+	// ValidateConditionKeys option is in use and a condition key will be
+	// ignored by the services targeted by its statement.
+	ErrUnknownConditionKey = errors.New("unknown condition key")
+)
+
+// ActionCatalog holds a set of known AWS service prefixes and, optionally,
+// the actions each service supports.
+//
+// Service prefixes and actions are case insensitive, as they are in IAM.
+type ActionCatalog struct {
+	// services maps lower case service prefixes to their lower case
+	// actions; a nil set means the service's actions are not known.
+	services map[string]map[string]bool
+
+	// keys maps lower case service prefixes (or "aws" for global keys) to
+	// their lower case condition keys.
+	keys map[string]map[string]bool
+}
+
+// NewActionCatalog returns an empty ActionCatalog.
+func NewActionCatalog() *ActionCatalog {
+	return &ActionCatalog{
+		services: make(map[string]map[string]bool),
+		keys:     make(map[string]map[string]bool),
+	}
+}
+
+// DefaultCatalog holds the service prefixes of most AWS services, along
+// with the full set of actions for some of the more commonly used services
+// (s3, sqs, sns, sts, kms, secretsmanager, dynamodb and logs) and the
+// condition keys of the global aws: namespace and of s3, sqs, sns, sts,
+// kms, secretsmanager, dynamodb and iam.
+//
+// Entries may be added to it with AddService.
+var DefaultCatalog = newDefaultCatalog()
+
+func newDefaultCatalog() *ActionCatalog {
+	c := NewActionCatalog()
+	for _, svc := range defaultServices {
+		c.AddService(svc)
+	}
+	for svc, actions := range defaultActions {
+		c.AddService(svc, actions...)
+	}
+	for svc, keys := range defaultConditionKeys {
+		c.AddConditionKeys(svc, keys...)
+	}
+	return c
+}
+
+// AddService adds a service prefix to the catalog, along with any of its
+// actions.  If no actions are ever supplied for the service, then only the
+// service prefix of each entry will be checked.
+func (c *ActionCatalog) AddService(service string, action ...string) {
+	service = strings.ToLower(service)
+	actions := c.services[service]
+	if actions == nil && len(action) > 0 {
+		actions = make(map[string]bool, len(action))
+	}
+	for _, a := range action {
+		actions[strings.ToLower(a)] = true
+	}
+	c.services[service] = actions
+}
+
+// Check verifies that action is of the form "service:action", that the
+// service is known and that the action, which may contain wildcards,
+// matches at least one of the actions the service supports.
+func (c *ActionCatalog) Check(action string) error {
+	if action == "*" {
+		return nil
+	}
+	i := strings.IndexByte(action, ':')
+	if i < 1 || i == len(action)-1 {
+		return fmt.Errorf("%w: %q is not of the form service:action", ErrUnknownAction, action)
+	}
+	service, name := strings.ToLower(action[:i]), strings.ToLower(action[i+1:])
+	actions, ok := c.services[service]
+	if !ok {
+		return fmt.Errorf("%w: %q refers to unknown service %q", ErrUnknownAction, action, action[:i])
+	}
+	if actions == nil {
+		return nil
+	}
+	if !strings.ContainsAny(name, "*?") {
+		if !actions[name] {
+			return fmt.Errorf("%w: service %q has no action %q", ErrUnknownAction, action[:i], action[i+1:])
+		}
+		return nil
+	}
+	for a := range actions {
+		if wildcardMatch(name, a) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q does not match any actions of service %q", ErrUnknownAction, action, action[:i])
+}
+
+// Services returns the sorted list of service prefixes in the catalog.
+func (c *ActionCatalog) Services() []string {
+	out := make([]string, 0, len(c.services))
+	for svc := range c.services {
+		out = append(out, svc)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ValidateActions causes Validate to check each Action and NotAction
+// entry of the policy against the supplied catalog, which will normally be
+// DefaultCatalog.  Entries supplied as outputs are not checked.
+func ValidateActions(catalog *ActionCatalog) Opt {
+	return func(p *Policy) {
+		p.catalog = catalog
+	}
+}
+
+func (p Policy) checkActions() error {
+	for _, s := range p.Statement {
+		for _, a := range append(staticStrings(s.Action), staticStrings(s.NotAction)...) {
+			if err := p.catalog.Check(a); err != nil {
+				return s.withSource(fmt.Errorf("%w in statement %q", err, s.Sid))
+			}
+		}
+	}
+	return nil
+}
+
+// AddConditionKeys adds condition keys supported by a service to the
+// catalog; service should be "aws" for global condition keys.  Keys ending
+// in "/" or ":" are treated as prefixes, matching keys such as
+// "aws:ResourceTag/Name".
+//
+// Calling AddConditionKeys for a service with no keys records that the
+// service supports no service specific keys.
+func (c *ActionCatalog) AddConditionKeys(service string, key ...string) {
+	service = strings.ToLower(service)
+	keys := c.keys[service]
+	if keys == nil {
+		keys = make(map[string]bool, len(key))
+	}
+	for _, k := range key {
+		keys[strings.ToLower(k)] = true
+	}
+	c.keys[service] = keys
+}
+
+// CheckConditionKey verifies that a condition key will be honoured by at
+// least one of the supplied services, which are the service prefixes of
+// the actions of the statement the key is used in; "*" stands for every
+// service.
+//
+// Global aws: keys are checked against the catalog's aws keys.  Keys
+// belonging to services that aren't in the catalog, such as web identity
+// federation keys, are not checked.
+func (c *ActionCatalog) CheckConditionKey(key string, services []string) error {
+	i := strings.IndexByte(key, ':')
+	if i < 1 {
+		return fmt.Errorf("%w: %q is not of the form service:key", ErrUnknownConditionKey, key)
+	}
+	service := strings.ToLower(key[:i])
+	if service != "aws" {
+		if _, ok := c.services[service]; !ok {
+			return nil
+		}
+		targeted := false
+		for _, svc := range services {
+			if svc == "*" || strings.EqualFold(svc, service) {
+				targeted = true
+				break
+			}
+		}
+		if !targeted {
+			return fmt.Errorf("%w: %q will be ignored as the statement has no %s actions",
+				ErrUnknownConditionKey, key, key[:i])
+		}
+	}
+	keys, ok := c.keys[service]
+	if !ok {
+		return nil
+	}
+	lkey := strings.ToLower(key)
+	if keys[lkey] {
+		return nil
+	}
+	for k := range keys {
+		if strings.HasSuffix(k, "/") || strings.HasSuffix(k, ":") {
+			if strings.HasPrefix(lkey, k) && len(lkey) > len(k) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %q is not supported by %s and will be ignored",
+		ErrUnknownConditionKey, key, key[:i])
+}
+
+// ValidateConditionKeys causes Validate to check each condition key used
+// by the policy against the supplied catalog, which will normally be
+// DefaultCatalog, using CheckConditionKey.  Statements using NotAction are
+// only checked for unknown keys, as they target every service.
+func ValidateConditionKeys(catalog *ActionCatalog) Opt {
+	return func(p *Policy) {
+		p.keyCatalog = catalog
+	}
+}
+
+func (p Policy) checkConditionKeys() error {
+	for _, s := range p.Statement {
+		services := []string{"*"}
+		if len(s.NotAction) == 0 {
+			services = nil
+			for _, a := range staticStrings(s.Action) {
+				if i := strings.IndexByte(a, ':'); i > 0 {
+					services = append(services, a[:i])
+				} else {
+					services = append(services, a)
+				}
+			}
+			if len(staticStrings(s.Action)) < len(s.Action) {
+				// some actions are outputs, so the services are unknown
+				services = append(services, "*")
+			}
+		}
+		for _, kv := range s.Condition {
+			for key := range kv {
+				if err := p.keyCatalog.CheckConditionKey(key, services); err != nil {
+					return s.withSource(fmt.Errorf("%w in statement %q", err, s.Sid))
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package policy
+
+// defaultServices lists the IAM service prefixes known to DefaultCatalog.
+// Only the services in defaultActions have their individual actions checked.
+var defaultServices = []string{
+	"a4b", "access-analyzer", "account", "acm", "acm-pca", "airflow", "amplify",
+	"apigateway", "app-integrations", "appconfig", "appflow", "application-autoscaling",
+	"applicationinsights", "appmesh", "apprunner", "appstream", "appsync", "aps",
+	"athena", "auditmanager", "autoscaling", "autoscaling-plans", "aws-marketplace",
+	"aws-portal", "backup", "batch", "bedrock", "billing", "budgets", "ce", "chatbot",
+	"chime", "cloud9", "cloudcontrolapi", "clouddirectory", "cloudformation",
+	"cloudfront", "cloudhsm", "cloudsearch", "cloudshell", "cloudtrail", "cloudwatch",
+	"codeartifact", "codebuild", "codecommit", "codedeploy", "codeguru-reviewer",
+	"codepipeline", "codestar", "codestar-connections", "codestar-notifications",
+	"cognito-identity", "cognito-idp", "cognito-sync", "comprehend", "compute-optimizer",
+	"config", "connect", "cur", "databrew", "dataexchange", "datapipeline", "datasync",
+	"dax", "detective", "devicefarm", "devops-guru", "directconnect", "discovery", "dlm",
+	"dms", "ds", "dynamodb", "ebs", "ec2", "ec2-instance-connect", "ec2messages", "ecr",
+	"ecr-public", "ecs", "eks", "elastic-inference", "elasticache", "elasticbeanstalk",
+	"elasticfilesystem", "elasticloadbalancing", "elasticmapreduce", "elastictranscoder",
+	"emr-containers", "emr-serverless", "es", "events", "evidently", "execute-api",
+	"firehose", "fis", "fms", "forecast", "frauddetector", "fsx", "gamelift", "glacier",
+	"globalaccelerator", "glue", "grafana", "greengrass", "groundstation", "guardduty",
+	"health", "iam", "identitystore", "imagebuilder", "inspector", "inspector2", "iot",
+	"iotanalytics", "iotevents", "iotsitewise", "iotwireless", "ivs", "kafka",
+	"kafka-cluster", "kendra", "kinesis", "kinesisanalytics", "kinesisvideo", "kms",
+	"lakeformation", "lambda", "lex", "license-manager", "lightsail", "logs",
+	"lookoutvision", "macie2", "mediaconvert", "medialive", "mediapackage", "mediastore",
+	"memorydb", "mgn", "mobiletargeting", "mq", "network-firewall", "networkmanager",
+	"organizations", "outposts", "personalize", "pi", "pipes", "polly", "pricing",
+	"qldb", "quicksight", "ram", "rds", "rds-data", "rds-db", "redshift", "redshift-data",
+	"redshift-serverless", "rekognition", "resource-explorer-2", "resource-groups",
+	"robomaker", "route53", "route53domains", "route53resolver", "rum", "s3",
+	"s3-object-lambda", "s3-outposts", "sagemaker", "savingsplans", "scheduler",
+	"schemas", "sdb", "secretsmanager", "securityhub", "serverlessrepo",
+	"servicecatalog", "servicediscovery", "servicequotas", "ses", "shield", "signer",
+	"sms", "sms-voice", "snowball", "sns", "sqs", "ssm", "ssm-contacts", "ssm-incidents",
+	"ssmmessages", "sso", "sso-directory", "sso-oauth", "states", "storagegateway",
+	"sts", "support", "swf", "synthetics", "tag", "textract", "timestream", "transcribe",
+	"transfer", "translate", "trustedadvisor", "verifiedpermissions", "vpc-lattice",
+	"waf", "waf-regional", "wafv2", "wellarchitected", "workmail", "workspaces",
+	"xray",
+}
+
+// defaultActions lists the actions known to DefaultCatalog for each of the
+// services it fully covers.
+var defaultActions = map[string][]string{
+	"sqs": {
+		"AddPermission", "CancelMessageMoveTask", "ChangeMessageVisibility",
+		"ChangeMessageVisibilityBatch", "CreateQueue", "DeleteMessage",
+		"DeleteMessageBatch", "DeleteQueue", "GetQueueAttributes", "GetQueueUrl",
+		"ListDeadLetterSourceQueues", "ListMessageMoveTasks", "ListQueueTags", "ListQueues",
+		"PurgeQueue", "ReceiveMessage", "RemovePermission", "SendMessage",
+		"SendMessageBatch", "SetQueueAttributes", "StartMessageMoveTask", "TagQueue",
+		"UntagQueue",
+	},
+	"sns": {
+		"AddPermission", "CheckIfPhoneNumberIsOptedOut", "ConfirmSubscription",
+		"CreatePlatformApplication", "CreatePlatformEndpoint", "CreateSMSSandboxPhoneNumber",
+		"CreateTopic", "DeleteEndpoint", "DeletePlatformApplication",
+		"DeleteSMSSandboxPhoneNumber", "DeleteTopic", "GetDataProtectionPolicy",
+		"GetEndpointAttributes", "GetPlatformApplicationAttributes", "GetSMSAttributes",
+		"GetSMSSandboxAccountStatus", "GetSubscriptionAttributes", "GetTopicAttributes",
+		"ListEndpointsByPlatformApplication", "ListOriginationNumbers",
+		"ListPhoneNumbersOptedOut", "ListPlatformApplications", "ListSMSSandboxPhoneNumbers",
+		"ListSubscriptions", "ListSubscriptionsByTopic", "ListTagsForResource", "ListTopics",
+		"OptInPhoneNumber", "Publish", "PutDataProtectionPolicy", "RemovePermission",
+		"SetEndpointAttributes", "SetPlatformApplicationAttributes", "SetSMSAttributes",
+		"SetSubscriptionAttributes", "SetTopicAttributes", "Subscribe", "TagResource",
+		"Unsubscribe", "UntagResource", "VerifySMSSandboxPhoneNumber",
+	},
+	"sts": {
+		"AssumeRole", "AssumeRoleWithSAML", "AssumeRoleWithWebIdentity", "DecodeAuthorizationMessage",
+		"GetAccessKeyInfo", "GetCallerIdentity", "GetFederationToken", "GetServiceBearerToken",
+		"GetSessionToken", "SetSourceIdentity", "TagSession",
+	},
+	"kms": {
+		"CancelKeyDeletion", "ConnectCustomKeyStore", "CreateAlias", "CreateCustomKeyStore",
+		"CreateGrant", "CreateKey", "Decrypt", "DeleteAlias", "DeleteCustomKeyStore",
+		"DeleteImportedKeyMaterial", "DescribeCustomKeyStores", "DescribeKey", "DisableKey",
+		"DisableKeyRotation", "DisconnectCustomKeyStore", "EnableKey", "EnableKeyRotation",
+		"Encrypt", "GenerateDataKey", "GenerateDataKeyPair", "GenerateDataKeyPairWithoutPlaintext",
+		"GenerateDataKeyWithoutPlaintext", "GenerateMac", "GenerateRandom", "GetKeyPolicy",
+		"GetKeyRotationStatus", "GetParametersForImport", "GetPublicKey", "ImportKeyMaterial",
+		"ListAliases", "ListGrants", "ListKeyPolicies", "ListKeys", "ListResourceTags",
+		"ListRetirableGrants", "PutKeyPolicy", "ReEncryptFrom", "ReEncryptTo", "ReplicateKey",
+		"RetireGrant", "RevokeGrant", "ScheduleKeyDeletion", "Sign", "SynchronizeMultiRegionKey",
+		"TagResource", "UntagResource", "UpdateAlias", "UpdateCustomKeyStore",
+		"UpdateKeyDescription", "UpdatePrimaryRegion", "Verify", "VerifyMac",
+	},
+	"secretsmanager": {
+		"BatchGetSecretValue", "CancelRotateSecret", "CreateSecret", "DeleteResourcePolicy",
+		"DeleteSecret", "DescribeSecret", "GetRandomPassword", "GetResourcePolicy",
+		"GetSecretValue", "ListSecretVersionIds", "ListSecrets", "PutResourcePolicy",
+		"PutSecretValue", "RemoveRegionsFromReplication", "ReplicateSecretToRegions",
+		"RestoreSecret", "RotateSecret", "StopReplicationToReplica", "TagResource",
+		"UntagResource", "UpdateSecret", "UpdateSecretVersionStage", "ValidateResourcePolicy",
+	},
+	"dynamodb": {
+		"BatchGetItem", "BatchWriteItem", "ConditionCheckItem", "CreateBackup",
+		"CreateGlobalTable", "CreateTable", "CreateTableReplica", "DeleteBackup",
+		"DeleteItem", "DeleteResourcePolicy", "DeleteTable", "DeleteTableReplica",
+		"DescribeBackup", "DescribeContinuousBackups", "DescribeContributorInsights",
+		"DescribeEndpoints", "DescribeExport", "DescribeGlobalTable",
+		"DescribeGlobalTableSettings", "DescribeImport", "DescribeKinesisStreamingDestination",
+		"DescribeLimits", "DescribeReservedCapacity", "DescribeReservedCapacityOfferings",
+		"DescribeStream", "DescribeTable", "DescribeTableReplicaAutoScaling",
+		"DescribeTimeToLive", "DisableKinesisStreamingDestination",
+		"EnableKinesisStreamingDestination", "ExportTableToPointInTime", "GetItem",
+		"GetRecords", "GetResourcePolicy", "GetShardIterator", "ImportTable", "ListBackups",
+		"ListContributorInsights", "ListExports", "ListGlobalTables", "ListImports",
+		"ListStreams", "ListTables", "ListTagsOfResource", "PartiQLDelete", "PartiQLInsert",
+		"PartiQLSelect", "PartiQLUpdate", "PurchaseReservedCapacityOfferings", "PutItem",
+		"PutResourcePolicy", "Query", "RestoreTableFromAwsBackup", "RestoreTableFromBackup",
+		"RestoreTableToPointInTime", "Scan", "StartAwsBackupJob", "TagResource",
+		"UntagResource", "UpdateContinuousBackups", "UpdateContributorInsights",
+		"UpdateGlobalTable", "UpdateGlobalTableSettings", "UpdateGlobalTableVersion",
+		"UpdateItem", "UpdateKinesisStreamingDestination", "UpdateTable",
+		"UpdateTableReplicaAutoScaling", "UpdateTimeToLive",
+	},
+	"logs": {
+		"AssociateKmsKey", "CancelExportTask", "CreateDelivery", "CreateExportTask",
+		"CreateLogDelivery", "CreateLogGroup", "CreateLogStream", "DeleteDataProtectionPolicy",
+		"DeleteDestination", "DeleteLogDelivery", "DeleteLogGroup", "DeleteLogStream",
+		"DeleteMetricFilter", "DeleteQueryDefinition", "DeleteResourcePolicy",
+		"DeleteRetentionPolicy", "DeleteSubscriptionFilter", "DescribeDestinations",
+		"DescribeExportTasks", "DescribeLogGroups", "DescribeLogStreams",
+		"DescribeMetricFilters", "DescribeQueries", "DescribeQueryDefinitions",
+		"DescribeResourcePolicies", "DescribeSubscriptionFilters", "DisassociateKmsKey",
+		"FilterLogEvents", "GetDataProtectionPolicy", "GetLogDelivery", "GetLogEvents",
+		"GetLogGroupFields", "GetLogRecord", "GetQueryResults", "Link", "ListLogDeliveries",
+		"ListTagsForResource", "ListTagsLogGroup", "PutDataProtectionPolicy",
+		"PutDestination", "PutDestinationPolicy", "PutLogEvents", "PutMetricFilter",
+		"PutQueryDefinition", "PutResourcePolicy", "PutRetentionPolicy",
+		"PutSubscriptionFilter", "StartLiveTail", "StartQuery", "StopLiveTail", "StopQuery",
+		"TagLogGroup", "TagResource", "TestMetricFilter", "UntagLogGroup", "UntagResource",
+		"UpdateLogDelivery",
+	},
+	"s3": {
+		"AbortMultipartUpload", "BypassGovernanceRetention", "CreateAccessPoint",
+		"CreateAccessPointForObjectLambda", "CreateBucket", "CreateJob",
+		"CreateMultiRegionAccessPoint", "DeleteAccessPoint", "DeleteAccessPointForObjectLambda",
+		"DeleteAccessPointPolicy", "DeleteAccessPointPolicyForObjectLambda", "DeleteBucket",
+		"DeleteBucketOwnershipControls", "DeleteBucketPolicy", "DeleteBucketWebsite",
+		"DeleteJobTagging", "DeleteMultiRegionAccessPoint", "DeleteObject",
+		"DeleteObjectTagging", "DeleteObjectVersion", "DeleteObjectVersionTagging",
+		"DeleteStorageLensConfiguration", "DeleteStorageLensConfigurationTagging",
+		"DescribeJob", "DescribeMultiRegionAccessPointOperation", "GetAccelerateConfiguration",
+		"GetAccessPoint", "GetAccessPointConfigurationForObjectLambda",
+		"GetAccessPointForObjectLambda", "GetAccessPointPolicy",
+		"GetAccessPointPolicyForObjectLambda", "GetAccessPointPolicyStatus",
+		"GetAccessPointPolicyStatusForObjectLambda", "GetAccountPublicAccessBlock",
+		"GetAnalyticsConfiguration", "GetBucketAcl", "GetBucketCORS", "GetBucketLocation",
+		"GetBucketLogging", "GetBucketNotification", "GetBucketObjectLockConfiguration",
+		"GetBucketOwnershipControls", "GetBucketPolicy", "GetBucketPolicyStatus",
+		"GetBucketPublicAccessBlock", "GetBucketRequestPayment", "GetBucketTagging",
+		"GetBucketVersioning", "GetBucketWebsite", "GetEncryptionConfiguration",
+		"GetIntelligentTieringConfiguration", "GetInventoryConfiguration", "GetJobTagging",
+		"GetLifecycleConfiguration", "GetMetricsConfiguration", "GetMultiRegionAccessPoint",
+		"GetMultiRegionAccessPointPolicy", "GetMultiRegionAccessPointPolicyStatus",
+		"GetObject", "GetObjectAcl", "GetObjectAttributes", "GetObjectLegalHold",
+		"GetObjectRetention", "GetObjectTagging", "GetObjectTorrent", "GetObjectVersion",
+		"GetObjectVersionAcl", "GetObjectVersionAttributes", "GetObjectVersionForReplication",
+		"GetObjectVersionTagging", "GetObjectVersionTorrent", "GetReplicationConfiguration",
+		"GetStorageLensConfiguration", "GetStorageLensConfigurationTagging",
+		"GetStorageLensDashboard", "InitiateReplication", "ListAccessPoints",
+		"ListAccessPointsForObjectLambda", "ListAllMyBuckets", "ListBucket",
+		"ListBucketMultipartUploads", "ListBucketVersions", "ListJobs",
+		"ListMultiRegionAccessPoints", "ListMultipartUploadParts", "ListStorageLensConfigurations",
+		"ObjectOwnerOverrideToBucketOwner", "PutAccelerateConfiguration",
+		"PutAccessPointConfigurationForObjectLambda", "PutAccessPointPolicy",
+		"PutAccessPointPolicyForObjectLambda", "PutAccessPointPublicAccessBlock",
+		"PutAccountPublicAccessBlock", "PutAnalyticsConfiguration", "PutBucketAcl",
+		"PutBucketCORS", "PutBucketLogging", "PutBucketNotification",
+		"PutBucketObjectLockConfiguration", "PutBucketOwnershipControls", "PutBucketPolicy",
+		"PutBucketPublicAccessBlock", "PutBucketRequestPayment", "PutBucketTagging",
+		"PutBucketVersioning", "PutBucketWebsite", "PutEncryptionConfiguration",
+		"PutIntelligentTieringConfiguration", "PutInventoryConfiguration", "PutJobTagging",
+		"PutLifecycleConfiguration", "PutMetricsConfiguration", "PutMultiRegionAccessPointPolicy",
+		"PutObject", "PutObjectAcl", "PutObjectLegalHold", "PutObjectRetention",
+		"PutObjectTagging", "PutObjectVersionAcl", "PutObjectVersionTagging",
+		"PutReplicationConfiguration", "PutStorageLensConfiguration",
+		"PutStorageLensConfigurationTagging", "ReplicateDelete", "ReplicateObject",
+		"ReplicateTags", "RestoreObject", "UpdateJobPriority", "UpdateJobStatus",
+	},
+}
+
+// defaultConditionKeys lists the condition keys known to DefaultCatalog.
+// Keys ending in "/" or ":" match any key beginning with that prefix, such
+// as aws:ResourceTag/Name.
+var defaultConditionKeys = map[string][]string{
+	"aws": {
+		"aws:CalledVia", "aws:CalledViaFirst", "aws:CalledViaLast", "aws:CurrentTime",
+		"aws:Ec2InstanceSourcePrivateIPv4", "aws:Ec2InstanceSourceVpc", "aws:EpochTime",
+		"aws:FederatedProvider", "aws:MultiFactorAuthAge", "aws:MultiFactorAuthPresent",
+		"aws:PrincipalAccount", "aws:PrincipalArn", "aws:PrincipalIsAWSService",
+		"aws:PrincipalOrgID", "aws:PrincipalOrgPaths", "aws:PrincipalServiceName",
+		"aws:PrincipalServiceNamesList", "aws:PrincipalTag/", "aws:PrincipalType",
+		"aws:referer", "aws:RequestedRegion", "aws:RequestTag/", "aws:ResourceAccount",
+		"aws:ResourceOrgID", "aws:ResourceOrgPaths", "aws:ResourceTag/",
+		"aws:SecureTransport", "aws:SourceAccount", "aws:SourceArn", "aws:SourceIdentity",
+		"aws:SourceIp", "aws:SourceOrgID", "aws:SourceOrgPaths", "aws:SourceVpc",
+		"aws:SourceVpce", "aws:TagKeys", "aws:TokenIssueTime", "aws:UserAgent",
+		"aws:userid", "aws:username", "aws:ViaAWSService", "aws:VpcSourceIp",
+	},
+	"s3": {
+		"s3:AccessPointNetworkOrigin", "s3:authType", "s3:DataAccessPointAccount",
+		"s3:DataAccessPointArn", "s3:delimiter", "s3:ExistingObjectTag/",
+		"s3:LocationConstraint", "s3:max-keys", "s3:object-lock-legal-hold",
+		"s3:object-lock-mode", "s3:object-lock-remaining-retention-days",
+		"s3:object-lock-retain-until-date", "s3:prefix", "s3:RequestObjectTag/",
+		"s3:RequestObjectTagKeys", "s3:ResourceAccount", "s3:signatureAge",
+		"s3:signatureversion", "s3:TlsVersion", "s3:versionid", "s3:x-amz-acl",
+		"s3:x-amz-content-sha256", "s3:x-amz-copy-source", "s3:x-amz-grant-full-control",
+		"s3:x-amz-grant-read", "s3:x-amz-grant-read-acp", "s3:x-amz-grant-write",
+		"s3:x-amz-grant-write-acp", "s3:x-amz-metadata-directive",
+		"s3:x-amz-object-ownership", "s3:x-amz-server-side-encryption",
+		"s3:x-amz-server-side-encryption-aws-kms-key-id", "s3:x-amz-storage-class",
+		"s3:x-amz-website-redirect-location",
+	},
+	"kms": {
+		"kms:BypassPolicyLockoutSafetyCheck", "kms:CallerAccount",
+		"kms:CustomerMasterKeySpec", "kms:CustomerMasterKeyUsage", "kms:DataKeyPairSpec",
+		"kms:EncryptionAlgorithm", "kms:EncryptionContext:", "kms:EncryptionContextKeys",
+		"kms:ExpirationModel", "kms:GrantConstraintType", "kms:GrantIsForAWSResource",
+		"kms:GrantOperations", "kms:GranteePrincipal", "kms:KeyOrigin", "kms:KeySpec",
+		"kms:KeyUsage", "kms:MacAlgorithm", "kms:MessageType", "kms:MultiRegion",
+		"kms:MultiRegionKeyType", "kms:PrimaryRegion", "kms:ReEncryptOnSameKey",
+		"kms:RecipientAttestation:", "kms:ReplicaRegion", "kms:RequestAlias",
+		"kms:ResourceAliases", "kms:RetiringPrincipal",
+		"kms:ScheduleKeyDeletionPendingWindowInDays", "kms:SigningAlgorithm",
+		"kms:ValidTo", "kms:ViaService", "kms:WrappingAlgorithm", "kms:WrappingKeySpec",
+	},
+	"sqs": {},
+	"sns": {"sns:Endpoint", "sns:Protocol"},
+	"sts": {
+		"sts:AWSServiceName", "sts:DurationSeconds", "sts:ExternalId",
+		"sts:RoleSessionName", "sts:SourceIdentity", "sts:TransitiveTagKeys",
+	},
+	"secretsmanager": {
+		"secretsmanager:AddReplicaRegions", "secretsmanager:BlockPublicPolicy",
+		"secretsmanager:Description", "secretsmanager:ForceDeleteWithoutRecovery",
+		"secretsmanager:ForceOverwriteReplicaSecret", "secretsmanager:KmsKeyId",
+		"secretsmanager:ModifyRotationRules", "secretsmanager:Name",
+		"secretsmanager:RecoveryWindowInDays", "secretsmanager:resource/AllowRotationLambdaArn",
+		"secretsmanager:ResourceTag/", "secretsmanager:RotateImmediately",
+		"secretsmanager:RotationLambdaARN", "secretsmanager:SecretId",
+		"secretsmanager:SecretPrimaryRegion", "secretsmanager:VersionId",
+		"secretsmanager:VersionStage",
+	},
+	"dynamodb": {
+		"dynamodb:Attributes", "dynamodb:EnclosingOperation", "dynamodb:FullTableScan",
+		"dynamodb:LeadingKeys", "dynamodb:ReturnConsumedCapacity", "dynamodb:ReturnValues",
+		"dynamodb:Select",
+	},
+	"iam": {
+		"iam:AssociatedResourceArn", "iam:AWSServiceName", "iam:OrganizationsPolicyId",
+		"iam:PassedToService", "iam:PermissionsBoundary", "iam:PolicyARN",
+		"iam:ResourceTag/",
+	},
+}
@@ -0,0 +1,72 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"gopkg.in/yaml.v3"
+)
+
+// ToCloudFormationYAML renders the policy as a YAML document suitable for
+// embedding as the PolicyDocument property of a CloudFormation or SAM
+// template resource, once all of its inputs have resolved.
+//
+// Elements are emitted in the same order as the JSON form and lists of
+// values use the short flow form, eg. Action: ['s3:GetObject', 's3:PutObject'].
+func (p Policy) ToCloudFormationYAML() pulumi.StringOutput {
+	if err := p.Validate(); err != nil {
+		panic(err)
+	}
+	return pulumi.ToOutput(p).ApplyT(func(data interface{}) (string, error) {
+		js, err := json.Marshal(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal json for policy %q: %w", p.ID, err)
+		}
+		return jsonToYAML(js)
+	}).(pulumi.StringOutput)
+}
+
+// jsonToYAML converts a JSON document to block style YAML, preserving the
+// order of keys and using flow style for lists of scalars.
+func jsonToYAML(js []byte) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(js, &doc); err != nil {
+		return "", err
+	}
+	setYAMLStyle(&doc)
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func setYAMLStyle(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		n.Style = yaml.FlowStyle
+		for _, c := range n.Content {
+			if c.Kind != yaml.ScalarNode {
+				n.Style = 0
+			}
+		}
+	case yaml.ScalarNode:
+		// JSON strings are parsed as double quoted; only quote where needed
+		if n.Tag == "!!str" {
+			n.Style = 0
+		}
+	default:
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		setYAMLStyle(c)
+	}
+}
@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// conditionOperators holds the documented IAM condition operators.
+//
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_elements_condition_operators.html
+var conditionOperators = map[string]bool{
+	"StringEquals":              true,
+	"StringNotEquals":           true,
+	"StringEqualsIgnoreCase":    true,
+	"StringNotEqualsIgnoreCase": true,
+	"StringLike":                true,
+	"StringNotLike":             true,
+	"NumericEquals":             true,
+	"NumericNotEquals":          true,
+	"NumericLessThan":           true,
+	"NumericLessThanEquals":     true,
+	"NumericGreaterThan":        true,
+	"NumericGreaterThanEquals":  true,
+	"DateEquals":                true,
+	"DateNotEquals":             true,
+	"DateLessThan":              true,
+	"DateLessThanEquals":        true,
+	"DateGreaterThan":           true,
+	"DateGreaterThanEquals":     true,
+	"Bool":                      true,
+	"BinaryEquals":              true,
+	"IpAddress":                 true,
+	"NotIpAddress":              true,
+	"ArnEquals":                 true,
+	"ArnNotEquals":              true,
+	"ArnLike":                   true,
+	"ArnNotLike":                true,
+	"Null":                      true,
+}
+
+// ValidateConditions checks that each condition operator used by the
+// statement is one of the documented IAM operators, optionally with a
+// ForAllValues: or ForAnyValue: prefix and an IfExists suffix.
+func (s Stmt) ValidateConditions() error {
+	for op := range s.Condition {
+		if !validConditionOperator(op) {
+			return s.withSource(fmt.Errorf("%w: unknown condition operator %q in statement %q",
+				ErrInvalidStatement, op, s.Sid))
+		}
+	}
+	return nil
+}
+
+func validConditionOperator(op string) bool {
+	base := op
+	prefixed := false
+	for _, prefix := range []string{"ForAllValues:", "ForAnyValue:"} {
+		if strings.HasPrefix(base, prefix) {
+			base = base[len(prefix):]
+			prefixed = true
+			break
+		}
+	}
+	if base == "Null" {
+		return !prefixed
+	}
+	base = strings.TrimSuffix(base, "IfExists")
+	return base != "Null" && conditionOperators[base]
+}
+
+// CheckConditions causes Validate to call ValidateConditions for each
+// statement in the policy, catching misspelled operators such as
+// "StringEqual" before the policy is deployed.
+func CheckConditions() Opt {
+	return func(p *Policy) {
+		p.checkConditions = true
+	}
+}
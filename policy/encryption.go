@@ -0,0 +1,77 @@
+package policy
+
+// DenyUnencryptedUploads adds statements to a bucket policy that deny
+// uploads to the bucket that don't request server side encryption, or
+// request it with something other than SSE-S3 or SSE-KMS.
+//
+// bucketArn may be a string or a StringInput, such as the Arn output of an
+// s3.Bucket.
+func DenyUnencryptedUploads(bucketArn interface{}) Opt {
+	objects := withSuffix(bucketArn, "/*")
+	return combineOpts(
+		Statement("DenyIncorrectEncryptionHeader",
+			Effect(Deny),
+			Principal("AWS", "*"),
+			Action("s3:PutObject"),
+			Resource(objects),
+			Condition("StringNotEquals", "s3:x-amz-server-side-encryption", "AES256", "aws:kms"),
+		),
+		Statement("DenyUnencryptedObjectUploads",
+			Effect(Deny),
+			Principal("AWS", "*"),
+			Action("s3:PutObject"),
+			Resource(objects),
+			Condition("Null", "s3:x-amz-server-side-encryption", "true"),
+		),
+	)
+}
+
+// RequireKMSKey adds statements to a bucket policy that deny uploads to
+// the bucket unless they're encrypted with SSE-KMS using the supplied key.
+//
+// bucketArn and keyArn may be strings or StringInputs, such as the Arn
+// outputs of an s3.Bucket and kms.Key.
+func RequireKMSKey(bucketArn, keyArn interface{}) Opt {
+	objects := withSuffix(bucketArn, "/*")
+	return combineOpts(
+		Statement("DenyIncorrectEncryptionHeader",
+			Effect(Deny),
+			Principal("AWS", "*"),
+			Action("s3:PutObject"),
+			Resource(objects),
+			Condition("StringNotEquals", "s3:x-amz-server-side-encryption", "aws:kms"),
+		),
+		Statement("DenyIncorrectKMSKey",
+			Effect(Deny),
+			Principal("AWS", "*"),
+			Action("s3:PutObject"),
+			Resource(objects),
+			Condition("StringNotEqualsIfExists", "s3:x-amz-server-side-encryption-aws-kms-key-id", keyArn),
+		),
+	)
+}
+
+// DenyInsecureTransport adds a statement to a resource policy that denies
+// every action of service (eg. "s3", "sqs" or "sns") on the supplied
+// resources for requests not made over TLS.
+//
+// resource arguments may be string, []string, StringInput or
+// StringArrayInput, such as the Arn output of an sqs.Queue or sns.Topic.
+func DenyInsecureTransport(service string, resource ...interface{}) Opt {
+	return Statement("DenyInsecureTransport",
+		Effect(Deny),
+		Principal("AWS", "*"),
+		Action(service+":*"),
+		Resource(resource...),
+		Condition("Bool", "aws:SecureTransport", "false"),
+	)
+}
+
+// combineOpts returns an Opt that applies each of opts in turn.
+func combineOpts(opts ...Opt) Opt {
+	return func(p *Policy) {
+		for _, opt := range opts {
+			opt(p)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrUnresolved is returned when an operation that requires static values
+// is performed on a policy that contains outputs.
+var ErrUnresolved = errors.New("policy contains unresolved outputs")
+
+// Equal reports whether two policies are semantically equivalent, ignoring
+// the order of statements and values, the order of keys, duplicate values
+// and whether single values are represented as a string or an array.
+//
+// An error wrapping ErrUnresolved is returned if either policy contains
+// outputs; use EqualJSON to compare rendered policies.
+func Equal(a, b *Policy) (bool, error) {
+	aj, err := a.staticJSON()
+	if err != nil {
+		return false, err
+	}
+	bj, err := b.staticJSON()
+	if err != nil {
+		return false, err
+	}
+	return EqualJSON(string(aj), string(bj))
+}
+
+// EqualJSON reports whether two JSON policy documents are semantically
+// equivalent, using the same rules as Equal.
+func EqualJSON(a, b string) (bool, error) {
+	an, err := normalizeJSON(a)
+	if err != nil {
+		return false, err
+	}
+	bn, err := normalizeJSON(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(an, bn), nil
+}
+
+// staticJSON marshals the policy, returning an error if any of its values
+// are outputs.
+func (p Policy) staticJSON() ([]byte, error) {
+	for _, s := range p.Statement {
+		if !s.isStatic() {
+			return nil, fmt.Errorf("%w: policy %q, statement %q", ErrUnresolved, p.ID, s.Sid)
+		}
+	}
+	return json.Marshal(p)
+}
+
+// isStatic reports whether every value in the statement is a string or
+// []string.
+func (s Stmt) isStatic() bool {
+	lists := []Strings{s.Action, s.NotAction, s.Resource, s.NotResource}
+	for _, v := range s.Principal {
+		lists = append(lists, v)
+	}
+	for _, v := range s.NotPrincipal {
+		lists = append(lists, v)
+	}
+	for _, kv := range s.Condition {
+		for _, v := range kv {
+			lists = append(lists, v)
+		}
+	}
+	for _, l := range lists {
+		for _, el := range l {
+			switch el.(type) {
+			case string, []string:
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// normalizeJSON parses a policy document and re-marshals it in a canonical
+// form suitable for comparison.
+func normalizeJSON(doc string) ([]byte, error) {
+	var p map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &p); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse policy: %v", ErrInvalidPolicy, err)
+	}
+
+	var stmts []interface{}
+	switch v := p["Statement"].(type) {
+	case []interface{}:
+		stmts = v
+	case map[string]interface{}:
+		stmts = []interface{}{v}
+	}
+
+	keyed := make([]string, 0, len(stmts))
+	for _, s := range stmts {
+		if m, ok := s.(map[string]interface{}); ok {
+			normalizeStmt(m)
+		}
+		b, _ := json.Marshal(s)
+		keyed = append(keyed, string(b))
+	}
+	sort.Strings(keyed)
+
+	normalized := make([]json.RawMessage, len(keyed))
+	for i, s := range keyed {
+		normalized[i] = json.RawMessage(s)
+	}
+	p["Statement"] = normalized
+	return json.Marshal(p)
+}
+
+func normalizeStmt(s map[string]interface{}) {
+	for _, k := range []string{"Action", "NotAction", "Resource", "NotResource"} {
+		if v, ok := s[k]; ok {
+			s[k] = stringSet(v)
+		}
+	}
+	for _, k := range []string{"Principal", "NotPrincipal"} {
+		if m, ok := s[k].(map[string]interface{}); ok {
+			for pt, v := range m {
+				m[pt] = stringSet(v)
+			}
+		}
+	}
+	if cond, ok := s["Condition"].(map[string]interface{}); ok {
+		for _, kv := range cond {
+			if m, ok := kv.(map[string]interface{}); ok {
+				for k, v := range m {
+					m[k] = stringSet(v)
+				}
+			}
+		}
+	}
+}
+
+// stringSet converts a single value or a list of values to a sorted list
+// without duplicates.
+func stringSet(v interface{}) interface{} {
+	var in []interface{}
+	switch v := v.(type) {
+	case []interface{}:
+		in = v
+	default:
+		in = []interface{}{v}
+	}
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, el := range in {
+		s := fmt.Sprint(el)
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
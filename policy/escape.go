@@ -0,0 +1,88 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// escapeReplacer replaces the "${" sequence, which would otherwise begin a
+// policy variable, with "${$}{", the policy variable for a literal dollar
+// sign followed by a brace.
+var escapeReplacer = strings.NewReplacer("${", "${$}{")
+
+// Escape escapes any literal "${" sequences in a value so that they are not
+// treated as the start of a policy variable such as ${aws:username}.
+//
+// v may be a string, []string, StringInput or StringArrayInput; outputs are
+// escaped once they resolve.
+//
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_policies_variables.html#policy-vars-specialchars
+func Escape(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return escapeReplacer.Replace(v)
+	case []string:
+		return escapeAll(v)
+	case pulumi.StringInput:
+		return v.ToStringOutput().ApplyT(escapeReplacer.Replace).(pulumi.StringOutput)
+	case pulumi.StringArrayInput:
+		return v.ToStringArrayOutput().ApplyT(escapeAll).(pulumi.StringArrayOutput)
+	default:
+		panic(fmt.Sprintf("unexpected type passed to Escape: %T: %#v", v, v))
+	}
+}
+
+func escapeAll(v []string) []string {
+	out := make([]string, len(v))
+	for i, s := range v {
+		out[i] = escapeReplacer.Replace(s)
+	}
+	return out
+}
+
+// AutoEscape causes all Resource, NotResource and Condition values in the
+// policy to be passed through Escape when the policy is rendered.  It
+// should not be used if the policy relies on policy variables in those
+// elements.
+func AutoEscape() Opt {
+	return func(p *Policy) {
+		p.autoEscape = true
+	}
+}
+
+// escapeStrings returns a copy of s, with each resolved entry escaped.
+func escapeStrings(s Strings) Strings {
+	if s == nil {
+		return nil
+	}
+	out := make(Strings, len(s))
+	for i, el := range s {
+		out[i] = Escape(el)
+	}
+	return out
+}
+
+// escaped returns a copy of the resolved policy p with its Resource,
+// NotResource and Condition values escaped.
+func (p Policy) escaped() Policy {
+	stmts := make(Stmts, len(p.Statement))
+	for i, s := range p.Statement {
+		s.Resource = escapeStrings(s.Resource)
+		s.NotResource = escapeStrings(s.NotResource)
+		if s.Condition != nil {
+			cond := make(map[string]map[string]Strings, len(s.Condition))
+			for op, kv := range s.Condition {
+				cond[op] = make(map[string]Strings, len(kv))
+				for k, v := range kv {
+					cond[op][k] = escapeStrings(v)
+				}
+			}
+			s.Condition = cond
+		}
+		stmts[i] = s
+	}
+	p.Statement = stmts
+	return p
+}
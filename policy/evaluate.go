@@ -0,0 +1,186 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedCondition is returned by Evaluate if the policy uses a
+// condition operator it does not implement.
+var ErrUnsupportedCondition = errors.New("unsupported condition operator")
+
+// Decision is the result of evaluating a request against a policy.
+type Decision string
+
+// Possible results from Evaluate.
+const (
+	// ImplicitDeny means no statement allowed or denied the request.
+	ImplicitDeny Decision = "ImplicitDeny"
+	// ExplicitDeny means at least one Deny statement matched the request.
+	ExplicitDeny Decision = "ExplicitDeny"
+	// Allowed means an Allow statement matched the request and no Deny
+	// statement did.
+	Allowed Decision = "Allowed"
+)
+
+// Evaluate performs a simplified IAM evaluation of the policy for a single
+// request, intended for use in unit tests that need to assert that a
+// policy does or does not grant some access.
+//
+// action and resource are matched against the Action, NotAction, Resource
+// and NotResource elements of each statement, honouring "*" and "?"
+// wildcards; statements without a Resource or NotResource element (as in
+// resource policies) match any resource.  context supplies the values of
+// condition keys, such as "aws:SourceIp" or "s3:prefix".
+//
+// An explicit Deny always wins over an Allow.  Principals are not
+// considered, nor are policy variables, and any values supplied as outputs
+// are treated as not matching.  The string, ARN, numeric, Bool and Null
+// condition operators are supported, along with their IfExists variants
+// and ForAllValues/ForAnyValue prefixes; other operators cause
+// ErrUnsupportedCondition to be returned.
+func (p Policy) Evaluate(action, resource string, context map[string]string) (Decision, error) {
+	ctx := make(map[string]string, len(context))
+	for k, v := range context {
+		ctx[strings.ToLower(k)] = v
+	}
+
+	decision := ImplicitDeny
+	for _, s := range p.Statement {
+		ok, err := s.matches(action, resource, ctx)
+		if err != nil {
+			return ImplicitDeny, fmt.Errorf("statement %q: %w", s.Sid, err)
+		}
+		if !ok {
+			continue
+		}
+		if s.Effect == Deny {
+			return ExplicitDeny, nil
+		}
+		decision = Allowed
+	}
+	return decision, nil
+}
+
+func (s Stmt) matches(action, resource string, ctx map[string]string) (bool, error) {
+	action = strings.ToLower(action)
+	if len(s.NotAction) > 0 {
+		if matchAny(staticStrings(s.NotAction), action, true) {
+			return false, nil
+		}
+	} else if !matchAny(staticStrings(s.Action), action, true) {
+		return false, nil
+	}
+
+	switch {
+	case len(s.NotResource) > 0:
+		if matchAny(staticStrings(s.NotResource), resource, false) {
+			return false, nil
+		}
+	case len(s.Resource) > 0:
+		if !matchAny(staticStrings(s.Resource), resource, false) {
+			return false, nil
+		}
+	}
+
+	for op, kv := range s.Condition {
+		for key, values := range kv {
+			ok, err := evalCondition(op, ctx, strings.ToLower(key), staticStrings(values))
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func matchAny(patterns []string, s string, fold bool) bool {
+	for _, p := range patterns {
+		if fold {
+			p = strings.ToLower(p)
+		}
+		if wildcardMatch(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// evalCondition evaluates a single condition operator and key against the
+// request context.
+func evalCondition(op string, ctx map[string]string, key string, values []string) (bool, error) {
+	if op == "Null" {
+		_, present := ctx[key]
+		for _, v := range values {
+			if (v == "true") == present {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	base := strings.TrimPrefix(strings.TrimPrefix(op, "ForAllValues:"), "ForAnyValue:")
+	ifExists := strings.HasSuffix(base, "IfExists")
+	base = strings.TrimSuffix(base, "IfExists")
+
+	negated := strings.Contains(base, "Not")
+	cmp, ok := conditionOps[strings.Replace(base, "Not", "", 1)]
+	if !ok {
+		return false, fmt.Errorf("%w: %q", ErrUnsupportedCondition, op)
+	}
+
+	actual, present := ctx[key]
+	if !present {
+		// missing keys satisfy IfExists and negated operators, and fail
+		// everything else
+		return ifExists || negated, nil
+	}
+	for _, v := range values {
+		if cmp(actual, v) {
+			return !negated, nil
+		}
+	}
+	return negated, nil
+}
+
+// conditionOps maps the positive form of each supported condition operator
+// to a function comparing a context value with a policy value.
+var conditionOps = map[string]func(actual, expected string) bool{
+	"StringEquals": func(a, e string) bool { return a == e },
+	"StringEqualsIgnoreCase": func(a, e string) bool {
+		return strings.EqualFold(a, e)
+	},
+	"StringLike": func(a, e string) bool { return wildcardMatch(e, a) },
+	"ArnEquals":  func(a, e string) bool { return wildcardMatch(e, a) },
+	"ArnLike":    func(a, e string) bool { return wildcardMatch(e, a) },
+	"Bool":       func(a, e string) bool { return strings.EqualFold(a, e) },
+	"NumericEquals": func(a, e string) bool {
+		return compareNumbers(a, e, func(a, e float64) bool { return a == e })
+	},
+	"NumericLessThan": func(a, e string) bool {
+		return compareNumbers(a, e, func(a, e float64) bool { return a < e })
+	},
+	"NumericLessThanEquals": func(a, e string) bool {
+		return compareNumbers(a, e, func(a, e float64) bool { return a <= e })
+	},
+	"NumericGreaterThan": func(a, e string) bool {
+		return compareNumbers(a, e, func(a, e float64) bool { return a > e })
+	},
+	"NumericGreaterThanEquals": func(a, e string) bool {
+		return compareNumbers(a, e, func(a, e float64) bool { return a >= e })
+	},
+}
+
+func compareNumbers(a, e string, cmp func(a, e float64) bool) bool {
+	af, err := strconv.ParseFloat(a, 64)
+	if err != nil {
+		return false
+	}
+	ef, err := strconv.ParseFloat(e, 64)
+	if err != nil {
+		return false
+	}
+	return cmp(af, ef)
+}
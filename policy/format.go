@@ -0,0 +1,69 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// RenderOptions controls the formatting of the JSON generated by
+// ToStringOutput, eg. to match the conventions of hand written policies
+// being migrated, so that the migration doesn't cause spurious diffs.
+//
+// By default policies are indented with four spaces, keys are ordered as
+// in the AWS documentation (Version, Id, Statement, then Sid, Effect,
+// Principal, etc.) and there's no trailing newline.
+type RenderOptions struct {
+	// Indent is repeated for each level of indentation, eg. "  " or "\t".
+	// If empty, the policy is rendered compactly on a single line.
+	Indent string
+	// SortKeys orders the keys of every object alphabetically.
+	SortKeys bool
+	// TrailingNewline adds a newline to the end of the document.
+	TrailingNewline bool
+}
+
+// WithRenderOptions sets the formatting used when the policy is rendered
+// to JSON.
+//
+// Rendering compactly also minimizes the size of policies whose quota
+// includes whitespace, such as session policies.
+func WithRenderOptions(opts RenderOptions) Opt {
+	return func(p *Policy) {
+		p.renderOpts = &opts
+	}
+}
+
+// marshal renders the policy data according to its render options.
+func (p Policy) marshal(data interface{}) ([]byte, error) {
+	opts := p.renderOpts
+	if opts == nil {
+		return json.MarshalIndent(data, "", "    ")
+	}
+	v, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if opts.SortKeys {
+		// maps are marshaled with sorted keys
+		var generic interface{}
+		dec := json.NewDecoder(bytes.NewReader(v))
+		dec.UseNumber()
+		if err := dec.Decode(&generic); err != nil {
+			return nil, err
+		}
+		if v, err = json.Marshal(generic); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Indent != "" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, v, "", opts.Indent); err != nil {
+			return nil, err
+		}
+		v = indented.Bytes()
+	}
+	if opts.TrailingNewline {
+		v = append(v, '\n')
+	}
+	return v, nil
+}
@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Permission describes access to be granted to a role with Grants.  Its
+// options are used to build an Allow statement with the supplied Sid.
+type Permission struct {
+	Sid  string
+	Opts []StatementOpt
+}
+
+// Permit returns a Permission from arbitrary statement options, for access
+// not covered by the presets such as S3Read.
+func Permit(sid string, opts ...StatementOpt) Permission {
+	return Permission{Sid: sid, Opts: opts}
+}
+
+// S3Read grants read access to the objects in a bucket, and permission to
+// list them.
+//
+// bucketArn may be a string or a StringInput, such as the Arn output of an
+// s3.Bucket.
+func S3Read(bucketArn interface{}) Permission {
+	return Permit("S3Read",
+		Action("s3:GetObject", "s3:GetObjectVersion", "s3:ListBucket", "s3:GetBucketLocation"),
+		Resource(bucketArn, withSuffix(bucketArn, "/*")),
+	)
+}
+
+// S3ReadWrite grants read, write and delete access to the objects in a
+// bucket, and permission to list them.
+//
+// bucketArn may be a string or a StringInput.
+func S3ReadWrite(bucketArn interface{}) Permission {
+	return Permit("S3ReadWrite",
+		Action("s3:GetObject", "s3:GetObjectVersion", "s3:ListBucket", "s3:GetBucketLocation",
+			"s3:PutObject", "s3:DeleteObject", "s3:AbortMultipartUpload"),
+		Resource(bucketArn, withSuffix(bucketArn, "/*")),
+	)
+}
+
+// DynamoDBRead grants read access to a table and its indexes.
+//
+// tableArn may be a string or a StringInput, such as the Arn output of a
+// dynamodb.Table.
+func DynamoDBRead(tableArn interface{}) Permission {
+	return Permit("DynamoDBRead",
+		Action("dynamodb:GetItem", "dynamodb:BatchGetItem", "dynamodb:Query", "dynamodb:Scan",
+			"dynamodb:DescribeTable", "dynamodb:ConditionCheckItem"),
+		Resource(tableArn, withSuffix(tableArn, "/index/*")),
+	)
+}
+
+// DynamoDBReadWrite grants read and write access to a table and its
+// indexes.
+//
+// tableArn may be a string or a StringInput.
+func DynamoDBReadWrite(tableArn interface{}) Permission {
+	return Permit("DynamoDBReadWrite",
+		Action("dynamodb:GetItem", "dynamodb:BatchGetItem", "dynamodb:Query", "dynamodb:Scan",
+			"dynamodb:DescribeTable", "dynamodb:ConditionCheckItem",
+			"dynamodb:PutItem", "dynamodb:UpdateItem", "dynamodb:DeleteItem", "dynamodb:BatchWriteItem"),
+		Resource(tableArn, withSuffix(tableArn, "/index/*")),
+	)
+}
+
+// SQSSend grants permission to send messages to a queue.
+//
+// queueArn may be a string or a StringInput, such as the Arn output of an
+// sqs.Queue.
+func SQSSend(queueArn interface{}) Permission {
+	return Permit("SQSSend",
+		Action("sqs:SendMessage", "sqs:GetQueueAttributes", "sqs:GetQueueUrl"),
+		Resource(queueArn),
+	)
+}
+
+// SQSConsume grants permission to receive and delete messages from a
+// queue.
+//
+// queueArn may be a string or a StringInput.
+func SQSConsume(queueArn interface{}) Permission {
+	return Permit("SQSConsume",
+		Action("sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility",
+			"sqs:GetQueueAttributes", "sqs:GetQueueUrl"),
+		Resource(queueArn),
+	)
+}
+
+// Grants accumulates permissions granted to roles throughout a program, and
+// materializes them as a single inline policy per role.
+//
+//	grants := policy.NewGrants("app")
+//	grants.Grant(apiRole, policy.S3Read(assets.Arn), policy.DynamoDBReadWrite(table.Arn))
+//	grants.Grant(workerRole, policy.SQSConsume(queue.Arn))
+//	if _, err := grants.Apply(ctx); err != nil {
+//		return err
+//	}
+//
+// Each role's policy is created as an iam.RolePolicy named after the
+// Grants and the order in which roles were first granted a permission, eg.
+// "app-0", "app-1".
+type Grants struct {
+	name  string
+	mu    sync.Mutex
+	roles []*iam.Role
+	perms map[*iam.Role][]Permission
+}
+
+// NewGrants creates an empty set of grants.  name is used to derive the
+// names of the policies and resources created by Apply.
+func NewGrants(name string) *Grants {
+	return &Grants{
+		name:  name,
+		perms: make(map[*iam.Role][]Permission),
+	}
+}
+
+// Grant adds permissions to those granted to role.  It may be called any
+// number of times for the same role.
+func (g *Grants) Grant(role *iam.Role, perms ...Permission) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.perms[role]; !ok {
+		g.roles = append(g.roles, role)
+	}
+	g.perms[role] = append(g.perms[role], perms...)
+}
+
+// Policy returns the policy granting the permissions accumulated for role,
+// or nil if it has been granted none.  Permissions sharing a Sid are
+// numbered to keep their statements' Sids unique.
+func (g *Grants) Policy(role *iam.Role) *Policy {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, r := range g.roles {
+		if r == role {
+			return g.policy(i)
+		}
+	}
+	return nil
+}
+
+func (g *Grants) policy(i int) *Policy {
+	perms := g.perms[g.roles[i]]
+	seen := make(map[string]int, len(perms))
+	opts := make([]Opt, len(perms))
+	for j, perm := range perms {
+		sid := perm.Sid
+		if seen[perm.Sid]++; seen[perm.Sid] > 1 {
+			sid += strconv.Itoa(seen[perm.Sid])
+		}
+		opts[j] = Statement(sid, append([]StatementOpt{Effect(Allow)}, perm.Opts...)...)
+	}
+	return New(fmt.Sprintf("%s-%d", g.name, i), opts...)
+}
+
+// Apply validates the accumulated policies, and creates an inline
+// iam.RolePolicy for each role, returning them in the order the roles
+// were first granted a permission.  opts are applied to every resource.
+func (g *Grants) Apply(ctx *pulumi.Context, opts ...pulumi.ResourceOption) ([]*iam.RolePolicy, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	policies := make([]*Policy, len(g.roles))
+	for i := range g.roles {
+		policies[i] = g.policy(i)
+		if err := policies[i].Validate(); err != nil {
+			return nil, fmt.Errorf("grants %q: %w", g.name, err)
+		}
+	}
+	result := make([]*iam.RolePolicy, len(g.roles))
+	for i, role := range g.roles {
+		rp, err := iam.NewRolePolicy(ctx, policies[i].ID, &iam.RolePolicyArgs{
+			Role:   role.Name,
+			Policy: policies[i].ToStringOutput(),
+		}, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("grants %q: failed to create role policy: %w", g.name, err)
+		}
+		result[i] = rp
+	}
+	return result, nil
+}
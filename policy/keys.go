@@ -0,0 +1,251 @@
+package policy
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// resourceTagKey returns the aws:ResourceTag condition key for tagKey.
+func resourceTagKey(tagKey string) string {
+	if tagKey == "" {
+		panic("empty tag key passed to a resource tag condition")
+	}
+	return "aws:ResourceTag/" + tagKey
+}
+
+// ResourceTagEquals adds a condition to a Statement that limits it to
+// resources whose tagKey tag is set to value, for attribute based access
+// control.
+//
+// value may be a string or a StringInput, such as the output of another
+// resource's tags.
+func ResourceTagEquals(tagKey string, value interface{}) StatementOpt {
+	return Condition("StringEquals", resourceTagKey(tagKey), value)
+}
+
+// ResourceTagLike adds a condition to a Statement that limits it to
+// resources whose tagKey tag matches pattern, which may contain * and ?
+// wildcards.
+//
+// pattern may be a string or a StringInput.
+func ResourceTagLike(tagKey string, pattern interface{}) StatementOpt {
+	return Condition("StringLike", resourceTagKey(tagKey), pattern)
+}
+
+// ResourceTagExists adds a condition to a Statement that limits it to
+// resources that have the tagKey tag, whatever its value.
+func ResourceTagExists(tagKey string) StatementOpt {
+	return Condition("Null", resourceTagKey(tagKey), "false")
+}
+
+// FromOrganization adds a condition to a Statement that limits it to
+// principals belonging to an account in the AWS Organizations
+// organization orgID, eg. to allow access to a resource from any account
+// in the organization.
+//
+// orgID may be a string or a StringInput, such as the Id output of an
+// organizations.Organization.
+func FromOrganization(orgID interface{}) StatementOpt {
+	return Condition("StringEquals", "aws:PrincipalOrgID", orgID)
+}
+
+// FromVPCEndpoint adds a condition to a Statement that limits it to
+// requests made through the VPC endpoint vpceID, eg. to restrict a bucket
+// to private access.
+//
+// vpceID may be a string, a StringInput or an IDInput, such as the ID of
+// an ec2.VpcEndpoint.
+func FromVPCEndpoint(vpceID interface{}) StatementOpt {
+	return Condition("StringEquals", "aws:SourceVpce", idValue("FromVPCEndpoint", vpceID))
+}
+
+// FromVPC adds a condition to a Statement that limits it to requests made
+// through a VPC endpoint in the VPC vpcID.
+//
+// vpcID may be a string, a StringInput or an IDInput, such as the ID of an
+// ec2.Vpc.
+func FromVPC(vpcID interface{}) StatementOpt {
+	return Condition("StringEquals", "aws:SourceVpc", idValue("FromVPC", vpcID))
+}
+
+// idValue converts an IDInput, which Strings can't hold, to a
+// StringOutput.
+func idValue(caller string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return v
+	case pulumi.IDInput:
+		// checked first, as IDOutput also implements StringInput
+		return v.ToIDOutput().ToStringOutput()
+	case pulumi.StringInput:
+		return v
+	default:
+		panic(fmt.Sprintf("unexpected type passed to %s: %T: %#v", caller, v, v))
+	}
+}
+
+// ValidUntil adds a condition to a Statement that limits it to requests
+// made before t, eg. for a temporary break-glass grant.  t is converted to
+// UTC and rendered in ISO 8601 format, eg. "2024-01-31T17:00:00Z".
+func ValidUntil(t time.Time) StatementOpt {
+	return Condition("DateLessThan", "aws:CurrentTime", isoTime(t))
+}
+
+// ValidBetween adds conditions to a Statement that limit it to requests
+// made after start and before end.
+//
+// Will panic if end is not after start.
+func ValidBetween(start, end time.Time) StatementOpt {
+	if !end.After(start) {
+		panic(fmt.Sprintf("ValidBetween end %s is not after start %s", isoTime(end), isoTime(start)))
+	}
+	return Combine(
+		Condition("DateGreaterThan", "aws:CurrentTime", isoTime(start)),
+		ValidUntil(end),
+	)
+}
+
+func isoTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// SourceIP adds a condition to a Statement that limits it to requests made
+// from one of the supplied IPv4 or IPv6 address ranges, in CIDR notation.
+//
+// cidrs may be strings, []strings, StringInputs or StringArrayInputs, such
+// as a list of office ranges exported by another stack.  Static values are
+// checked immediately, causing a panic if they aren't valid CIDR blocks;
+// outputs are checked once they resolve, failing the rendered policy.
+//
+// Note that aws:SourceIp isn't set for requests made through a VPC
+// endpoint; see FromVPCEndpoint.
+func SourceIP(cidrs ...interface{}) StatementOpt {
+	values := make([]interface{}, len(cidrs))
+	for i, c := range cidrs {
+		switch v := c.(type) {
+		case string:
+			mustCIDR(v)
+			values[i] = v
+		case []string:
+			for _, s := range v {
+				mustCIDR(s)
+			}
+			values[i] = v
+		case pulumi.StringInput:
+			values[i] = v.ToStringOutput().ApplyT(func(s string) (string, error) {
+				return s, checkCIDR(s)
+			}).(pulumi.StringOutput)
+		case pulumi.StringArrayInput:
+			values[i] = v.ToStringArrayOutput().ApplyT(func(ss []string) ([]string, error) {
+				for _, s := range ss {
+					if err := checkCIDR(s); err != nil {
+						return nil, err
+					}
+				}
+				return ss, nil
+			}).(pulumi.StringArrayOutput)
+		default:
+			panic(fmt.Sprintf("unexpected type passed to SourceIP: %T: %#v", c, c))
+		}
+	}
+	return Condition("IpAddress", "aws:SourceIp", values...)
+}
+
+func checkCIDR(s string) error {
+	if _, _, err := net.ParseCIDR(s); err != nil {
+		return fmt.Errorf("%w: %q is not a valid CIDR block for aws:SourceIp", ErrInvalidStatement, s)
+	}
+	return nil
+}
+
+func mustCIDR(s string) {
+	if err := checkCIDR(s); err != nil {
+		panic(err)
+	}
+}
+
+// GlobalServiceActions lists the actions of global services, such as IAM,
+// Organizations and Route 53, whose requests are made to us-east-1
+// regardless of where the caller is, so must be excluded from region
+// restrictions.  It's taken from the example region restriction SCP in the
+// AWS Organizations documentation.
+//
+// See https://docs.aws.amazon.com/organizations/latest/userguide/orgs_manage_policies_scps_examples_general.html
+var GlobalServiceActions = []string{
+	"a4b:*",
+	"acm:*",
+	"aws-marketplace-management:*",
+	"aws-marketplace:*",
+	"aws-portal:*",
+	"budgets:*",
+	"ce:*",
+	"chime:*",
+	"cloudfront:*",
+	"config:*",
+	"cur:*",
+	"directconnect:*",
+	"ec2:DescribeRegions",
+	"ec2:DescribeTransitGateways",
+	"ec2:DescribeVpnGateways",
+	"fms:*",
+	"globalaccelerator:*",
+	"health:*",
+	"iam:*",
+	"importexport:*",
+	"kms:*",
+	"mobileanalytics:*",
+	"networkmanager:*",
+	"organizations:*",
+	"pricing:*",
+	"route53-recovery-cluster:*",
+	"route53-recovery-control-config:*",
+	"route53-recovery-readiness:*",
+	"route53:*",
+	"route53domains:*",
+	"s3:GetAccountPublic*",
+	"s3:ListAllMyBuckets",
+	"s3:ListMultiRegionAccessPoints",
+	"s3:PutAccountPublic*",
+	"shield:*",
+	"sts:*",
+	"support:*",
+	"trustedadvisor:*",
+	"waf-regional:*",
+	"waf:*",
+	"wafv2:*",
+	"wellarchitected:*",
+}
+
+// RequestedRegion adds a condition to a Statement that limits it to
+// requests made to one of the supplied regions.
+//
+// regions may be strings, []strings, StringInputs or StringArrayInputs.
+func RequestedRegion(regions ...interface{}) StatementOpt {
+	return Condition("StringEquals", "aws:RequestedRegion", regions...)
+}
+
+// NotRequestedRegion adds a condition to a Statement that limits it to
+// requests made to any region other than those supplied.
+//
+// regions may be strings, []strings, StringInputs or StringArrayInputs.
+func NotRequestedRegion(regions ...interface{}) StatementOpt {
+	return Condition("StringNotEquals", "aws:RequestedRegion", regions...)
+}
+
+// RestrictRegions adds a "DenyOutsideRegions" statement to a policy that
+// denies every action, other than those of GlobalServiceActions, requested
+// in a region other than those supplied.  It's intended for guardrail
+// policies, such as service control policies and permissions boundaries.
+//
+// regions may be strings, []strings, StringInputs or StringArrayInputs.
+func RestrictRegions(regions ...interface{}) Opt {
+	return Statement("DenyOutsideRegions",
+		Effect(Deny),
+		NotAction(GlobalServiceActions),
+		Resource("*"),
+		NotRequestedRegion(regions...),
+	)
+}
@@ -0,0 +1,184 @@
+package policy
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// kind describes a particular type of policy document and the additional
+// rules it must follow beyond those of a regular IAM policy.
+type kind struct {
+	name     string
+	maxSize  int
+	validate func(p Policy) error
+
+	// countWhitespace is set if whitespace outside of quoted strings
+	// counts towards maxSize.
+	countWhitespace bool
+}
+
+// maxSCPSize is the maximum size of a service control policy, in characters.
+const maxSCPSize = 5120
+
+var scpKind = &kind{
+	name:     "service control policy",
+	maxSize:  maxSCPSize,
+	validate: validateSCP,
+}
+
+// KindSCP marks the policy as an AWS Organizations service control policy
+// (SCP).
+//
+// In addition to the regular checks, Validate will ensure that the Version
+// is set to 2012-10-17 and that no statement has a Principal, NotPrincipal
+// or NotResource element.  The rendered policy is also checked against the
+// 5120 character SCP quota (ignoring whitespace outside of quoted strings,
+// as Organizations does) once all of its inputs have resolved.
+//
+// See https://docs.aws.amazon.com/organizations/latest/userguide/orgs_manage_policies_scps_syntax.html
+func KindSCP() Opt {
+	return func(p *Policy) {
+		p.kind = scpKind
+	}
+}
+
+// maxManagedSize is the maximum size of a customer managed IAM policy, in
+// characters.
+const maxManagedSize = 6144
+
+var managedKind = &kind{
+	name:    "managed policy",
+	maxSize: maxManagedSize,
+}
+
+// KindManaged marks the policy as a customer managed IAM policy.
+//
+// The rendered policy is checked against the 6144 character quota for
+// managed policies (ignoring whitespace outside of quoted strings, as IAM
+// does) once all of its inputs have resolved.
+//
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_iam-quotas.html
+func KindManaged() Opt {
+	return func(p *Policy) {
+		p.kind = managedKind
+	}
+}
+
+// maxSessionSize is the maximum size of an STS session policy, in
+// characters.
+const maxSessionSize = 2048
+
+var sessionKind = &kind{
+	name:            "session policy",
+	maxSize:         maxSessionSize,
+	validate:        validateSession,
+	countWhitespace: true,
+}
+
+// KindSession marks the policy as an inline STS session policy, passed
+// when assuming a role or getting a federation token to further limit the
+// session's permissions.
+//
+// In addition to the regular checks, Validate will ensure that no
+// statement has a Principal or NotPrincipal element.  The rendered policy
+// is also checked against the 2048 character quota for session policies
+// once all of its inputs have resolved.  Unlike other quotas, whitespace
+// counts towards it.
+//
+// Meeting the quota isn't sufficient for STS to accept the policy, as it
+// must also fit, along with any session tags, within a packed binary
+// limit; see EstimateSessionSize.
+//
+// See https://docs.aws.amazon.com/IAM/latest/UserGuide/access_policies.html#policies_session
+func KindSession() Opt {
+	return func(p *Policy) {
+		p.kind = sessionKind
+	}
+}
+
+func validateSession(p Policy) error {
+	for _, s := range p.Statement {
+		if len(s.Principal) > 0 || len(s.NotPrincipal) > 0 {
+			return s.withSource(fmt.Errorf("%w: Principal and NotPrincipal are not supported for statement %q",
+				ErrInvalidStatement, s.Sid))
+		}
+	}
+	return nil
+}
+
+// SessionSize describes the size of a rendered session policy.
+type SessionSize struct {
+	// Plaintext is the number of characters in the document, as checked
+	// against the 2048 character quota.
+	Plaintext int
+	// Compact is the number of characters in the document once whitespace
+	// outside of quoted strings has been removed.  Rendering the document
+	// compactly is the simplest way to reduce its size.
+	Compact int
+	// PackedEstimate approximates the size of the document in STS's packed
+	// binary format, by compressing it and base64 encoding the result.
+	// STS doesn't document the format or its limit, reporting only the
+	// percentage used when a role is assumed, so this is only useful to
+	// compare documents, eg. to track how much a change will affect it.
+	PackedEstimate int
+}
+
+// EstimateSessionSize measures a rendered session policy document.
+func EstimateSessionSize(doc string) (SessionSize, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(doc)); err != nil {
+		return SessionSize{}, fmt.Errorf("%w: failed to parse policy: %v", ErrInvalidPolicy, err)
+	}
+	var packed bytes.Buffer
+	w, err := flate.NewWriter(&packed, flate.BestCompression)
+	if err != nil {
+		return SessionSize{}, err
+	}
+	if _, err := w.Write(compact.Bytes()); err != nil {
+		return SessionSize{}, err
+	}
+	if err := w.Close(); err != nil {
+		return SessionSize{}, err
+	}
+	return SessionSize{
+		Plaintext:      utf8.RuneCountInString(doc),
+		Compact:        utf8.RuneCount(compact.Bytes()),
+		PackedEstimate: base64.StdEncoding.EncodedLen(packed.Len()),
+	}, nil
+}
+
+func validateSCP(p Policy) error {
+	if p.Version != "2012-10-17" {
+		return fmt.Errorf("%w: Version must be set to %q", ErrInvalidPolicy, "2012-10-17")
+	}
+	for _, s := range p.Statement {
+		if len(s.Principal) > 0 || len(s.NotPrincipal) > 0 {
+			return s.withSource(fmt.Errorf("%w: Principal and NotPrincipal are not supported for statement %q",
+				ErrInvalidStatement, s.Sid))
+		}
+		if len(s.NotResource) > 0 {
+			return s.withSource(fmt.Errorf("%w: NotResource is not supported for statement %q",
+				ErrInvalidStatement, s.Sid))
+		}
+	}
+	return nil
+}
+
+// staticStrings returns the entries of s that are known at build time,
+// skipping any outputs.
+func staticStrings(s Strings) []string {
+	var out []string
+	for _, el := range s {
+		switch v := el.(type) {
+		case string:
+			out = append(out, v)
+		case []string:
+			out = append(out, v...)
+		}
+	}
+	return out
+}
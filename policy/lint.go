@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Finding describes a potential problem with a policy reported by Lint.
+type Finding struct {
+	// Rule is a short identifier for the check that produced the finding.
+	Rule string
+	// Sid identifies the statement the finding relates to.
+	Sid string
+	// Message describes the problem and how to address it.
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: statement %q: %s", f.Rule, f.Sid, f.Message)
+}
+
+// Lint checks the policy for patterns that are valid, but frequently grant
+// more access than intended:
+//
+//   - "admin-access": an Allow statement with an Action of "*" and a
+//     Resource of "*".
+//   - "passrole-without-condition": an Allow statement granting
+//     iam:PassRole without a Condition, such as iam:PassedToService.
+//   - "wildcard-principal": an Allow statement with a "*" principal and no
+//     Condition.
+//   - "open-assume-role": an Allow statement letting any AWS principal call
+//     sts:AssumeRole.
+//
+// Only values supplied as plain strings are considered; outputs are
+// skipped.  An empty slice is returned if no problems are found.
+func (p Policy) Lint() []Finding {
+	findings := []Finding{}
+	for _, s := range p.Statement {
+		if s.Effect != Allow {
+			continue
+		}
+		if s.matchesAction("*") && containsString(staticStrings(s.Resource), "*") {
+			findings = append(findings, Finding{"admin-access", s.Sid,
+				`Action "*" with Resource "*" grants full administrative access; list the required actions and resources`})
+		}
+		if s.matchesAction("iam:PassRole") && len(s.Condition) == 0 {
+			findings = append(findings, Finding{"passrole-without-condition", s.Sid,
+				"iam:PassRole is granted without a Condition; restrict it with iam:PassedToService " +
+					"and limit Resource to the roles that need to be passed"})
+		}
+		wildcard := s.hasWildcardPrincipal()
+		if wildcard && len(s.Condition) == 0 {
+			findings = append(findings, Finding{"wildcard-principal", s.Sid,
+				`a "*" principal without a Condition grants access to anyone; name the principals ` +
+					"or add a condition such as aws:PrincipalOrgID"})
+		}
+		if wildcard && s.matchesAction("sts:AssumeRole") {
+			findings = append(findings, Finding{"open-assume-role", s.Sid,
+				"any AWS principal may assume this role; name the trusted accounts or roles instead"})
+		}
+	}
+	return findings
+}
+
+// matchesAction reports whether any of the statement's static Action
+// entries match action, which may not contain wildcards.
+func (s Stmt) matchesAction(action string) bool {
+	action = strings.ToLower(action)
+	for _, a := range staticStrings(s.Action) {
+		if wildcardMatch(strings.ToLower(a), action) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWildcardPrincipal reports whether the statement's Principal element
+// includes "*", either as an AWS principal or as the catch-all principal.
+func (s Stmt) hasWildcardPrincipal() bool {
+	for _, ids := range s.Principal {
+		if containsString(staticStrings(ids), "*") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, el := range list {
+		if el == s {
+			return true
+		}
+	}
+	return false
+}
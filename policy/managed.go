@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// FromManagedPolicy looks up an existing managed policy, such as the AWS
+// managed arn:aws:iam::aws:policy/ReadOnlyAccess policy, and parses its
+// default version into a Policy.  opts are then applied to it, allowing
+// statements to be added with Statement or replaced with ReplaceStatement.
+//
+// The returned policy uses the ID of the supplied id argument.
+func FromManagedPolicy(ctx *pulumi.Context, id, arn string, opts ...Opt) (*Policy, error) {
+	result, err := iam.LookupPolicy(ctx, &iam.LookupPolicyArgs{Arn: &arn})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up managed policy %q: %w", arn, err)
+	}
+	p, err := Parse(result.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse managed policy %q: %w", arn, err)
+	}
+	p.ID = id
+	if p.Version == "" {
+		p.Version = "2012-10-17"
+	}
+	return p.Extend(opts...), nil
+}
+
+// NewManagedPolicy validates p and creates a customer managed iam.Policy
+// holding it.  opts are applied to the created resource.
+//
+// Unless the policy already has a kind set, it's treated as KindManaged,
+// so the rendered document is checked against the managed policy size
+// quota.  Policies that contain no outputs are checked before the resource
+// is created, returning an error wrapping ErrPolicyTooLarge; others are
+// checked once their inputs have resolved.
+func NewManagedPolicy(ctx *pulumi.Context, name string, p *Policy, opts ...pulumi.ResourceOption) (*iam.Policy, error) {
+	mp := *p
+	if mp.kind == nil {
+		mp.kind = managedKind
+	}
+	if err := mp.Validate(); err != nil {
+		return nil, err
+	}
+	if js, err := mp.staticJSON(); err == nil {
+		if err := mp.checkSize(js); err != nil {
+			return nil, err
+		}
+	}
+	return iam.NewPolicy(ctx, name, &iam.PolicyArgs{
+		Policy: mp.ToStringOutput(),
+	}, opts...)
+}
+
+// ReplaceStatement replaces the statement with the supplied Sid, keeping
+// its position in the policy.  If no statement with that Sid exists then
+// the new statement is appended, as with Statement.
+func ReplaceStatement(sid string, opts ...StatementOpt) Opt {
+	add := Statement(sid, opts...)
+	return func(p *Policy) {
+		var tmp Policy
+		add(&tmp)
+		for i, s := range p.Statement {
+			if s.Sid == sid {
+				p.Statement[i] = tmp.Statement[0]
+				return
+			}
+		}
+		p.Statement = append(p.Statement, tmp.Statement[0])
+	}
+}
+
+// RemoveStatement removes any statement with the supplied Sid.
+func RemoveStatement(sid string) Opt {
+	return func(p *Policy) {
+		stmts := p.Statement[:0]
+		for _, s := range p.Statement {
+			if s.Sid != sid {
+				stmts = append(stmts, s)
+			}
+		}
+		p.Statement = stmts
+	}
+}
@@ -0,0 +1,37 @@
+package policy
+
+// wildcardMatch reports whether s matches pattern, where "*" in pattern
+// matches any sequence of characters and "?" matches any single character,
+// as in IAM Action and Resource elements.
+func wildcardMatch(pattern, s string) bool {
+	var px, sx int
+	nextPx, nextSx := -1, -1
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				nextPx, nextSx = px, sx+1
+				px++
+				continue
+			case '?':
+				if sx < len(s) {
+					px++
+					sx++
+					continue
+				}
+			default:
+				if sx < len(s) && s[sx] == c {
+					px++
+					sx++
+					continue
+				}
+			}
+		}
+		if nextSx > 0 && nextSx <= len(s) {
+			px, sx = nextPx, nextSx
+			continue
+		}
+		return false
+	}
+	return true
+}
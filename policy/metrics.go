@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Metrics holds size and complexity measurements of a policy, useful for
+// tracking policy sprawl over time.
+type Metrics struct {
+	Statements        int
+	DistinctActions   int
+	DistinctResources int
+	WildcardActions   int // Action/NotAction entries containing * or ?
+	WildcardResources int // Resource/NotResource entries containing * or ?
+	Size              int // size of the rendered policy in bytes, with whitespace removed
+}
+
+// Map returns the metrics as a map, keyed by the field names, suitable for
+// exporting from a stack.
+func (m Metrics) Map() map[string]int {
+	return map[string]int{
+		"Statements":        m.Statements,
+		"DistinctActions":   m.DistinctActions,
+		"DistinctResources": m.DistinctResources,
+		"WildcardActions":   m.WildcardActions,
+		"WildcardResources": m.WildcardResources,
+		"Size":              m.Size,
+	}
+}
+
+// Metrics calculates the metrics of a policy that contains no outputs.
+// An error wrapping ErrUnresolved is returned if it does; use
+// MetricsOutput instead for such policies.
+func (p Policy) Metrics() (Metrics, error) {
+	js, err := p.staticJSON()
+	if err != nil {
+		return Metrics{}, err
+	}
+	return p.metrics(js), nil
+}
+
+// MetricsOutput calculates the metrics of the policy once all of its
+// inputs have resolved and returns them as a map, as returned by
+// Metrics.Map.
+func (p Policy) MetricsOutput() pulumi.IntMapOutput {
+	return pulumi.ToOutput(p).ApplyT(func(data interface{}) (map[string]int, error) {
+		resolved := data.(Policy)
+		js, err := json.Marshal(resolved)
+		if err != nil {
+			return nil, err
+		}
+		return resolved.metrics(js).Map(), nil
+	}).(pulumi.IntMapOutput)
+}
+
+// ExportMetrics exports the policy's metrics from the stack under name.
+func (p Policy) ExportMetrics(ctx *pulumi.Context, name string) {
+	ctx.Export(name, p.MetricsOutput())
+}
+
+func (p Policy) metrics(rendered []byte) Metrics {
+	m := Metrics{Statements: len(p.Statement)}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, rendered); err == nil {
+		m.Size = compact.Len()
+	}
+
+	actions := make(map[string]bool)
+	resources := make(map[string]bool)
+	for _, s := range p.Statement {
+		for _, a := range append(staticStrings(s.Action), staticStrings(s.NotAction)...) {
+			actions[strings.ToLower(a)] = true
+			if strings.ContainsAny(a, "*?") {
+				m.WildcardActions++
+			}
+		}
+		for _, r := range append(staticStrings(s.Resource), staticStrings(s.NotResource)...) {
+			resources[r] = true
+			if strings.ContainsAny(r, "*?") {
+				m.WildcardResources++
+			}
+		}
+	}
+	m.DistinctActions = len(actions)
+	m.DistinctResources = len(resources)
+	return m
+}
@@ -0,0 +1,159 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Parse builds a Policy from a JSON policy document, such as one returned
+// by an AWS API.  The returned policy can be extended by appending further
+// statements, eg. with the Extend method.
+//
+// A Principal of "*" is converted to the equivalent {"AWS": "*"} form and
+// non-string condition values, such as booleans, are converted to strings.
+func Parse(doc string) (*Policy, error) {
+	var raw struct {
+		Version   string
+		ID        string `json:"Id"`
+		Statement json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(doc), &raw); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse policy: %v", ErrInvalidPolicy, err)
+	}
+
+	var rawStmts []rawStmt
+	if s := bytes.TrimSpace(raw.Statement); len(s) > 0 && s[0] == '{' {
+		rawStmts = make([]rawStmt, 1)
+		if err := json.Unmarshal(s, &rawStmts[0]); err != nil {
+			return nil, fmt.Errorf("%w: failed to parse statement: %v", ErrInvalidPolicy, err)
+		}
+	} else if len(s) > 0 {
+		if err := json.Unmarshal(s, &rawStmts); err != nil {
+			return nil, fmt.Errorf("%w: failed to parse statements: %v", ErrInvalidPolicy, err)
+		}
+	}
+
+	p := &Policy{
+		Version: raw.Version,
+		ID:      raw.ID,
+	}
+	for _, rs := range rawStmts {
+		s, err := rs.toStmt()
+		if err != nil {
+			return nil, fmt.Errorf("%w: statement %q: %v", ErrInvalidStatement, rs.Sid, err)
+		}
+		p.Statement = append(p.Statement, s)
+	}
+	return p, nil
+}
+
+// Extend applies further options, such as additional statements, to an
+// existing policy.
+func (p *Policy) Extend(opts ...Opt) *Policy {
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+type rawStmt struct {
+	Sid          string
+	Effect       EffectType
+	Principal    json.RawMessage
+	NotPrincipal json.RawMessage
+	Action       json.RawMessage
+	NotAction    json.RawMessage
+	Resource     json.RawMessage
+	NotResource  json.RawMessage
+	Condition    map[string]map[string]json.RawMessage
+}
+
+func (rs rawStmt) toStmt() (s Stmt, err error) {
+	s = Stmt{Sid: rs.Sid, Effect: rs.Effect}
+	if s.Principal, err = parsePrincipal(rs.Principal); err != nil {
+		return s, err
+	}
+	if s.NotPrincipal, err = parsePrincipal(rs.NotPrincipal); err != nil {
+		return s, err
+	}
+	for _, el := range []struct {
+		raw json.RawMessage
+		out *Strings
+	}{
+		{rs.Action, &s.Action},
+		{rs.NotAction, &s.NotAction},
+		{rs.Resource, &s.Resource},
+		{rs.NotResource, &s.NotResource},
+	} {
+		if *el.out, err = parseStrings(el.raw); err != nil {
+			return s, err
+		}
+	}
+	if len(rs.Condition) > 0 {
+		s.Condition = make(map[string]map[string]Strings, len(rs.Condition))
+		for op, kv := range rs.Condition {
+			s.Condition[op] = make(map[string]Strings, len(kv))
+			for k, v := range kv {
+				if s.Condition[op][k], err = parseStrings(v); err != nil {
+					return s, err
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// parseStrings parses a single value or list of values into Strings.
+func parseStrings(raw json.RawMessage) (Strings, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var values []interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		values = []interface{}{v}
+	}
+	out := make(Strings, 0, len(values))
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			out = append(out, v)
+		case bool, float64:
+			out = append(out, fmt.Sprint(v))
+		default:
+			return nil, fmt.Errorf("unexpected value %v", v)
+		}
+	}
+	return out, nil
+}
+
+func parsePrincipal(raw json.RawMessage) (map[string]Strings, error) {
+	out := map[string]Strings{}
+	if len(raw) == 0 {
+		return out, nil
+	}
+	var star string
+	if err := json.Unmarshal(raw, &star); err == nil {
+		if star != "*" {
+			return nil, fmt.Errorf("invalid principal %q", star)
+		}
+		out["AWS"] = Strings{"*"}
+		return out, nil
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	for pt, v := range m {
+		s, err := parseStrings(v)
+		if err != nil {
+			return nil, err
+		}
+		out[pt] = s
+	}
+	return out, nil
+}
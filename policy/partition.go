@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPartitionMismatch is returned by Validate if an ARN in the policy
+// does not belong to the partition set by ExpectPartition.
+var ErrPartitionMismatch = errors.New("ARN partition mismatch")
+
+// ExpectPartition causes Validate to check that every ARN supplied as a
+// plain string in the Resource, NotResource, Principal and NotPrincipal
+// elements of the policy belongs to the supplied partition, such as "aws",
+// "aws-cn" or "aws-us-gov".
+func ExpectPartition(partition string) Opt {
+	return func(p *Policy) {
+		p.partition = partition
+	}
+}
+
+// SubstitutePartition rewrites ARNs in the Resource, NotResource, Principal
+// and NotPrincipal elements that use the standard "aws" partition to use
+// the supplied partition instead, once the policy's outputs have resolved.
+// It also implies ExpectPartition, allowing "aws" ARNs to pass validation.
+//
+// The current partition is usually obtained from the provider:
+//
+//	part, err := aws.GetPartition(ctx, nil)
+//	...
+//	policy.New("my-policy", policy.SubstitutePartition(part.Partition), ...)
+func SubstitutePartition(partition string) Opt {
+	return func(p *Policy) {
+		p.partition = partition
+		p.substitutePartition = true
+	}
+}
+
+// arnPartition returns the partition field of s, if it is an ARN.
+func arnPartition(s string) (string, bool) {
+	if !strings.HasPrefix(s, "arn:") {
+		return "", false
+	}
+	part := s[len("arn:"):]
+	if i := strings.IndexByte(part, ':'); i >= 0 {
+		return part[:i], true
+	}
+	return "", false
+}
+
+// arnElements returns the elements of the statement that may contain ARNs.
+func (s Stmt) arnElements() []Strings {
+	elements := []Strings{s.Resource, s.NotResource}
+	for _, ids := range s.Principal {
+		elements = append(elements, ids)
+	}
+	for _, ids := range s.NotPrincipal {
+		elements = append(elements, ids)
+	}
+	return elements
+}
+
+func (p Policy) checkPartition() error {
+	for _, s := range p.Statement {
+		for _, el := range s.arnElements() {
+			for _, v := range staticStrings(el) {
+				part, ok := arnPartition(v)
+				if !ok || part == p.partition || (p.substitutePartition && part == "aws") {
+					continue
+				}
+				return s.withSource(fmt.Errorf("%w: %q in statement %q is in partition %q, expected %q",
+					ErrPartitionMismatch, v, s.Sid, part, p.partition))
+			}
+		}
+	}
+	return nil
+}
+
+// withPartition returns a copy of the resolved policy p with the partition
+// of any standard aws ARNs replaced with partition.
+func (p Policy) withPartition(partition string) Policy {
+	replace := func(s Strings) Strings {
+		if s == nil {
+			return nil
+		}
+		out := make(Strings, 0, len(s))
+		for _, v := range s.flatten() {
+			if part, ok := arnPartition(v); ok && part == "aws" {
+				v = "arn:" + partition + v[len("arn:aws"):]
+			}
+			out = append(out, v)
+		}
+		return out
+	}
+	replaceMap := func(m map[string]Strings) map[string]Strings {
+		out := make(map[string]Strings, len(m))
+		for k, v := range m {
+			out[k] = replace(v)
+		}
+		return out
+	}
+
+	stmts := make(Stmts, len(p.Statement))
+	for i, s := range p.Statement {
+		s.Resource = replace(s.Resource)
+		s.NotResource = replace(s.NotResource)
+		s.Principal = replaceMap(s.Principal)
+		s.NotPrincipal = replaceMap(s.NotPrincipal)
+		stmts[i] = s
+	}
+	p.Statement = stmts
+	return p
+}
@@ -40,10 +40,12 @@
 package policy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -52,6 +54,8 @@ import (
 var (
 	ErrInvalidPolicy    = errors.New("invalid policy")
 	ErrInvalidStatement = errors.New("invalid statement")
+	ErrPolicyTooLarge   = errors.New("policy too large")
+	ErrStrictViolation  = errors.New("strict validation failed")
 )
 
 // EffectType is used with the Effect element of a Statement.
@@ -72,17 +76,98 @@ type Policy struct {
 	Version   string
 	ID        string `json:"Id"`
 	Statement Stmts
+
+	kind       *kind
+	strict     bool
+	autoEscape bool
+	secret     bool
+	catalog    *ActionCatalog
+	keyCatalog *ActionCatalog
+
+	checkConditions     bool
+	partition           string
+	substitutePartition bool
+	rules               []Rule
+	renderOpts          *RenderOptions
 }
 
-// Validate performs a basic structural check of the Policy.
+// Validate performs a basic structural check of the Policy, including
+// checking that no two statements share the same Sid.
 func (p Policy) Validate() error {
 	if p.Version == "" || p.ID == "" {
 		return fmt.Errorf("%w: policy %q has no version or id set", ErrInvalidPolicy, p.ID)
 	}
-	for _, s := range p.Statement {
+	sids := make(map[string]int, len(p.Statement))
+	for i, s := range p.Statement {
 		if err := s.Validate(); err != nil {
 			return fmt.Errorf("policy %q has errors: %w", p.ID, err)
 		}
+		if s.Sid == "" {
+			continue
+		}
+		if j, ok := sids[s.Sid]; ok {
+			err := fmt.Errorf("%w: policy %q has duplicate Sid %q for statements %d and %d",
+				ErrInvalidPolicy, p.ID, s.Sid, j+1, i+1)
+			if first := p.Statement[j].source; first != "" && s.source != "" {
+				err = fmt.Errorf("%w (defined at %s and %s)", err, first, s.source)
+			}
+			return err
+		}
+		sids[s.Sid] = i
+	}
+	if p.strict {
+		for _, s := range p.Statement {
+			if err := s.withSource(s.validateStrict()); err != nil {
+				return fmt.Errorf("policy %q has errors: %w", p.ID, err)
+			}
+		}
+	}
+	if p.checkConditions {
+		for _, s := range p.Statement {
+			if err := s.ValidateConditions(); err != nil {
+				return fmt.Errorf("policy %q has errors: %w", p.ID, err)
+			}
+		}
+	}
+	if p.catalog != nil {
+		if err := p.checkActions(); err != nil {
+			return fmt.Errorf("policy %q has errors: %w", p.ID, err)
+		}
+	}
+	if p.keyCatalog != nil {
+		if err := p.checkConditionKeys(); err != nil {
+			return fmt.Errorf("policy %q has errors: %w", p.ID, err)
+		}
+	}
+	if p.partition != "" {
+		if err := p.checkPartition(); err != nil {
+			return fmt.Errorf("policy %q has errors: %w", p.ID, err)
+		}
+	}
+	if p.kind != nil && p.kind.validate != nil {
+		if err := p.kind.validate(p); err != nil {
+			return fmt.Errorf("policy %q is not a valid %s: %w", p.ID, p.kind.name, err)
+		}
+	}
+	return p.checkRules()
+}
+
+// checkSize verifies that the rendered policy falls within the size quota
+// of the policy's kind, if any.
+func (p Policy) checkSize(rendered []byte) error {
+	if p.kind == nil || p.kind.maxSize == 0 {
+		return nil
+	}
+	if !p.kind.countWhitespace {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, rendered); err != nil {
+			return err
+		}
+		rendered = compact.Bytes()
+	}
+	if n := utf8.RuneCount(rendered); n > p.kind.maxSize {
+		return fmt.Errorf("%w: %s %q is %d characters long; the maximum is %d",
+			ErrPolicyTooLarge, p.kind.name, p.ID, n, p.kind.maxSize)
 	}
 	return nil
 }
@@ -95,19 +180,60 @@ func (p Policy) ToStringOutput() pulumi.StringOutput {
 
 // ToStringOutputWithContext generates a formatted JSON policy as a suitable input
 // for various AWS objects that require one.
+//
+// The output will be marked as secret if any of the policy's inputs are
+// secret, or if the AsSecret option was supplied.
 func (p Policy) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
 	if err := p.Validate(); err != nil {
 		panic(err)
 	}
-	return pulumi.ToOutput(p).ApplyTWithContext(ctx, func(_, data interface{}) string {
-		v, err := json.MarshalIndent(data, "", "    ")
+	recordAudit(p)
+	out := p.render(ctx)
+	if p.secret {
+		return pulumi.ToSecretWithContext(ctx, out).(pulumi.StringOutput)
+	}
+	return out
+}
+
+func (p Policy) render(ctx context.Context) pulumi.StringOutput {
+	return pulumi.ToOutput(p).ApplyTWithContext(ctx, func(_, data interface{}) (string, error) {
+		if p.substitutePartition {
+			data = data.(Policy).withPartition(p.partition)
+		}
+		if p.autoEscape {
+			data = data.(Policy).escaped()
+		}
+		v, err := p.marshal(data)
 		if err != nil {
 			panic(fmt.Sprintf("failed to marshal json for policy %q: %v", p.ID, err))
 		}
-		return string(v)
+		if err := p.checkSize(v); err != nil {
+			return "", err
+		}
+		return string(v), nil
 	}).(pulumi.StringOutput)
 }
 
+// ToMapOutput generates the policy as a structured map rather than a
+// JSON string, for resources (such as those in the aws-native provider)
+// that accept policy documents as objects.
+func (p Policy) ToMapOutput() pulumi.MapOutput {
+	return p.ToMapOutputWithContext(context.Background())
+}
+
+// ToMapOutputWithContext generates the policy as a structured map rather
+// than a JSON string, for resources (such as those in the aws-native
+// provider) that accept policy documents as objects.
+func (p Policy) ToMapOutputWithContext(ctx context.Context) pulumi.MapOutput {
+	return p.ToStringOutputWithContext(ctx).ApplyTWithContext(ctx, func(_ context.Context, js string) (map[string]interface{}, error) {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(js), &m); err != nil {
+			return nil, fmt.Errorf("failed to parse json for policy %q: %w", p.ID, err)
+		}
+		return m, nil
+	}).(pulumi.MapOutput)
+}
+
 // Stmts holds an ordered group of statements.
 type Stmts []Stmt
 
@@ -122,10 +248,20 @@ type Stmt struct {
 	Resource     Strings                       `json:",omitempty"`
 	NotResource  Strings                       `json:",omitempty"`
 	Condition    map[string]map[string]Strings `json:",omitempty"`
+
+	// source is the file:line the statement was defined at, if known.
+	source string
 }
 
 // Validate does some very basic checks to ensure required fields are present.
+//
+// Errors for statements built with Statement include the location in the
+// program that the statement was defined.
 func (s Stmt) Validate() error {
+	return s.withSource(s.validate())
+}
+
+func (s Stmt) validate() error {
 	if s.Effect != Allow && s.Effect != Deny {
 		return fmt.Errorf("%w: invalid Effect element for statement %q: %q",
 			ErrInvalidStatement, s.Sid, s.Effect)
@@ -147,7 +283,27 @@ func (s Stmt) Validate() error {
 			ErrInvalidStatement, s.Sid)
 	}
 	return nil
+}
 
+// validateStrict checks for statement element combinations that are valid,
+// but commonly grant or deny far more than intended.
+func (s Stmt) validateStrict() error {
+	if s.Effect == Allow && len(s.NotAction) > 0 {
+		return fmt.Errorf("%w: statement %q combines Allow with NotAction, which grants every action "+
+			"not listed, including those from services added in future; list the allowed actions "+
+			"with Action instead", ErrStrictViolation, s.Sid)
+	}
+	if s.Effect == Allow && len(s.NotResource) > 0 {
+		return fmt.Errorf("%w: statement %q combines Allow with NotResource, which grants access to "+
+			"every resource not listed; list the allowed resources with Resource instead",
+			ErrStrictViolation, s.Sid)
+	}
+	if s.Effect == Deny && len(s.NotPrincipal) > 0 {
+		return fmt.Errorf("%w: statement %q combines Deny with NotPrincipal, which also denies the "+
+			"assumed-role sessions and accounts of the listed principals unless each is listed "+
+			"explicitly; use a Condition on aws:PrincipalArn instead", ErrStrictViolation, s.Sid)
+	}
+	return nil
 }
 
 // Strings is a convenience helper that marshals its entries either to a
@@ -196,16 +352,39 @@ func New(id string, opts ...Opt) *Policy {
 	return p
 }
 
+// Strict enables additional validation of the policy that rejects
+// statements combining Allow with NotAction or NotResource, or Deny with
+// NotPrincipal.  These combinations are valid, but are a common cause of
+// policies granting far broader access than intended.
+func Strict() Opt {
+	return func(p *Policy) {
+		p.strict = true
+	}
+}
+
+// AsSecret marks the rendered policy as a secret output, regardless of
+// whether any of its inputs are secret.
+func AsSecret() Opt {
+	return func(p *Policy) {
+		p.secret = true
+	}
+}
+
 // StatementOpt is implemented by functions that can be passed to Statement.
 type StatementOpt func(*Stmt)
 
 // Statement defines a single policy statement that can be passed to New.
+//
+// The location of the call is recorded, to be included in any errors
+// returned by Validate for the statement.
 func Statement(sid string, opts ...StatementOpt) Opt {
+	source := callerLocation()
 	return func(p *Policy) {
 		s := Stmt{
 			Sid:          sid,
 			Principal:    map[string]Strings{},
 			NotPrincipal: map[string]Strings{},
+			source:       source,
 		}
 		for _, opt := range opts {
 			opt(&s)
@@ -2,9 +2,18 @@ package policy
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/stretchr/testify/assert"
@@ -46,6 +55,8 @@ func TestPolicy(t *testing.T) {
 		},
 	}
 
+	_, file, line, _ := runtime.Caller(0)
+	expected.Statement[0].source = fmt.Sprintf("%s:%d", file, line+3)
 	p := New("test-id",
 		Statement("stmt1",
 			Effect(Deny),
@@ -232,3 +243,1890 @@ func TestMultiStmtJSON(t *testing.T) {
 	}, pulumi.WithMocks("project", "stack", mocks(0)))
 	wg.Wait()
 }
+
+var scpTests = []struct {
+	name     string
+	version  string
+	effect   EffectType
+	stmt     []StatementOpt
+	expected error
+}{
+	{
+		name:     "ok",
+		effect:   Allow,
+		stmt:     []StatementOpt{Action("s3:*"), Resource("*")},
+		expected: nil,
+	}, {
+		name:     "bad-version",
+		version:  "2008-10-17",
+		effect:   Allow,
+		stmt:     []StatementOpt{Action("s3:*"), Resource("*")},
+		expected: ErrInvalidPolicy,
+	}, {
+		name:     "principal",
+		effect:   Allow,
+		stmt:     []StatementOpt{Action("s3:*"), Principal("AWS", "*")},
+		expected: ErrInvalidStatement,
+	}, {
+		name:     "not-principal",
+		effect:   Deny,
+		stmt:     []StatementOpt{Action("s3:*"), NotPrincipal("AWS", "*")},
+		expected: ErrInvalidStatement,
+	}, {
+		name:     "not-resource",
+		effect:   Deny,
+		stmt:     []StatementOpt{Action("s3:*"), NotResource("arn")},
+		expected: ErrInvalidStatement,
+	}, {
+		name:   "allow-not-action",
+		effect: Allow,
+		stmt:   []StatementOpt{NotAction("iam:*"), Resource("*")},
+	}, {
+		name:   "allow-condition",
+		effect: Allow,
+		stmt: []StatementOpt{Action("s3:*"), Resource("*"),
+			Condition("StringEquals", "aws:RequestedRegion", "us-east-1")},
+	}, {
+		name:   "deny-condition",
+		effect: Deny,
+		stmt: []StatementOpt{Action("s3:*"), Resource("*"),
+			Condition("StringNotEquals", "aws:RequestedRegion", "us-east-1")},
+	},
+}
+
+func TestSCPValidate(t *testing.T) {
+	for _, test := range scpTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			p := New("id", KindSCP(),
+				Statement("stmt1", append([]StatementOpt{Effect(test.effect)}, test.stmt...)...),
+			)
+			if test.version != "" {
+				p.Version = test.version
+			}
+			err := p.Validate()
+			if test.expected == nil {
+				assert.NoError(err)
+			} else {
+				assert.ErrorIs(err, test.expected)
+			}
+		})
+	}
+}
+
+func TestSCPSize(t *testing.T) {
+	assert := assert.New(t)
+	p := New("id", KindSCP())
+
+	assert.NoError(p.checkSize([]byte(`{"Version": "2012-10-17",   "Statement": []}`)))
+
+	large := []byte(`{"Sid": "` + strings.Repeat("x", maxSCPSize) + `"}`)
+	assert.ErrorIs(p.checkSize(large), ErrPolicyTooLarge)
+
+	// policies without a kind have no size limit
+	assert.NoError(New("id").checkSize(large))
+}
+
+func TestSCPSizeOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New("id", KindSCP(),
+			Statement("stmt1",
+				Effect(Deny),
+				Action("s3:*"),
+				Resource(pulumi.String(strings.Repeat("x", maxSCPSize))),
+			),
+		)
+		ctx.Export("policy", p.ToStringOutput())
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.ErrorIs(err, ErrPolicyTooLarge)
+}
+
+func TestPermissionsBoundary(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		boundaryArn := pulumi.String("arn:aws:iam::123:policy/boundary").ToStringOutput()
+		p := PermissionsBoundary("boundary", boundaryArn, "s3", "sqs")
+		assert.NoError(p.Validate())
+
+		expected := `{
+			"Version": "2012-10-17",
+			"Id": "boundary",
+			"Statement": [{
+				"Sid": "AllowServices",
+				"Effect": "Allow",
+				"Action": ["s3:*", "sqs:*"],
+				"Resource": "*"
+			}, {
+				"Sid": "DenyPrincipalsWithoutBoundary",
+				"Effect": "Deny",
+				"Action": [
+					"iam:AttachRolePolicy",
+					"iam:AttachUserPolicy",
+					"iam:CreateRole",
+					"iam:CreateUser",
+					"iam:DeleteRolePolicy",
+					"iam:DeleteUserPolicy",
+					"iam:DetachRolePolicy",
+					"iam:DetachUserPolicy",
+					"iam:PutRolePermissionsBoundary",
+					"iam:PutRolePolicy",
+					"iam:PutUserPermissionsBoundary",
+					"iam:PutUserPolicy"
+				],
+				"Resource": "*",
+				"Condition": {
+					"StringNotEquals": {
+						"iam:PermissionsBoundary": "arn:aws:iam::123:policy/boundary"
+					}
+				}
+			}, {
+				"Sid": "DenyBoundaryRemoval",
+				"Effect": "Deny",
+				"Action": [
+					"iam:DeleteRolePermissionsBoundary",
+					"iam:DeleteUserPermissionsBoundary"
+				],
+				"Resource": "*"
+			}, {
+				"Sid": "DenyBoundaryModification",
+				"Effect": "Deny",
+				"Action": [
+					"iam:CreatePolicyVersion",
+					"iam:DeletePolicy",
+					"iam:DeletePolicyVersion",
+					"iam:SetDefaultPolicyVersion"
+				],
+				"Resource": "arn:aws:iam::123:policy/boundary"
+			}]
+		}`
+		p.ToStringOutput().ApplyT(func(js string) int {
+			assert.JSONEq(expected, js)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestPermissionsBoundaryNoServices(t *testing.T) {
+	assert.Panics(t, func() {
+		PermissionsBoundary("boundary", "arn:aws:iam::123:policy/boundary")
+	})
+}
+
+var accessPointTests = []struct {
+	name     string
+	stmt     []StatementOpt
+	expected error
+}{
+	{
+		name: "ok",
+		stmt: []StatementOpt{
+			Principal("AWS", "arn:aws:iam::123456789012:role/reader"),
+			Resource(AccessPointObjects("arn:aws:s3:us-east-1:123456789012:accesspoint/ap")),
+			CalledVia("s3-object-lambda.amazonaws.com"),
+		},
+	}, {
+		name: "output-resource",
+		stmt: []StatementOpt{
+			Principal("AWS", "*"),
+			Resource(AccessPointObjects(pulumi.String("arn").ToStringOutput())),
+		},
+	}, {
+		name: "no-principal",
+		stmt: []StatementOpt{
+			Resource("arn:aws:s3:us-east-1:123456789012:accesspoint/ap"),
+		},
+		expected: ErrInvalidStatement,
+	}, {
+		name: "bucket-resource",
+		stmt: []StatementOpt{
+			Principal("AWS", "*"),
+			Resource("arn:aws:s3:::bucket/*"),
+		},
+		expected: ErrInvalidStatement,
+	},
+}
+
+func TestAccessPointValidate(t *testing.T) {
+	for _, test := range accessPointTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			p := New("id", KindS3AccessPoint(),
+				Statement("stmt1", append([]StatementOpt{Effect(Allow), Action("s3:GetObject")}, test.stmt...)...),
+			)
+			err := p.Validate()
+			if test.expected == nil {
+				assert.NoError(err)
+			} else {
+				assert.ErrorIs(err, test.expected)
+			}
+		})
+	}
+}
+
+func TestDelegateToAccessPoints(t *testing.T) {
+	assert := assert.New(t)
+
+	p := DelegateToAccessPoints("id", "arn:aws:s3:::bucket", "123456789012")
+	assert.NoError(p.Validate())
+	out, err := json.Marshal(p)
+	assert.NoError(err)
+	assert.JSONEq(`{
+		"Version": "2012-10-17",
+		"Id": "id",
+		"Statement": [{
+			"Sid": "DelegateToAccessPoints",
+			"Effect": "Allow",
+			"Principal": {"AWS": "*"},
+			"Action": "*",
+			"Resource": ["arn:aws:s3:::bucket", "arn:aws:s3:::bucket/*"],
+			"Condition": {
+				"StringEquals": {"s3:DataAccessPointAccount": "123456789012"}
+			}
+		}]
+	}`, string(out))
+}
+
+var strictTests = []struct {
+	name     string
+	effect   EffectType
+	stmt     []StatementOpt
+	expected error
+}{
+	{
+		name:   "allow-action",
+		effect: Allow,
+		stmt:   []StatementOpt{Action("s3:GetObject"), Resource("*")},
+	}, {
+		name:     "allow-not-action",
+		effect:   Allow,
+		stmt:     []StatementOpt{NotAction("iam:*"), Resource("*")},
+		expected: ErrStrictViolation,
+	}, {
+		name:     "allow-not-resource",
+		effect:   Allow,
+		stmt:     []StatementOpt{Action("s3:GetObject"), NotResource("arn:aws:s3:::secret/*")},
+		expected: ErrStrictViolation,
+	}, {
+		name:   "deny-not-action",
+		effect: Deny,
+		stmt:   []StatementOpt{NotAction("iam:*"), Resource("*")},
+	}, {
+		name:     "deny-not-principal",
+		effect:   Deny,
+		stmt:     []StatementOpt{Action("s3:*"), NotPrincipal("AWS", "arn:aws:iam::123:role/admin")},
+		expected: ErrStrictViolation,
+	},
+}
+
+func TestStrictValidate(t *testing.T) {
+	for _, test := range strictTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			stmt := Statement("stmt1", append([]StatementOpt{Effect(test.effect)}, test.stmt...)...)
+
+			// without Strict every combination is valid
+			assert.NoError(New("id", stmt).Validate())
+
+			err := New("id", Strict(), stmt).Validate()
+			if test.expected == nil {
+				assert.NoError(err)
+			} else {
+				assert.ErrorIs(err, test.expected)
+			}
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("arn:aws:s3:::bucket/${$}{literal}", Escape("arn:aws:s3:::bucket/${literal}"))
+	assert.Equal("no-vars", Escape("no-vars"))
+	assert.Equal([]string{"${$}{a}", "b"}, Escape([]string{"${a}", "b"}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		out := Escape(pulumi.String("${out}")).(pulumi.StringOutput)
+		out.ApplyT(func(s string) int {
+			assert.Equal("${$}{out}", s)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestAutoEscape(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New("id", AutoEscape(),
+			Statement("stmt1",
+				Effect(Allow),
+				Action("s3:GetObject"),
+				Resource(pulumi.String("arn:aws:s3:::bucket/${key}")),
+				Condition("StringEquals", "s3:prefix", "${prefix}"),
+			),
+		)
+
+		expected := `{
+			"Version": "2012-10-17",
+			"Id": "id",
+			"Statement": [{
+				"Sid": "stmt1",
+				"Effect": "Allow",
+				"Action": "s3:GetObject",
+				"Resource": "arn:aws:s3:::bucket/${$}{key}",
+				"Condition": {
+					"StringEquals": {"s3:prefix": "${$}{prefix}"}
+				}
+			}]
+		}`
+		p.ToStringOutput().ApplyT(func(js string) int {
+			assert.JSONEq(expected, js)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestSecret(t *testing.T) {
+	assert := assert.New(t)
+
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		stmt := func(resource interface{}) Opt {
+			return Statement("stmt1",
+				Effect(Allow),
+				Action("s3:GetObject"),
+				Resource(resource),
+			)
+		}
+		assert.False(pulumi.IsSecret(New("id", stmt("arn")).ToStringOutput()))
+		assert.True(pulumi.IsSecret(New("id", stmt(pulumi.ToSecret(pulumi.String("arn")))).ToStringOutput()))
+		assert.True(pulumi.IsSecret(New("id", AsSecret(), stmt("arn")).ToStringOutput()))
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+}
+
+var wildcardTests = []struct {
+	pattern  string
+	s        string
+	expected bool
+}{
+	{"getobject", "getobject", true},
+	{"get*", "getobject", true},
+	{"*object", "getobject", true},
+	{"get?bject", "getobject", true},
+	{"*", "", true},
+	{"get*tag*", "getobjecttagging", true},
+	{"put*", "getobject", false},
+	{"get?", "getobject", false},
+	{"getobject", "getobjectacl", false},
+}
+
+func TestWildcardMatch(t *testing.T) {
+	for _, test := range wildcardTests {
+		assert.Equal(t, test.expected, wildcardMatch(test.pattern, test.s), "%s ~ %s", test.pattern, test.s)
+	}
+}
+
+var catalogTests = []struct {
+	action   string
+	expected error
+}{
+	{"*", nil},
+	{"s3:GetObject", nil},
+	{"S3:getobject", nil},
+	{"s3:Get*", nil},
+	{"s3:*", nil},
+	{"ec2:DescribeInstances", nil},
+	{"s3:GetObjects", ErrUnknownAction},
+	{"s3:Fetch*", ErrUnknownAction},
+	{"s4:GetObject", ErrUnknownAction},
+	{"GetObject", ErrUnknownAction},
+	{"s3:", ErrUnknownAction},
+}
+
+func TestActionCatalog(t *testing.T) {
+	for _, test := range catalogTests {
+		err := DefaultCatalog.Check(test.action)
+		if test.expected == nil {
+			assert.NoError(t, err, test.action)
+		} else {
+			assert.ErrorIs(t, err, test.expected, test.action)
+		}
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	assert := assert.New(t)
+
+	stmt := Statement("stmt1",
+		Effect(Allow),
+		Action("s3:GetObject", "sqs:SendMesage", pulumi.String("not:checked")),
+		Resource("*"),
+	)
+	assert.NoError(New("id", stmt).Validate())
+	assert.ErrorIs(New("id", ValidateActions(DefaultCatalog), stmt).Validate(), ErrUnknownAction)
+
+	c := NewActionCatalog()
+	c.AddService("custom", "DoThing")
+	assert.NoError(New("id", ValidateActions(c),
+		Statement("stmt1", Effect(Allow), Action("custom:Do*"), Resource("*")),
+	).Validate())
+}
+
+var lintTests = []struct {
+	name     string
+	stmt     []StatementOpt
+	expected []string
+}{
+	{
+		name:     "clean",
+		stmt:     []StatementOpt{Effect(Allow), Action("s3:GetObject"), Resource("arn:aws:s3:::b/*")},
+		expected: []string{},
+	}, {
+		name:     "admin",
+		stmt:     []StatementOpt{Effect(Allow), Action("*"), Resource("*")},
+		expected: []string{"admin-access", "passrole-without-condition"},
+	}, {
+		name:     "deny-admin",
+		stmt:     []StatementOpt{Effect(Deny), Action("*"), Resource("*")},
+		expected: []string{},
+	}, {
+		name:     "passrole",
+		stmt:     []StatementOpt{Effect(Allow), Action("iam:Pass*"), Resource("*")},
+		expected: []string{"passrole-without-condition"},
+	}, {
+		name: "passrole-condition",
+		stmt: []StatementOpt{Effect(Allow), Action("iam:PassRole"), Resource("*"),
+			Condition("StringEquals", "iam:PassedToService", "ec2.amazonaws.com")},
+		expected: []string{},
+	}, {
+		name:     "wildcard-principal",
+		stmt:     []StatementOpt{Effect(Allow), Action("s3:GetObject"), Principal("AWS", "*")},
+		expected: []string{"wildcard-principal"},
+	}, {
+		name:     "open-assume-role",
+		stmt:     []StatementOpt{Effect(Allow), Action("sts:AssumeRole"), Principal("AWS", "*")},
+		expected: []string{"wildcard-principal", "open-assume-role"},
+	},
+}
+
+func TestLint(t *testing.T) {
+	for _, test := range lintTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			rules := []string{}
+			for _, f := range New("id", Statement("stmt1", test.stmt...)).Lint() {
+				rules = append(rules, f.Rule)
+			}
+			assert.Equal(t, test.expected, rules)
+		})
+	}
+}
+
+var evaluatePolicy = New("id",
+	Statement("read",
+		Effect(Allow),
+		Action("s3:Get*", "s3:ListBucket"),
+		Resource("arn:aws:s3:::bucket", "arn:aws:s3:::bucket/*"),
+	),
+	Statement("deny-secret",
+		Effect(Deny),
+		Action("s3:*"),
+		Resource("arn:aws:s3:::bucket/secret/*"),
+	),
+	Statement("write-from-vpce",
+		Effect(Allow),
+		Action("s3:PutObject"),
+		Resource("arn:aws:s3:::bucket/*"),
+		Condition("StringEquals", "aws:SourceVpce", "vpce-1234"),
+	),
+	Statement("deny-insecure",
+		Effect(Deny),
+		NotAction("s3:ListBucket"),
+		Resource("*"),
+		Condition("Bool", "aws:SecureTransport", "false"),
+	),
+)
+
+var evaluateTests = []struct {
+	name     string
+	action   string
+	resource string
+	context  map[string]string
+	expected Decision
+}{
+	{"get", "s3:GetObject", "arn:aws:s3:::bucket/key", nil, Allowed},
+	{"get-case", "S3:getobject", "arn:aws:s3:::bucket/key", nil, Allowed},
+	{"list", "s3:ListBucket", "arn:aws:s3:::bucket", nil, Allowed},
+	{"other-bucket", "s3:GetObject", "arn:aws:s3:::other/key", nil, ImplicitDeny},
+	{"delete", "s3:DeleteObject", "arn:aws:s3:::bucket/key", nil, ImplicitDeny},
+	{"secret", "s3:GetObject", "arn:aws:s3:::bucket/secret/key", nil, ExplicitDeny},
+	{"put-no-vpce", "s3:PutObject", "arn:aws:s3:::bucket/key", nil, ImplicitDeny},
+	{"put-vpce", "s3:PutObject", "arn:aws:s3:::bucket/key",
+		map[string]string{"aws:SourceVpce": "vpce-1234"}, Allowed},
+	{"put-wrong-vpce", "s3:PutObject", "arn:aws:s3:::bucket/key",
+		map[string]string{"aws:sourcevpce": "vpce-9999"}, ImplicitDeny},
+	{"insecure-get", "s3:GetObject", "arn:aws:s3:::bucket/key",
+		map[string]string{"aws:SecureTransport": "false"}, ExplicitDeny},
+	{"insecure-list", "s3:ListBucket", "arn:aws:s3:::bucket",
+		map[string]string{"aws:SecureTransport": "false"}, Allowed},
+}
+
+func TestEvaluate(t *testing.T) {
+	for _, test := range evaluateTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			d, err := evaluatePolicy.Evaluate(test.action, test.resource, test.context)
+			assert.NoError(err)
+			assert.Equal(test.expected, d)
+		})
+	}
+}
+
+var conditionTests = []struct {
+	op       string
+	value    string
+	context  map[string]string
+	expected bool
+}{
+	{"StringLike", "home/*", map[string]string{"s3:prefix": "home/user"}, true},
+	{"StringNotLike", "home/*", map[string]string{"s3:prefix": "home/user"}, false},
+	{"StringNotEquals", "home/", nil, true},
+	{"StringEqualsIfExists", "home/", nil, true},
+	{"StringEqualsIgnoreCase", "HOME/", map[string]string{"s3:prefix": "home/"}, true},
+	{"NumericLessThan", "10", map[string]string{"s3:prefix": "5"}, true},
+	{"NumericGreaterThanEquals", "10", map[string]string{"s3:prefix": "5"}, false},
+	{"Null", "true", nil, true},
+	{"Null", "false", nil, false},
+	{"ForAnyValue:StringEquals", "a", map[string]string{"s3:prefix": "a"}, true},
+}
+
+func TestEvaluateConditions(t *testing.T) {
+	for _, test := range conditionTests {
+		p := New("id", Statement("stmt1",
+			Effect(Allow),
+			Action("s3:ListBucket"),
+			Condition(test.op, "s3:prefix", test.value),
+		))
+		d, err := p.Evaluate("s3:ListBucket", "arn:aws:s3:::bucket", test.context)
+		assert.NoError(t, err, test.op)
+		assert.Equal(t, test.expected, d == Allowed, "%s %s %v", test.op, test.value, test.context)
+	}
+
+	p := New("id", Statement("stmt1",
+		Effect(Allow),
+		Action("s3:ListBucket"),
+		Condition("IpAddress", "aws:SourceIp", "10.0.0.0/8"),
+	))
+	_, err := p.Evaluate("s3:ListBucket", "arn:aws:s3:::bucket", nil)
+	assert.ErrorIs(t, err, ErrUnsupportedCondition)
+}
+
+func TestEqual(t *testing.T) {
+	assert := assert.New(t)
+
+	a := New("id",
+		Statement("one", Effect(Allow), Action("s3:GetObject", "s3:PutObject"), Resource("arn")),
+		Statement("two", Effect(Deny), Action("s3:*"), Resource("secret"),
+			Condition("Bool", "aws:SecureTransport", "false")),
+	)
+	b := New("id",
+		Statement("two", Effect(Deny), Action([]string{"s3:*"}), Resource("secret"),
+			Condition("Bool", "aws:SecureTransport", "false", "false")),
+		Statement("one", Effect(Allow), Action("s3:PutObject", "s3:GetObject", "s3:PutObject"), Resource("arn")),
+	)
+	c := New("id",
+		Statement("one", Effect(Allow), Action("s3:GetObject"), Resource("arn")),
+	)
+
+	eq, err := Equal(a, b)
+	assert.NoError(err)
+	assert.True(eq)
+
+	eq, err = Equal(a, c)
+	assert.NoError(err)
+	assert.False(eq)
+
+	_, err = Equal(a, New("id", Statement("one", Effect(Allow), Action("s3:GetObject"),
+		Resource(pulumi.String("arn")))))
+	assert.ErrorIs(err, ErrUnresolved)
+}
+
+func TestEqualJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	eq, err := EqualJSON(
+		`{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}}`,
+		`{"Statement": [{"Resource": ["*"], "Action": ["s3:GetObject"], "Effect": "Allow"}], "Version": "2012-10-17"}`,
+	)
+	assert.NoError(err)
+	assert.True(eq)
+
+	eq, err = EqualJSON(
+		`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+		`{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "Action": "s3:GetObject", "Resource": "*"}]}`,
+	)
+	assert.NoError(err)
+	assert.False(eq)
+
+	_, err = EqualJSON(`{`, `{}`)
+	assert.ErrorIs(err, ErrInvalidPolicy)
+}
+
+var conditionOpTests = []struct {
+	op    string
+	valid bool
+}{
+	{"StringEquals", true},
+	{"StringEqualsIfExists", true},
+	{"ForAnyValue:StringLike", true},
+	{"ForAllValues:ArnLikeIfExists", true},
+	{"Null", true},
+	{"StringEqual", false},
+	{"ArnEqual", false},
+	{"ForSomeValues:StringEquals", false},
+	{"NullIfExists", false},
+	{"ForAnyValue:Null", false},
+}
+
+func TestCheckConditions(t *testing.T) {
+	for _, test := range conditionOpTests {
+		stmt := Statement("stmt1",
+			Effect(Allow),
+			Action("s3:ListBucket"),
+			Condition(test.op, "s3:prefix", "home/"),
+		)
+		assert.NoError(t, New("id", stmt).Validate(), test.op)
+
+		err := New("id", CheckConditions(), stmt).Validate()
+		if test.valid {
+			assert.NoError(t, err, test.op)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidStatement, test.op)
+		}
+	}
+}
+
+var conditionKeyTests = []struct {
+	name     string
+	action   string
+	key      string
+	expected error
+}{
+	{"global", "s3:GetObject", "aws:SourceVpce", nil},
+	{"global-tag", "ec2:RunInstances", "aws:ResourceTag/Team", nil},
+	{"service", "s3:ListBucket", "s3:prefix", nil},
+	{"service-wildcard-action", "*", "kms:ViaService", nil},
+	{"service-prefix", "kms:Decrypt", "kms:EncryptionContext:Department", nil},
+	{"unchecked-service", "ec2:RunInstances", "ec2:InstanceType", nil},
+	{"federated", "sts:AssumeRoleWithWebIdentity", "accounts.google.com:aud", nil},
+	{"wrong-service", "s3:GetObject", "kms:ViaService", ErrUnknownConditionKey},
+	{"typo", "s3:ListBucket", "s3:prefixes", ErrUnknownConditionKey},
+	{"global-typo", "s3:GetObject", "aws:SourceVPCE1", ErrUnknownConditionKey},
+	{"no-service-keys", "sqs:SendMessage", "sqs:QueueName", ErrUnknownConditionKey},
+}
+
+func TestValidateConditionKeys(t *testing.T) {
+	for _, test := range conditionKeyTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			stmt := Statement("stmt1",
+				Effect(Allow),
+				Action(test.action),
+				Resource("*"),
+				Condition("StringEquals", test.key, "value"),
+			)
+			assert.NoError(t, New("id", stmt).Validate())
+
+			err := New("id", ValidateConditionKeys(DefaultCatalog), stmt).Validate()
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestShadowed(t *testing.T) {
+	assert := assert.New(t)
+
+	p := New("id",
+		Statement("get-key",
+			Effect(Allow),
+			Action("s3:GetObject"),
+			Resource("arn:aws:s3:::bucket/key"),
+		),
+		Statement("all-objects",
+			Effect(Allow),
+			Action("s3:*"),
+			Resource("arn:aws:s3:::bucket/*"),
+		),
+		Statement("get-conditional",
+			Effect(Allow),
+			Action("s3:GetObject"),
+			Resource("arn:aws:s3:::bucket/other"),
+			Condition("Bool", "aws:SecureTransport", "true"),
+		),
+		Statement("deny-key",
+			Effect(Deny),
+			Action("s3:GetObject"),
+			Resource("arn:aws:s3:::bucket/key"),
+		),
+		Statement("conditional-all",
+			Effect(Allow),
+			Action("s3:*"),
+			Resource("arn:aws:s3:::other/*"),
+			Condition("Bool", "aws:SecureTransport", "true"),
+		),
+		Statement("conditional-one",
+			Effect(Allow),
+			Action("s3:GetObject"),
+			Resource("arn:aws:s3:::other/key"),
+		),
+		Statement("duplicate",
+			Effect(Deny),
+			Action("s3:GetObject"),
+			Resource("arn:aws:s3:::bucket/key"),
+		),
+	)
+
+	var sids []string
+	for _, f := range p.Shadowed() {
+		sids = append(sids, f.Sid)
+	}
+	assert.Equal([]string{"get-key", "get-conditional", "duplicate"}, sids)
+}
+
+func TestExpectPartition(t *testing.T) {
+	assert := assert.New(t)
+
+	stmt := func(resource, principal string) Opt {
+		return Statement("stmt1",
+			Effect(Allow),
+			Action("s3:GetObject"),
+			Resource(resource, pulumi.String("arn:aws:s3:::unchecked")),
+			Principal("AWS", principal),
+		)
+	}
+	assert.NoError(New("id", ExpectPartition("aws-cn"),
+		stmt("arn:aws-cn:s3:::bucket/*", "arn:aws-cn:iam::123:root")).Validate())
+	assert.ErrorIs(New("id", ExpectPartition("aws-cn"),
+		stmt("arn:aws:s3:::bucket/*", "arn:aws-cn:iam::123:root")).Validate(), ErrPartitionMismatch)
+	assert.ErrorIs(New("id", ExpectPartition("aws-cn"),
+		stmt("arn:aws-cn:s3:::bucket/*", "arn:aws:iam::123:root")).Validate(), ErrPartitionMismatch)
+	assert.NoError(New("id", SubstitutePartition("aws-cn"),
+		stmt("arn:aws:s3:::bucket/*", "arn:aws:iam::123:root")).Validate())
+	assert.ErrorIs(New("id", SubstitutePartition("aws-cn"),
+		stmt("arn:aws-us-gov:s3:::bucket/*", "*")).Validate(), ErrPartitionMismatch)
+}
+
+func TestSubstitutePartition(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New("id", SubstitutePartition("aws-us-gov"),
+			Statement("stmt1",
+				Effect(Allow),
+				Action("s3:GetObject"),
+				Resource(pulumi.String("arn:aws:s3:::bucket/*"), "arn:aws-us-gov:s3:::other/*"),
+				Principal("AWS", "arn:aws:iam::123:root"),
+			),
+		)
+
+		expected := `{
+			"Version": "2012-10-17",
+			"Id": "id",
+			"Statement": [{
+				"Sid": "stmt1",
+				"Effect": "Allow",
+				"Principal": {"AWS": "arn:aws-us-gov:iam::123:root"},
+				"Action": "s3:GetObject",
+				"Resource": ["arn:aws-us-gov:s3:::bucket/*", "arn:aws-us-gov:s3:::other/*"]
+			}]
+		}`
+		p.ToStringOutput().ApplyT(func(js string) int {
+			assert.JSONEq(expected, js)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestRules(t *testing.T) {
+	assert := assert.New(t)
+
+	noKMSWildcards := func(p Policy) []Finding {
+		var findings []Finding
+		for _, s := range p.Statement {
+			for _, a := range s.Action.flatten() {
+				if a == "kms:*" {
+					findings = append(findings, Finding{"no-kms-wildcard", s.Sid, "kms:* is not permitted"})
+				}
+			}
+		}
+		return findings
+	}
+
+	stmt := Statement("stmt1", Effect(Allow), Action("kms:*"), Resource("*"))
+	assert.NoError(New("id", stmt).Validate())
+
+	err := New("id", WithRules(noKMSWildcards), stmt).Validate()
+	assert.ErrorIs(err, ErrRuleViolation)
+	var rerr *RuleError
+	if assert.ErrorAs(err, &rerr) {
+		assert.Equal("id", rerr.PolicyID)
+		assert.Equal([]Finding{{"no-kms-wildcard", "stmt1", "kms:* is not permitted"}}, rerr.Findings)
+	}
+
+	assert.ErrorIs(New("id", WithRules(LintRule),
+		Statement("admin", Effect(Allow), Action("*"), Resource("*"))).Validate(), ErrRuleViolation)
+}
+
+func TestRegisterRules(t *testing.T) {
+	assert := assert.New(t)
+	defer func() { globalRules = nil }()
+
+	RegisterRules(func(p Policy) []Finding {
+		if p.ID == "forbidden" {
+			return []Finding{{Rule: "forbidden-id", Message: "policy id is forbidden"}}
+		}
+		return nil
+	})
+
+	stmt := Statement("stmt1", Effect(Allow), Action("s3:GetObject"), Resource("*"))
+	assert.NoError(New("id", stmt).Validate())
+	assert.ErrorIs(New("forbidden", stmt).Validate(), ErrRuleViolation)
+}
+
+var classifyTests = []struct {
+	action   string
+	expected []AccessLevel
+}{
+	{"s3:ListBucket", []AccessLevel{AccessList}},
+	{"ec2:DescribeInstances", []AccessLevel{AccessList}},
+	{"s3:GetObject", []AccessLevel{AccessRead}},
+	{"s3:GetBucketPolicy", []AccessLevel{AccessRead}},
+	{"s3:PutObject", []AccessLevel{AccessWrite}},
+	{"s3:PutBucketPolicy", []AccessLevel{AccessPermissions}},
+	{"iam:AttachRolePolicy", []AccessLevel{AccessPermissions}},
+	{"kms:CreateGrant", []AccessLevel{AccessPermissions}},
+	{"s3:TagResource", []AccessLevel{AccessTagging}},
+	{"s3:Get*", []AccessLevel{AccessRead}},
+	{"s3:*", AccessLevels},
+	{"*", AccessLevels},
+	{"s3:Put*", []AccessLevel{AccessWrite, AccessPermissions}},
+}
+
+func TestClassifyAction(t *testing.T) {
+	for _, test := range classifyTests {
+		assert.Equal(t, test.expected, classifyAction(test.action), test.action)
+	}
+}
+
+func TestReport(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New("id",
+		Statement("read", Effect(Allow), Action("s3:GetObject", "s3:ListBucket"), Resource("*")),
+		Statement("write", Effect(Allow), Action("s3:PutObject", "s3:GetObject"), Resource("*")),
+		Statement("deny", Effect(Deny), Action("s3:PutBucketPolicy"), Resource("*")),
+	).Report()
+
+	assert.Equal(map[AccessLevel][]string{
+		AccessList:  {"s3:ListBucket"},
+		AccessRead:  {"s3:GetObject"},
+		AccessWrite: {"s3:PutObject"},
+	}, r.Allowed)
+	assert.Len(r.Statements, 3)
+	assert.Equal([]string{"s3:PutBucketPolicy"}, r.Statements[2].Levels[AccessPermissions])
+	assert.Equal(`policy "id" allows:
+  List: s3:ListBucket
+  Read: s3:GetObject
+  Write: s3:PutObject
+`, r.String())
+}
+
+func TestDuplicateSid(t *testing.T) {
+	assert := assert.New(t)
+
+	stmt := func(sid string) Opt {
+		return Statement(sid, Effect(Allow), Action("s3:GetObject"), Resource("*"))
+	}
+	assert.NoError(New("id", stmt("one"), stmt("two"), stmt(""), stmt("")).Validate())
+
+	err := New("id", stmt("one"), stmt("two"), stmt("one")).Validate()
+	assert.ErrorIs(err, ErrInvalidPolicy)
+	assert.Contains(err.Error(), `duplicate Sid "one" for statements 1 and 3`)
+}
+
+func TestMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	stmts := []Opt{
+		Statement("one", Effect(Allow), Action("s3:GetObject", "s3:List*"), Resource("arn:aws:s3:::b/*")),
+		Statement("two", Effect(Allow), Action("s3:getobject"), Resource("arn:aws:s3:::b/key", "arn:aws:s3:::b/*")),
+	}
+	expected := Metrics{
+		Statements:        2,
+		DistinctActions:   2,
+		DistinctResources: 2,
+		WildcardActions:   1,
+		WildcardResources: 2,
+	}
+
+	m, err := New("id", stmts...).Metrics()
+	assert.NoError(err)
+	assert.NotZero(m.Size)
+	expected.Size = m.Size
+	assert.Equal(expected, m)
+
+	_, err = New("id", Statement("one", Effect(Allow), Action(pulumi.String("s3:GetObject")))).Metrics()
+	assert.ErrorIs(err, ErrUnresolved)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New("id", append(stmts,
+			Statement("three", Effect(Allow), Action("sqs:SendMessage"), Resource(pulumi.String("arn:queue"))))...)
+		p.MetricsOutput().ApplyT(func(m map[string]int) int {
+			assert.Equal(3, m["Statements"])
+			assert.Equal(3, m["DistinctActions"])
+			assert.Equal(3, m["DistinctResources"])
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestToCloudFormationYAML(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New("id",
+			Statement("stmt1",
+				Effect(Allow),
+				Action("s3:GetObject", "s3:PutObject"),
+				Principal("AWS", "*"),
+				Resource(pulumi.String("arn:aws:s3:::bucket/*")),
+				Condition("StringEquals", "aws:PrincipalOrgID", "o-123"),
+			),
+		)
+
+		expected := `Version: "2012-10-17"
+Id: id
+Statement:
+  - Sid: stmt1
+    Effect: Allow
+    Principal:
+      AWS: '*'
+    Action: ['s3:GetObject', 's3:PutObject']
+    Resource: arn:aws:s3:::bucket/*
+    Condition:
+      StringEquals:
+        aws:PrincipalOrgID: o-123
+`
+		p.ToCloudFormationYAML().ApplyT(func(y string) int {
+			assert.Equal(expected, y)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestToTerraformHCL(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New("id",
+			Statement("stmt1",
+				Effect(Allow),
+				Action("s3:GetObject", "s3:PutObject"),
+				Principal("AWS", "arn:aws:iam::123:root"),
+				Resource(pulumi.String("arn:aws:s3:::bucket/${aws:username}/*")),
+				Condition("StringEquals", "aws:PrincipalOrgID", "o-123"),
+			),
+			Statement("",
+				Effect(Deny),
+				NotAction("s3:*"),
+				Resource("*"),
+			),
+		)
+
+		expected := `data "aws_iam_policy_document" "example" {
+  policy_id = "id"
+  version   = "2012-10-17"
+
+  statement {
+    sid       = "stmt1"
+    effect    = "Allow"
+    actions   = ["s3:GetObject", "s3:PutObject"]
+    resources = ["arn:aws:s3:::bucket/$${aws:username}/*"]
+
+    principals {
+      type        = "AWS"
+      identifiers = ["arn:aws:iam::123:root"]
+    }
+
+    condition {
+      test     = "StringEquals"
+      variable = "aws:PrincipalOrgID"
+      values   = ["o-123"]
+    }
+  }
+
+  statement {
+    effect      = "Deny"
+    not_actions = ["s3:*"]
+    resources   = ["*"]
+  }
+}
+`
+		p.ToTerraformHCL("example").ApplyT(func(hcl string) int {
+			assert.Equal(expected, hcl)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestPolicyDocumentArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	args := &iam.GetPolicyDocumentArgs{
+		Statements: []iam.GetPolicyDocumentStatement{{
+			Sid:       stringPtr("read"),
+			Actions:   []string{"s3:GetObject"},
+			Resources: []string{"arn:aws:s3:::bucket/*"},
+			Principals: []iam.GetPolicyDocumentStatementPrincipal{
+				{Type: "AWS", Identifiers: []string{"arn:aws:iam::123:root"}},
+			},
+			Conditions: []iam.GetPolicyDocumentStatementCondition{
+				{Test: "StringEquals", Variable: "aws:PrincipalOrgID", Values: []string{"o-1"}},
+				{Test: "StringEquals", Variable: "aws:PrincipalOrgID", Values: []string{"o-2"}},
+			},
+		}},
+	}
+
+	p := FromPolicyDocumentArgs("id", args,
+		Statement("deny", Effect(Deny), NotAction("s3:GetObject"), Resource("*")),
+	)
+	assert.NoError(p.Validate())
+	out, err := json.Marshal(p)
+	assert.NoError(err)
+	assert.JSONEq(`{
+		"Version": "2012-10-17",
+		"Id": "id",
+		"Statement": [{
+			"Sid": "read",
+			"Effect": "Allow",
+			"Principal": {"AWS": "arn:aws:iam::123:root"},
+			"Action": "s3:GetObject",
+			"Resource": "arn:aws:s3:::bucket/*",
+			"Condition": {"StringEquals": {"aws:PrincipalOrgID": ["o-1", "o-2"]}}
+		}, {
+			"Sid": "deny",
+			"Effect": "Deny",
+			"NotAction": "s3:GetObject",
+			"Resource": "*"
+		}]
+	}`, string(out))
+
+	back, err := p.ToPolicyDocumentArgs()
+	assert.NoError(err)
+	assert.Equal("id", *back.PolicyId)
+	assert.Len(back.Statements, 2)
+	assert.Equal([]iam.GetPolicyDocumentStatementCondition{
+		{Test: "StringEquals", Variable: "aws:PrincipalOrgID", Values: []string{"o-1", "o-2"}},
+	}, back.Statements[0].Conditions)
+	assert.Equal("Deny", *back.Statements[1].Effect)
+	assert.Equal([]string{"s3:GetObject"}, back.Statements[1].NotActions)
+
+	_, err = New("id", Statement("s", Effect(Allow), Action(pulumi.String("s3:*")))).ToPolicyDocumentArgs()
+	assert.ErrorIs(err, ErrUnresolved)
+}
+
+func TestToMapOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p := New("id",
+			Statement("stmt1",
+				Effect(Allow),
+				Action("s3:GetObject", "s3:PutObject"),
+				Resource(pulumi.String("arn:aws:s3:::bucket/*")),
+			),
+		)
+		p.ToMapOutput().ApplyT(func(m map[string]interface{}) int {
+			assert.Equal(map[string]interface{}{
+				"Version": "2012-10-17",
+				"Id":      "id",
+				"Statement": []interface{}{
+					map[string]interface{}{
+						"Sid":      "stmt1",
+						"Effect":   "Allow",
+						"Action":   []interface{}{"s3:GetObject", "s3:PutObject"},
+						"Resource": "arn:aws:s3:::bucket/*",
+					},
+				},
+			}, m)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	doc := `{
+		"Version": "2012-10-17",
+		"Id": "id",
+		"Statement": [{
+			"Sid": "one",
+			"Effect": "Allow",
+			"Principal": "*",
+			"Action": ["s3:GetObject", "s3:PutObject"],
+			"Resource": "arn:aws:s3:::bucket/*",
+			"Condition": {"Bool": {"aws:SecureTransport": true}}
+		}, {
+			"Effect": "Deny",
+			"NotPrincipal": {"AWS": ["arn:aws:iam::123:root"]},
+			"NotAction": "s3:*",
+			"NotResource": "arn:aws:s3:::bucket"
+		}]
+	}`
+	p, err := Parse(doc)
+	assert.NoError(err)
+	assert.NoError(p.Validate())
+	assert.Equal(&Policy{
+		Version: "2012-10-17",
+		ID:      "id",
+		Statement: Stmts{{
+			Sid:          "one",
+			Effect:       Allow,
+			Principal:    map[string]Strings{"AWS": {"*"}},
+			NotPrincipal: map[string]Strings{},
+			Action:       Strings{"s3:GetObject", "s3:PutObject"},
+			Resource:     Strings{"arn:aws:s3:::bucket/*"},
+			Condition:    map[string]map[string]Strings{"Bool": {"aws:SecureTransport": {"true"}}},
+		}, {
+			Effect:       Deny,
+			Principal:    map[string]Strings{},
+			NotPrincipal: map[string]Strings{"AWS": {"arn:aws:iam::123:root"}},
+			NotAction:    Strings{"s3:*"},
+			NotResource:  Strings{"arn:aws:s3:::bucket"},
+		}},
+	}, p)
+
+	p.Extend(Statement("two", Effect(Allow), Action("sqs:SendMessage"), Resource("*")))
+	assert.Len(p.Statement, 3)
+
+	single, err := Parse(`{"Version": "2012-10-17", "Statement": {"Effect": "Allow", "Action": "*", "Resource": "*"}}`)
+	assert.NoError(err)
+	assert.Len(single.Statement, 1)
+
+	_, err = Parse(`{"Statement": [{"Principal": "someone"}]}`)
+	assert.ErrorIs(err, ErrInvalidStatement)
+	_, err = Parse(`not json`)
+	assert.ErrorIs(err, ErrInvalidPolicy)
+}
+
+type smithyDoc string
+
+func (d smithyDoc) UnmarshalSmithyDocument(v interface{}) error {
+	return json.Unmarshal([]byte(d), v)
+}
+
+func TestFromAWSSDK(t *testing.T) {
+	assert := assert.New(t)
+
+	encoded := url.QueryEscape(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`)
+	p, err := FromPolicyVersion(&iamtypes.PolicyVersion{Document: &encoded})
+	assert.NoError(err)
+	assert.Equal(Strings{"s3:*"}, p.Statement[0].Action)
+
+	_, err = FromPolicyVersion(&iamtypes.PolicyVersion{})
+	assert.ErrorIs(err, ErrInvalidPolicy)
+
+	p, err = FromSmithyDocument(smithyDoc(`{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Action":"s3:*","Resource":"*"}]}`))
+	assert.NoError(err)
+	assert.Equal(Deny, p.Statement[0].Effect)
+}
+
+type managedPolicyMocks struct {
+	mocks
+}
+
+func (managedPolicyMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	if args.Token == "aws:iam/getPolicy:getPolicy" {
+		return resource.NewPropertyMapFromMap(map[string]interface{}{
+			"arn": args.Args["arn"].StringValue(),
+			"policy": `{
+				"Version": "2012-10-17",
+				"Statement": [
+					{"Sid": "Read", "Effect": "Allow", "Action": ["s3:Get*", "s3:List*"], "Resource": "*"},
+					{"Sid": "Describe", "Effect": "Allow", "Action": "ec2:Describe*", "Resource": "*"}
+				]
+			}`,
+		}), nil
+	}
+	return args.Args, nil
+}
+
+func TestFromManagedPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		p, err := FromManagedPolicy(ctx, "extended", "arn:aws:iam::aws:policy/ReadOnlyAccess",
+			ReplaceStatement("Read", Effect(Allow), Action("s3:GetObject"), Resource("*")),
+			Statement("Extra", Effect(Allow), Action("sqs:SendMessage"), Resource("*")),
+			RemoveStatement("Describe"),
+		)
+		if err != nil {
+			return err
+		}
+		assert.NoError(p.Validate())
+		assert.Equal("extended", p.ID)
+		assert.Len(p.Statement, 2)
+		assert.Equal("Read", p.Statement[0].Sid)
+		assert.Equal(Strings{"s3:GetObject"}, p.Statement[0].Action)
+		assert.Equal("Extra", p.Statement[1].Sid)
+		return nil
+	}, pulumi.WithMocks("project", "stack", managedPolicyMocks{}))
+	assert.NoError(err)
+}
+
+func TestGrants(t *testing.T) {
+	mk := testutil.Run(t, func(ctx *pulumi.Context) error {
+		api, err := iam.NewRole(ctx, "api", &iam.RoleArgs{Name: pulumi.String("api"), AssumeRolePolicy: pulumi.String("{}")})
+		if err != nil {
+			return err
+		}
+		worker, err := iam.NewRole(ctx, "worker", &iam.RoleArgs{Name: pulumi.String("worker"), AssumeRolePolicy: pulumi.String("{}")})
+		if err != nil {
+			return err
+		}
+
+		grants := NewGrants("app")
+		grants.Grant(api, S3Read("arn:aws:s3:::assets"))
+		grants.Grant(worker, SQSConsume(pulumi.String("arn:aws:sqs:us-east-1:123456789012:jobs")))
+		grants.Grant(api, S3Read(pulumi.String("arn:aws:s3:::uploads")), DynamoDBReadWrite("arn:aws:dynamodb:us-east-1:123456789012:table/t"))
+
+		p := grants.Policy(api)
+		assert.Equal(t, "app-0", p.ID)
+		assert.Equal(t, []string{"S3Read", "S3Read2", "DynamoDBReadWrite"}, []string{p.Statement[0].Sid, p.Statement[1].Sid, p.Statement[2].Sid})
+		assert.Nil(t, grants.Policy(&iam.Role{}))
+
+		rps, err := grants.Apply(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, rps, 2)
+
+		bad := NewGrants("bad")
+		bad.Grant(api, Permit("Empty", Resource("*")))
+		_, err = bad.Apply(ctx)
+		assert.ErrorIs(t, err, ErrInvalidStatement)
+		return nil
+	})
+
+	rp, ok := mk.Resource("app-1")
+	assert.True(t, ok)
+	assert.Equal(t, "worker", rp.Inputs["role"].StringValue())
+	assert.JSONEq(t, `{
+		"Version": "2012-10-17",
+		"Id": "app-1",
+		"Statement": [{
+			"Sid": "SQSConsume",
+			"Effect": "Allow",
+			"Action": ["sqs:ReceiveMessage", "sqs:DeleteMessage", "sqs:ChangeMessageVisibility", "sqs:GetQueueAttributes", "sqs:GetQueueUrl"],
+			"Resource": "arn:aws:sqs:us-east-1:123456789012:jobs"
+		}]
+	}`, rp.Inputs["policy"].StringValue())
+	_, ok = mk.Resource("bad-0")
+	assert.False(t, ok)
+}
+
+func TestAudit(t *testing.T) {
+	path := t.TempDir() + "/audit.json"
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		New("ignored", Statement("s", Effect(Allow), Action("s3:GetObject"), Resource("*"))).ToStringOutput()
+
+		audit := EnableAudit()
+		defer DisableAudit()
+		New("bucket",
+			Statement("read",
+				Effect(Allow),
+				Action("s3:GetObject", "s3:ListBucket"),
+				Principal("AWS", pulumi.String("arn:aws:iam::123456789012:root")),
+				Resource(pulumi.String("arn:aws:s3:::b"), "arn:aws:s3:::b/*"),
+			),
+			Statement("deny",
+				Effect(Deny),
+				NotAction("s3:GetObject"),
+				Principal("AWS", "*"),
+				Resource("*"),
+			),
+		).ToMapOutput()
+
+		assert.Len(t, audit.Policies(), 1)
+		assert.Equal(t, []AuditEntry{{
+			PolicyID: "bucket",
+			Allow: AuditAccess{
+				Principals: []string{"AWS:arn:aws:iam::123456789012:root"},
+				Actions:    []string{"s3:GetObject", "s3:ListBucket"},
+				Resources:  []string{"arn:aws:s3:::b", "arn:aws:s3:::b/*"},
+			},
+			Deny: AuditAccess{
+				Principals: []string{"AWS:*"},
+				NotActions: []string{"s3:GetObject"},
+				Resources:  []string{"*"},
+			},
+		}}, testutil.AwaitOutput[[]AuditEntry](t, audit.Entries()))
+		assert.Equal(t, path, testutil.AwaitOutput[string](t, audit.WriteFile(path)))
+		return nil
+	})
+
+	js, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(js), `"policyId": "bucket"`)
+}
+
+func TestNewManagedPolicy(t *testing.T) {
+	mk := testutil.Run(t, func(ctx *pulumi.Context) error {
+		p := New("managed", Statement("read", Effect(Allow), Action("s3:GetObject"), Resource(pulumi.String("arn:aws:s3:::b/*"))))
+		_, err := NewManagedPolicy(ctx, "managed", p)
+		assert.NoError(t, err)
+
+		_, err = NewManagedPolicy(ctx, "invalid", New("invalid", Statement("empty", Effect(Allow))))
+		assert.ErrorIs(t, err, ErrInvalidStatement)
+
+		actions := make([]string, 500)
+		for i := range actions {
+			actions[i] = fmt.Sprintf("s3:Action%d", i)
+		}
+		_, err = NewManagedPolicy(ctx, "large", New("large", Statement("many", Effect(Allow), Action(actions), Resource("*"))))
+		assert.ErrorIs(t, err, ErrPolicyTooLarge)
+		return nil
+	})
+
+	res, ok := mk.Resource("managed")
+	assert.True(t, ok)
+	assert.Contains(t, res.Inputs["policy"].StringValue(), "arn:aws:s3:::b/*")
+	_, ok = mk.Resource("large")
+	assert.False(t, ok)
+}
+
+func TestRoleWithPolicies(t *testing.T) {
+	mk := testutil.Run(t, func(ctx *pulumi.Context) error {
+		trust := New("trust", Statement("lambda",
+			Effect(Allow),
+			Principal("Service", "lambda.amazonaws.com"),
+			Action("sts:AssumeRole"),
+		))
+		role, err := NewRoleWithPolicies(ctx, "api", &RoleArgs{
+			TrustPolicy:     trust,
+			InlinePolicies:  map[string]*Policy{"data": New("data", Statement("read", Effect(Allow), Action("s3:GetObject"), Resource("*")))},
+			ManagedPolicies: map[string]*Policy{"queue": New("queue", Statement("send", Effect(Allow), Action("sqs:SendMessage"), Resource("*")))},
+			PolicyARNs:      map[string]interface{}{"logs": "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, role.InlinePolicies, 1)
+		assert.Len(t, role.ManagedPolicies, 1)
+		assert.Len(t, role.Attachments, 2)
+
+		_, err = NewRoleWithPolicies(ctx, "untrusted", &RoleArgs{})
+		assert.ErrorIs(t, err, ErrInvalidPolicy)
+		_, err = NewRoleWithPolicies(ctx, "invalid", &RoleArgs{
+			TrustPolicy:    trust,
+			InlinePolicies: map[string]*Policy{"bad": New("bad", Statement("empty", Effect(Allow)))},
+		})
+		assert.ErrorIs(t, err, ErrInvalidStatement)
+		return nil
+	})
+
+	role, ok := mk.Resource("api-role")
+	assert.True(t, ok)
+	assert.Contains(t, role.Inputs["assumeRolePolicy"].StringValue(), "lambda.amazonaws.com")
+	for _, name := range []string{"api", "api-data", "api-queue", "api-logs"} {
+		_, ok := mk.Resource(name)
+		assert.True(t, ok, name)
+	}
+	logs, _ := mk.Resource("api-logs")
+	assert.Equal(t, "aws:iam/rolePolicyAttachment:RolePolicyAttachment", logs.TypeToken)
+	_, ok = mk.Resource("invalid")
+	assert.False(t, ok)
+}
+
+func TestAttach(t *testing.T) {
+	mk := testutil.Run(t, func(ctx *pulumi.Context) error {
+		p := New("attach", Statement("read", Effect(Allow), Action("s3:GetObject"), Resource("*")))
+		role, _ := iam.NewRole(ctx, "role", &iam.RoleArgs{Name: pulumi.String("role"), AssumeRolePolicy: pulumi.String("{}")})
+		user, _ := iam.NewUser(ctx, "user", &iam.UserArgs{Name: pulumi.String("user")})
+		group, _ := iam.NewGroup(ctx, "group", &iam.GroupArgs{Name: pulumi.String("group")})
+
+		att, err := p.AttachToRole(ctx, "role-inline", role)
+		assert.NoError(t, err)
+		assert.IsType(t, &iam.RolePolicy{}, att.Inline)
+		assert.Nil(t, att.Policy)
+
+		att, err = p.AttachToRole(ctx, "role-managed", role, Managed())
+		assert.NoError(t, err)
+		assert.Nil(t, att.Inline)
+		assert.NotNil(t, att.Policy)
+		assert.IsType(t, &iam.RolePolicyAttachment{}, att.Attachment)
+
+		att, err = p.AttachToUser(ctx, "user-inline", user)
+		assert.NoError(t, err)
+		assert.IsType(t, &iam.UserPolicy{}, att.Inline)
+		att, err = p.AttachToGroup(ctx, "group-managed", group, Managed())
+		assert.NoError(t, err)
+		assert.IsType(t, &iam.GroupPolicyAttachment{}, att.Attachment)
+
+		_, err = New("bad", Statement("empty", Effect(Allow))).AttachToRole(ctx, "bad", role)
+		assert.ErrorIs(t, err, ErrInvalidStatement)
+		return nil
+	})
+
+	res, ok := mk.Resource("user-inline")
+	assert.True(t, ok)
+	assert.Equal(t, "user", res.Inputs["user"].StringValue())
+	_, ok = mk.Resource("bad")
+	assert.False(t, ok)
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-kms-decrypt", func(args ...interface{}) StatementOpt {
+		return Combine(
+			Effect(Allow),
+			Action("kms:Decrypt", "kms:DescribeKey"),
+			Resource(args...),
+		)
+	})
+	assert.Contains(t, Registered(), "test-kms-decrypt")
+	assert.Panics(t, func() { Register("test-kms-decrypt", nil) })
+	assert.Panics(t, func() { Use("test-missing") })
+
+	p := New("app",
+		Statement("Decrypt", Use("test-kms-decrypt", "arn:aws:kms:us-east-1:123456789012:key/a")),
+		Statement("DecryptMore", Use("test-kms-decrypt", "arn:aws:kms:us-east-1:123456789012:key/b"), Action("kms:GenerateDataKey")),
+	)
+	assert.NoError(t, p.Validate())
+	assert.Equal(t, Strings{"arn:aws:kms:us-east-1:123456789012:key/a"}, p.Statement[0].Resource)
+	assert.Equal(t, Strings{"kms:Decrypt", "kms:DescribeKey", "kms:GenerateDataKey"}, p.Statement[1].Action)
+}
+
+func TestRawStatement(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		p := New("raw",
+			RawStatement(`{
+				"Sid": "ReadReports",
+				"Effect": "Allow",
+				"Action": ["s3:GetObject"],
+				"Resource": ["{{0}}/reports/*", "{{1}}"],
+				"Condition": {"Bool": {"aws:SecureTransport": true}}
+			}`, pulumi.String("arn:aws:s3:::bucket"), "arn:aws:s3:::100%"),
+			Statement("Vpce",
+				Effect(Deny),
+				Action("s3:*"),
+				Resource("*"),
+				ConditionJSON(`{"StringNotEquals": {"aws:SourceVpce": ["{{0}}", "vpce-static"]}}`, pulumi.String("vpce-1234")),
+			),
+		)
+		assert.NoError(t, p.Validate())
+		assert.Equal(t, Strings{"arn:aws:s3:::100%"}, p.Statement[0].Resource[1:])
+		assert.JSONEq(t, `{
+			"Version": "2012-10-17",
+			"Id": "raw",
+			"Statement": [{
+				"Sid": "ReadReports",
+				"Effect": "Allow",
+				"Action": "s3:GetObject",
+				"Resource": ["arn:aws:s3:::bucket/reports/*", "arn:aws:s3:::100%"],
+				"Condition": {"Bool": {"aws:SecureTransport": "true"}}
+			}, {
+				"Sid": "Vpce",
+				"Effect": "Deny",
+				"Action": "s3:*",
+				"Resource": "*",
+				"Condition": {"StringNotEquals": {"aws:SourceVpce": ["vpce-1234", "vpce-static"]}}
+			}]
+		}`, testutil.AwaitOutput[string](t, p.ToStringOutput()))
+		return nil
+	})
+
+	assert.Panics(t, func() { RawStatement(`{"Effect": "Allow", "Unknown": 1}`) })
+	assert.Panics(t, func() { RawStatement(`{"Effect": "Allow", "Resource": "{{1}}"}`, "one") })
+	assert.Panics(t, func() { ConditionJSON(`{"StringEquals": "bad"}`) })
+	assert.Panics(t, func() { ConditionJSON(`{"StringEquals": {"k": "{{0}}"}}`, 1) })
+}
+
+func TestResourceTagConditions(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		p := New("abac", Statement("Team",
+			Effect(Allow),
+			Action("ec2:StartInstances"),
+			Resource("*"),
+			ResourceTagEquals("Team", pulumi.String("data").ToStringOutput()),
+			ResourceTagLike("Env", "prod-*"),
+			ResourceTagExists("Owner"),
+		), CheckConditions())
+		assert.NoError(t, p.Validate())
+		assert.JSONEq(t, `{
+			"StringEquals": {
+				"aws:ResourceTag/Team": "data"
+			},
+			"StringLike": {"aws:ResourceTag/Env": "prod-*"},
+			"Null": {"aws:ResourceTag/Owner": "false"}
+		}`, conditionJSON(t, p))
+		return nil
+	})
+	assert.Panics(t, func() { ResourceTagExists("") })
+}
+
+// conditionJSON renders the Condition element of the first statement of p.
+func conditionJSON(t *testing.T, p *Policy) string {
+	doc := testutil.AwaitOutput[map[string]interface{}](t, p.ToMapOutput())
+	js, err := json.Marshal(doc["Statement"].([]interface{})[0].(map[string]interface{})["Condition"])
+	assert.NoError(t, err)
+	return string(js)
+}
+
+func TestSourceIP(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		p := New("ip", Statement("Office",
+			Effect(Deny),
+			Action("*"),
+			Resource("*"),
+			SourceIP("203.0.113.0/24", []string{"2001:db8::/32"},
+				pulumi.String("198.51.100.0/24"),
+				pulumi.ToStringArray([]string{"192.0.2.0/24"}).ToStringArrayOutput()),
+		))
+		assert.JSONEq(t, `{"IpAddress": {"aws:SourceIp": ["203.0.113.0/24", "2001:db8::/32", "198.51.100.0/24", "192.0.2.0/24"]}}`,
+			conditionJSON(t, p))
+
+		bad := New("bad", Statement("Office", Effect(Deny), Action("*"), Resource("*"),
+			SourceIP(pulumi.StringArray{pulumi.String("10.0.0.0/8"), pulumi.String("office")})))
+		assert.ErrorIs(t, testutil.AwaitErr(t, bad.ToStringOutput()), ErrInvalidStatement)
+		return nil
+	})
+	assert.Panics(t, func() { SourceIP("203.0.113.5") })
+	assert.Panics(t, func() { SourceIP([]string{"10.0.0.0/33"}) })
+	assert.Panics(t, func() { SourceIP(1) })
+}
+
+func TestFromOrganization(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		p := New("org", Statement("Org",
+			Effect(Allow),
+			Principal("AWS", "*"),
+			Action("s3:GetObject"),
+			Resource("arn:aws:s3:::bucket/*"),
+			FromOrganization(pulumi.String("o-abc123").ToStringOutput()),
+		))
+		assert.JSONEq(t, `{"StringEquals": {"aws:PrincipalOrgID": "o-abc123"}}`, conditionJSON(t, p))
+		return nil
+	})
+}
+
+func TestRestrictRegions(t *testing.T) {
+	p := New("regions", KindSCP(), CheckConditions(), Strict(),
+		RestrictRegions("us-east-1", []string{"eu-west-1"}),
+		Statement("Allow", Effect(Allow), Action("s3:GetObject"), Resource("*"), RequestedRegion("us-east-1")),
+	)
+	assert.NoError(t, p.Validate())
+
+	s := p.Statement[0]
+	assert.Equal(t, "DenyOutsideRegions", s.Sid)
+	assert.Equal(t, Deny, s.Effect)
+	assert.Equal(t, GlobalServiceActions, staticStrings(s.NotAction))
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, staticStrings(s.Condition["StringNotEquals"]["aws:RequestedRegion"]))
+	assert.Equal(t, []string{"us-east-1"}, staticStrings(p.Statement[1].Condition["StringEquals"]["aws:RequestedRegion"]))
+
+	d, err := p.Evaluate("ec2:RunInstances", "*", map[string]string{"aws:RequestedRegion": "ap-southeast-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, ExplicitDeny, d)
+	d, err = p.Evaluate("iam:CreateRole", "*", map[string]string{"aws:RequestedRegion": "ap-southeast-2"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, ExplicitDeny, d)
+}
+
+func TestValidBetween(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.FixedZone("NZDT", 13*60*60))
+	end := start.Add(8 * time.Hour)
+	p := New("break-glass", CheckConditions(),
+		Statement("Admin", Effect(Allow), Action("*"), Resource("*"), ValidBetween(start, end)),
+		Statement("Read", Effect(Allow), Action("s3:GetObject"), Resource("*"), ValidUntil(end)),
+	)
+	assert.NoError(t, p.Validate())
+	assert.Equal(t, map[string]map[string]Strings{
+		"DateGreaterThan": {"aws:CurrentTime": {"2024-01-30T20:00:00Z"}},
+		"DateLessThan":    {"aws:CurrentTime": {"2024-01-31T04:00:00Z"}},
+	}, p.Statement[0].Condition)
+	assert.Equal(t, map[string]map[string]Strings{
+		"DateLessThan": {"aws:CurrentTime": {"2024-01-31T04:00:00Z"}},
+	}, p.Statement[1].Condition)
+	assert.Panics(t, func() { ValidBetween(end, start) })
+}
+
+func TestFromVPC(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		p := New("private", Statement("Private",
+			Effect(Deny),
+			Principal("AWS", "*"),
+			Action("s3:*"),
+			Resource("*"),
+			FromVPCEndpoint(pulumi.ID("vpce-1234").ToIDOutput()),
+			FromVPC("vpc-5678"),
+		))
+		assert.JSONEq(t, `{"StringEquals": {"aws:SourceVpce": "vpce-1234", "aws:SourceVpc": "vpc-5678"}}`, conditionJSON(t, p))
+		return nil
+	})
+	assert.Panics(t, func() { FromVPC(1) })
+}
+
+func TestEncryptionPresets(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		bucket := pulumi.String("arn:aws:s3:::bucket").ToStringOutput()
+		p := New("bucket", CheckConditions(),
+			DenyUnencryptedUploads(bucket),
+			DenyInsecureTransport("s3", bucket, pulumi.Sprintf("%s/*", bucket)),
+		)
+		assert.NoError(t, p.Validate())
+		assert.Equal(t, []string{"DenyIncorrectEncryptionHeader", "DenyUnencryptedObjectUploads", "DenyInsecureTransport"},
+			[]string{p.Statement[0].Sid, p.Statement[1].Sid, p.Statement[2].Sid})
+		assert.Contains(t, testutil.AwaitOutput[string](t, p.ToStringOutput()), `"arn:aws:s3:::bucket/*"`)
+
+		static := New("static", DenyUnencryptedUploads("arn:aws:s3:::bucket"))
+		d, err := static.Evaluate("s3:PutObject", "arn:aws:s3:::bucket/key", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, ExplicitDeny, d)
+		d, err = static.Evaluate("s3:PutObject", "arn:aws:s3:::bucket/key", map[string]string{"s3:x-amz-server-side-encryption": "AES256"})
+		assert.NoError(t, err)
+		assert.Equal(t, ImplicitDeny, d)
+
+		kms := New("kms", CheckConditions(), RequireKMSKey("arn:aws:s3:::bucket", "arn:aws:kms:us-east-1:123456789012:key/k"))
+		assert.NoError(t, kms.Validate())
+		for _, tc := range []struct {
+			ctx      map[string]string
+			expected Decision
+		}{
+			{map[string]string{"s3:x-amz-server-side-encryption": "AES256"}, ExplicitDeny},
+			{map[string]string{"s3:x-amz-server-side-encryption": "aws:kms", "s3:x-amz-server-side-encryption-aws-kms-key-id": "other"}, ExplicitDeny},
+			{map[string]string{"s3:x-amz-server-side-encryption": "aws:kms", "s3:x-amz-server-side-encryption-aws-kms-key-id": "arn:aws:kms:us-east-1:123456789012:key/k"}, ImplicitDeny},
+		} {
+			d, err := kms.Evaluate("s3:PutObject", "arn:aws:s3:::bucket/key", tc.ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, d, tc.ctx)
+		}
+
+		queue := New("queue", DenyInsecureTransport("sqs", "arn:aws:sqs:us-east-1:123456789012:q"))
+		d, err = queue.Evaluate("sqs:SendMessage", "arn:aws:sqs:us-east-1:123456789012:q", map[string]string{"aws:SecureTransport": "false"})
+		assert.NoError(t, err)
+		assert.Equal(t, ExplicitDeny, d)
+		return nil
+	})
+}
+
+func TestKindSession(t *testing.T) {
+	p := New("session", KindSession(),
+		Statement("Read", Effect(Allow), Action("s3:GetObject"), Resource("arn:aws:s3:::bucket/*")))
+	assert.NoError(t, p.Validate())
+
+	withPrincipal := New("session", KindSession(),
+		Statement("Read", Effect(Allow), Action("s3:GetObject"), Resource("*"), Principal("AWS", "*")))
+	assert.ErrorIs(t, withPrincipal.Validate(), ErrInvalidStatement)
+
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		doc := testutil.AwaitOutput[string](t, p.ToStringOutput())
+		size, err := EstimateSessionSize(doc)
+		assert.NoError(t, err)
+		assert.Equal(t, len(doc), size.Plaintext)
+		assert.Less(t, size.Compact, size.Plaintext)
+		assert.Greater(t, size.PackedEstimate, 0)
+
+		// fits when compacted, but not with indentation
+		resources := make([]string, 55)
+		for i := range resources {
+			resources[i] = fmt.Sprintf("arn:aws:s3:::bucket-%02d/*", i)
+		}
+		large := New("large", KindSession(),
+			Statement("Read", Effect(Allow), Action("s3:GetObject"), Resource(resources)))
+		compact, err := json.Marshal(large)
+		assert.NoError(t, err)
+		assert.Less(t, len(compact), 2048)
+		assert.ErrorIs(t, testutil.AwaitErr(t, large.ToStringOutput()), ErrPolicyTooLarge)
+		return nil
+	})
+
+	_, err := EstimateSessionSize("not json")
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+}
+
+func TestStatementTemplate(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		read := NewStatementTemplate("Read{{Name}}",
+			Effect(Allow),
+			Action("s3:GetObject", "s3:ListBucket"),
+			Resource("{{Bucket}}", "{{Bucket}}/*"),
+			Condition("StringEquals", "aws:PrincipalOrgID", "{{Org}}"),
+		)
+		p := New("readers",
+			read.With(map[string]interface{}{"Name": "Assets", "Bucket": pulumi.String("arn:aws:s3:::assets"), "Org": "o-1"}),
+			read.With(map[string]interface{}{"Name": "Logs", "Bucket": "arn:aws:s3:::logs", "Org": "o-1"}),
+		)
+		assert.NoError(t, p.Validate())
+		assert.Equal(t, "ReadAssets", p.Statement[0].Sid)
+		assert.Equal(t, Strings{"arn:aws:s3:::logs", "arn:aws:s3:::logs/*"}, p.Statement[1].Resource)
+		assert.JSONEq(t, `{
+			"Version": "2012-10-17",
+			"Id": "readers",
+			"Statement": [{
+				"Sid": "ReadAssets",
+				"Effect": "Allow",
+				"Action": ["s3:GetObject", "s3:ListBucket"],
+				"Resource": ["arn:aws:s3:::assets", "arn:aws:s3:::assets/*"],
+				"Condition": {"StringEquals": {"aws:PrincipalOrgID": "o-1"}}
+			}, {
+				"Sid": "ReadLogs",
+				"Effect": "Allow",
+				"Action": ["s3:GetObject", "s3:ListBucket"],
+				"Resource": ["arn:aws:s3:::logs", "arn:aws:s3:::logs/*"],
+				"Condition": {"StringEquals": {"aws:PrincipalOrgID": "o-1"}}
+			}]
+		}`, testutil.AwaitOutput[string](t, p.ToStringOutput()))
+
+		assert.Panics(t, func() { read.With(map[string]interface{}{"Name": "x"}) })
+		assert.Panics(t, func() {
+			read.With(map[string]interface{}{"Name": pulumi.String("x"), "Bucket": "b", "Org": "o"})
+		})
+		return nil
+	})
+}
+
+func TestSourceLocation(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	p := New("located",
+		Statement("ok", Effect(Allow), Action("s3:GetObject"), Resource("*")),
+		Statement("bad", Effect(Allow), Resource("*")),
+	)
+	err := p.Validate()
+	assert.ErrorIs(t, err, ErrInvalidStatement)
+	assert.Contains(t, err.Error(), fmt.Sprintf("(statement defined at %s:%d)", file, line+3))
+
+	// helpers building statements report the location they were called from
+	_, _, line, _ = runtime.Caller(0)
+	dup := New("dup", DenyInsecureTransport("s3", "*"), DenyInsecureTransport("s3", "*"))
+	err = dup.Validate()
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+	assert.Contains(t, err.Error(), fmt.Sprintf("%s:%d", file, line+1))
+	assert.NotContains(t, err.Error(), "encryption.go")
+
+	assert.PanicsWithError(t, p.Validate().Error(), func() { p.ToStringOutput() })
+
+	// as do the catalog, partition, kind and rule checks
+	_, _, line, _ = runtime.Caller(0)
+	typo := Statement("typo", Effect(Allow), Action("sqs:SendMesage"), Resource("*"))
+	defined := fmt.Sprintf("(statement defined at %s:%d)", file, line+1)
+
+	err = New("catalog", ValidateActions(DefaultCatalog), typo).Validate()
+	assert.ErrorIs(t, err, ErrUnknownAction)
+	assert.Contains(t, err.Error(), defined)
+
+	err = New("partition", ExpectPartition("aws-cn"),
+		Statement("typo", Effect(Allow), Action("s3:GetObject"), Resource("arn:aws:s3:::b")),
+	).Validate()
+	assert.ErrorIs(t, err, ErrPartitionMismatch)
+	assert.Contains(t, err.Error(), fmt.Sprintf("(statement defined at %s:", file))
+
+	err = New("scp", KindSCP(), Statement("typo", Effect(Allow), Principal("AWS", "*"), Action("s3:*"))).Validate()
+	assert.ErrorIs(t, err, ErrInvalidStatement)
+	assert.Contains(t, err.Error(), fmt.Sprintf("(statement defined at %s:", file))
+
+	err = New("rules", WithRules(LintRule),
+		Statement("typo", Effect(Allow), Action("*"), Resource("*")),
+	).Validate()
+	assert.ErrorIs(t, err, ErrRuleViolation)
+	assert.Contains(t, err.Error(), fmt.Sprintf("(statement defined at %s:", file))
+
+	parsed, err := Parse(`{"Statement": [{"Effect": "Allow"}]}`)
+	assert.NoError(t, err)
+	assert.NotContains(t, parsed.Validate().Error(), "defined at")
+}
+
+func TestRenderOptions(t *testing.T) {
+	stmt := Statement("Read", Effect(Allow), Action("s3:GetObject"), Resource("arn:aws:s3:::bucket/*"))
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		for _, tc := range []struct {
+			opts     RenderOptions
+			expected string
+		}{
+			{
+				RenderOptions{},
+				`{"Version":"2012-10-17","Id":"fmt","Statement":[{"Sid":"Read","Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`,
+			}, {
+				RenderOptions{SortKeys: true, TrailingNewline: true},
+				`{"Id":"fmt","Statement":[{"Action":"s3:GetObject","Effect":"Allow","Resource":"arn:aws:s3:::bucket/*","Sid":"Read"}],"Version":"2012-10-17"}` + "\n",
+			}, {
+				RenderOptions{Indent: "  ", SortKeys: true},
+				"{\n  \"Id\": \"fmt\",\n  \"Statement\": [\n    {\n      \"Action\": \"s3:GetObject\",\n      \"Effect\": \"Allow\",\n" +
+					"      \"Resource\": \"arn:aws:s3:::bucket/*\",\n      \"Sid\": \"Read\"\n    }\n  ],\n  \"Version\": \"2012-10-17\"\n}",
+			},
+		} {
+			p := New("fmt", stmt, WithRenderOptions(tc.opts))
+			assert.Equal(t, tc.expected, testutil.AwaitOutput[string](t, p.ToStringOutput()), tc.opts)
+		}
+
+		// the default remains four space indentation
+		assert.Contains(t, testutil.AwaitOutput[string](t, New("fmt", stmt).ToStringOutput()), "\n    \"Version\"")
+
+		// compact rendering counts towards quotas that include whitespace
+		resources := make([]string, 55)
+		for i := range resources {
+			resources[i] = fmt.Sprintf("arn:aws:s3:::bucket-%02d/*", i)
+		}
+		session := New("session", KindSession(), WithRenderOptions(RenderOptions{}),
+			Statement("Read", Effect(Allow), Action("s3:GetObject"), Resource(resources)))
+		assert.NoError(t, testutil.AwaitErr(t, session.ToStringOutput()))
+		return nil
+	})
+}
@@ -0,0 +1,175 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// placeholderRegexp matches the {{n}} placeholders accepted by RawStatement
+// and ConditionJSON, and the {{Name}} placeholders of a StatementTemplate.
+var placeholderRegexp = regexp.MustCompile(`\{\{(\w+)\}\}`)
+
+// RawStatement adds a statement given as a JSON fragment, for statements
+// that are easier to copy verbatim than to rebuild with the typed options.
+//
+// Values within the fragment may contain {{n}} placeholders that are
+// replaced by the n'th (zero based) entry of values, each of which may be
+// a string or a StringInput:
+//
+//	policy.RawStatement(`{
+//		"Sid": "ReadReports",
+//		"Effect": "Allow",
+//		"Action": "s3:GetObject",
+//		"Resource": "{{0}}/reports/*"
+//	}`, bucket.Arn)
+//
+// Will panic if doc isn't a valid statement, contains elements other than
+// those of an IAM policy statement, or refers to a missing value.
+func RawStatement(doc string, values ...interface{}) Opt {
+	var rs rawStmt
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rs); err != nil {
+		panic(fmt.Sprintf("invalid raw statement: %v", err))
+	}
+	s, err := rs.toStmt()
+	if err != nil {
+		panic(fmt.Sprintf("invalid raw statement %q: %v", rs.Sid, err))
+	}
+	s.substitute(indexedValues(values))
+	s.source = callerLocation()
+	return func(p *Policy) {
+		p.Statement = append(p.Statement, s)
+	}
+}
+
+// ConditionJSON adds the conditions in a JSON fragment, mapping condition
+// operators to keys and values, to a Statement.  Values may contain {{n}}
+// placeholders, as with RawStatement.
+//
+//	policy.ConditionJSON(`{"StringEquals": {"aws:SourceVpce": "{{0}}"}}`, endpoint.ID())
+//
+// As with Condition, each key replaces any existing values for the same
+// operator and key.
+//
+// Will panic if raw isn't a valid condition block or refers to a missing
+// value.
+func ConditionJSON(raw string, values ...interface{}) StatementOpt {
+	var block map[string]map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &block); err != nil {
+		panic(fmt.Sprintf("invalid raw condition: %v", err))
+	}
+	var opts []StatementOpt
+	for op, kv := range block {
+		for key, v := range kv {
+			if bytes.Equal(bytes.TrimSpace(v), []byte("null")) {
+				panic(fmt.Sprintf("invalid raw condition: %s %s has no value", op, key))
+			}
+			parsed, err := parseStrings(v)
+			if err != nil {
+				panic(fmt.Sprintf("invalid raw condition: %s %s: %v", op, key, err))
+			}
+			opts = append(opts, Condition(op, key, substituteStrings(parsed, indexedValues(values))...))
+		}
+	}
+	return Combine(opts...)
+}
+
+// placeholderValue returns the value of the named placeholder, which may
+// be a string or a StringInput.
+type placeholderValue func(name string) interface{}
+
+// indexedValues looks up {{n}} placeholders in values.
+//
+// Will panic if a placeholder refers to a missing value.
+func indexedValues(values []interface{}) placeholderValue {
+	return func(name string) interface{} {
+		i, err := strconv.Atoi(name)
+		if err != nil || i >= len(values) {
+			panic(fmt.Sprintf("placeholder {{%s}} has no value; %d supplied", name, len(values)))
+		}
+		return values[i]
+	}
+}
+
+// substitute replaces the placeholders in each of the statement's values.
+func (s *Stmt) substitute(lookup placeholderValue) {
+	s.Action = substituteStrings(s.Action, lookup)
+	s.NotAction = substituteStrings(s.NotAction, lookup)
+	s.Resource = substituteStrings(s.Resource, lookup)
+	s.NotResource = substituteStrings(s.NotResource, lookup)
+	for pt, v := range s.Principal {
+		s.Principal[pt] = substituteStrings(v, lookup)
+	}
+	for pt, v := range s.NotPrincipal {
+		s.NotPrincipal[pt] = substituteStrings(v, lookup)
+	}
+	for _, kv := range s.Condition {
+		for k, v := range kv {
+			kv[k] = substituteStrings(v, lookup)
+		}
+	}
+}
+
+// substituteStrings replaces the placeholders in each of the static
+// entries of s.
+func substituteStrings(s Strings, lookup placeholderValue) Strings {
+	if s == nil {
+		return nil
+	}
+	out := make(Strings, 0, len(s))
+	for _, el := range s {
+		switch v := el.(type) {
+		case string:
+			out = append(out, substitute(v, lookup))
+		case []string:
+			for _, str := range v {
+				out = append(out, substitute(str, lookup))
+			}
+		default:
+			out = append(out, el)
+		}
+	}
+	return out
+}
+
+// substitute replaces the placeholders in s, returning a string if every
+// value referenced is a string, or a StringOutput otherwise.
+func substitute(s string, lookup placeholderValue) interface{} {
+	matches := placeholderRegexp.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var (
+		format strings.Builder
+		args   []interface{}
+		static = true
+		last   int
+	)
+	for _, m := range matches {
+		format.WriteString(strings.ReplaceAll(s[last:m[0]], "%", "%%"))
+		format.WriteString("%s")
+		last = m[1]
+
+		v := lookup(s[m[2]:m[3]])
+		switch v.(type) {
+		case string:
+		case pulumi.StringInput:
+			static = false
+		default:
+			panic(fmt.Sprintf("unexpected type passed as a placeholder value: %T: %#v", v, v))
+		}
+		args = append(args, v)
+	}
+	format.WriteString(strings.ReplaceAll(s[last:], "%", "%%"))
+	if static {
+		return fmt.Sprintf(format.String(), args...)
+	}
+	return pulumi.Sprintf(format.String(), args...)
+}
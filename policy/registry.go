@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// StatementFunc builds the options of a registered statement from the
+// arguments supplied to Use.
+type StatementFunc func(args ...interface{}) StatementOpt
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]StatementFunc)
+)
+
+// Register defines a named, parameterized statement that may be used in
+// any policy with Use, so that common grants are defined once and applied
+// consistently.  It is intended to be called during program
+// initialization, and panics if name is already registered.
+//
+//	policy.Register("kms-decrypt", func(args ...interface{}) policy.StatementOpt {
+//		return policy.Combine(
+//			policy.Effect(policy.Allow),
+//			policy.Action("kms:Decrypt", "kms:DescribeKey"),
+//			policy.Resource(args...),
+//		)
+//	})
+//
+//	policy.New("app", policy.Statement("DecryptConfig", policy.Use("kms-decrypt", key.Arn)))
+func Register(name string, f StatementFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("statement %q is already registered", name))
+	}
+	registry[name] = f
+}
+
+// Registered returns the names of the registered statements, in sorted
+// order.
+func Registered() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Use applies the options of the statement registered as name, built with
+// args, to a Statement.  Options supplied alongside Use may add to, or
+// override, those of the registered statement.
+//
+// Will panic if no statement has been registered as name.
+func Use(name string, args ...interface{}) StatementOpt {
+	registryMu.Lock()
+	f, ok := registry[name]
+	registryMu.Unlock()
+	if !ok {
+		panic(fmt.Sprintf("no statement registered as %q", name))
+	}
+	return f(args...)
+}
+
+// Combine returns a StatementOpt that applies each of opts in turn.
+func Combine(opts ...StatementOpt) StatementOpt {
+	return func(s *Stmt) {
+		for _, opt := range opts {
+			opt(s)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AccessLevel is the AWS access level classification of an action.
+type AccessLevel string
+
+// Access levels used by Report, as defined in the AWS service authorization
+// reference.
+const (
+	AccessList        AccessLevel = "List"
+	AccessRead        AccessLevel = "Read"
+	AccessWrite       AccessLevel = "Write"
+	AccessPermissions AccessLevel = "Permissions management"
+	AccessTagging     AccessLevel = "Tagging"
+)
+
+// AccessLevels lists the access levels in order of increasing privilege.
+var AccessLevels = []AccessLevel{AccessList, AccessRead, AccessTagging, AccessWrite, AccessPermissions}
+
+// StatementReport describes the access levels granted or denied by a single
+// statement.
+type StatementReport struct {
+	Sid    string
+	Effect EffectType
+	// Levels maps each access level to the statement's actions in that
+	// level.  Wildcards that span every level, such as "s3:*", are listed
+	// under every level.
+	Levels map[AccessLevel][]string
+	// NotAction is true if the statement uses NotAction, in which case
+	// Levels describes the actions that are excluded.
+	NotAction bool
+}
+
+// Report summarizes the access granted by a policy.
+type Report struct {
+	PolicyID   string
+	Statements []StatementReport
+	// Allowed maps each access level to the sorted actions allowed at that
+	// level by any Allow statement using Action.
+	Allowed map[AccessLevel][]string
+}
+
+// Report classifies the actions of each statement into AWS access levels
+// to give security reviewers a quick picture of what the policy grants.
+//
+// Classification is based on the naming conventions AWS uses for actions
+// (eg. List*, Describe*, Get*, Put*Policy, Tag*), so is approximate for
+// actions that don't follow them.  Actions supplied as outputs are not
+// included.
+func (p Policy) Report() Report {
+	r := Report{
+		PolicyID: p.ID,
+		Allowed:  make(map[AccessLevel][]string),
+	}
+	seen := make(map[AccessLevel]map[string]bool)
+	for _, s := range p.Statement {
+		actions := s.Action
+		if len(s.NotAction) > 0 {
+			actions = s.NotAction
+		}
+		sr := StatementReport{
+			Sid:       s.Sid,
+			Effect:    s.Effect,
+			Levels:    make(map[AccessLevel][]string),
+			NotAction: len(s.NotAction) > 0,
+		}
+		for _, a := range staticStrings(actions) {
+			for _, level := range classifyAction(a) {
+				sr.Levels[level] = append(sr.Levels[level], a)
+				if s.Effect == Allow && !sr.NotAction {
+					if seen[level] == nil {
+						seen[level] = make(map[string]bool)
+					}
+					if !seen[level][a] {
+						seen[level][a] = true
+						r.Allowed[level] = append(r.Allowed[level], a)
+					}
+				}
+			}
+		}
+		r.Statements = append(r.Statements, sr)
+	}
+	for _, actions := range r.Allowed {
+		sort.Strings(actions)
+	}
+	return r
+}
+
+// String renders a short human readable summary of the allowed access.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "policy %q allows:\n", r.PolicyID)
+	for _, level := range AccessLevels {
+		if actions := r.Allowed[level]; len(actions) > 0 {
+			fmt.Fprintf(&b, "  %s: %s\n", level, strings.Join(actions, ", "))
+		}
+	}
+	for _, s := range r.Statements {
+		if s.Effect == Allow && s.NotAction {
+			fmt.Fprintf(&b, "  statement %q allows every action except those listed\n", s.Sid)
+		}
+	}
+	return b.String()
+}
+
+// accessPrefixes maps action name prefixes to their usual access level.
+// No prefix is a prefix of another, so the first match is the only one.
+var accessPrefixes = []struct {
+	prefix string
+	level  AccessLevel
+}{
+	{"list", AccessList},
+	{"describe", AccessList},
+	{"get", AccessRead},
+	{"head", AccessRead},
+	{"batchget", AccessRead},
+	{"query", AccessRead},
+	{"scan", AccessRead},
+	{"receive", AccessRead},
+	{"tag", AccessTagging},
+	{"untag", AccessTagging},
+}
+
+// classifyAction returns the access levels that action, which may contain
+// wildcards, could fall in to.
+func classifyAction(action string) []AccessLevel {
+	name := strings.ToLower(action)
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "*" || strings.HasPrefix(name, "*") || name == "" {
+		return AccessLevels
+	}
+
+	if isPermissionsAction(name) {
+		return []AccessLevel{AccessPermissions}
+	}
+	for _, ap := range accessPrefixes {
+		if strings.HasPrefix(name, ap.prefix) {
+			return []AccessLevel{ap.level}
+		}
+	}
+	if strings.ContainsAny(name, "*?") {
+		// a partial wildcard such as "s3:Put*" could match anything
+		// starting with that prefix
+		var levels []AccessLevel
+		stem := name[:strings.IndexAny(name, "*?")]
+		for _, ap := range accessPrefixes {
+			if strings.HasPrefix(ap.prefix, stem) {
+				levels = appendLevel(levels, ap.level)
+			}
+		}
+		levels = appendLevel(levels, AccessWrite)
+		return appendLevel(levels, AccessPermissions)
+	}
+	return []AccessLevel{AccessWrite}
+}
+
+func isPermissionsAction(name string) bool {
+	if strings.HasPrefix(name, "get") || strings.HasPrefix(name, "list") || strings.HasPrefix(name, "describe") {
+		return false
+	}
+	for _, s := range []string{"policy", "permission", "acl", "grant", "publicaccessblock"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendLevel(levels []AccessLevel, level AccessLevel) []AccessLevel {
+	for _, l := range levels {
+		if l == level {
+			return levels
+		}
+	}
+	return append(levels, level)
+}
@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/iam"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// RoleArgs holds the arguments used to create a RoleWithPolicies.
+type RoleArgs struct {
+	// TrustPolicy defines the principals allowed to assume the role.
+	TrustPolicy *Policy
+	// InlinePolicies are created as inline iam.RolePolicy resources,
+	// keyed by a short name used to derive each resource's name.
+	InlinePolicies map[string]*Policy
+	// ManagedPolicies are created as customer managed policies with
+	// NewManagedPolicy and attached to the role, keyed by a short name
+	// used to derive the names of the resources.
+	ManagedPolicies map[string]*Policy
+	// PolicyARNs are the ARNs of existing managed policies, such as AWS
+	// managed policies, to attach to the role, keyed by a short name used
+	// to derive the names of the attachments.  Each may be a string or a
+	// StringInput.
+	PolicyARNs map[string]interface{}
+
+	Description         pulumi.StringPtrInput
+	Path                pulumi.StringPtrInput
+	PermissionsBoundary pulumi.StringPtrInput
+	MaxSessionDuration  pulumi.IntPtrInput
+	Tags                pulumi.StringMapInput
+}
+
+// RoleWithPolicies is a component resource holding an IAM role along with
+// the policies granted to it and their attachments.
+//
+//	role, err := policy.NewRoleWithPolicies(ctx, "api", &policy.RoleArgs{
+//		TrustPolicy: policy.New("trust",
+//			policy.Statement("lambda",
+//				policy.Effect(policy.Allow),
+//				policy.Principal("Service", "lambda.amazonaws.com"),
+//				policy.Action("sts:AssumeRole"),
+//			),
+//		),
+//		InlinePolicies: map[string]*policy.Policy{"data": dataPolicy},
+//		PolicyARNs: map[string]interface{}{
+//			"logs": "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+//		},
+//	})
+//
+// Resources are named after the component, eg. "api-role", "api-data" and
+// "api-logs".
+type RoleWithPolicies struct {
+	pulumi.ResourceState
+
+	Role            *iam.Role
+	InlinePolicies  map[string]*iam.RolePolicy
+	ManagedPolicies map[string]*iam.Policy
+	Attachments     map[string]*iam.RolePolicyAttachment
+
+	Arn  pulumi.StringOutput `pulumi:"arn"`
+	Name pulumi.StringOutput `pulumi:"name"`
+}
+
+// NewRoleWithPolicies validates the supplied policies, then creates the
+// role, its policies and attachments.
+func NewRoleWithPolicies(ctx *pulumi.Context, name string, args *RoleArgs, opts ...pulumi.ResourceOption) (*RoleWithPolicies, error) {
+	if args == nil || args.TrustPolicy == nil {
+		return nil, fmt.Errorf("%w: role %q has no trust policy", ErrInvalidPolicy, name)
+	}
+	if err := args.TrustPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("role %q: %w", name, err)
+	}
+	for _, key := range sortedPolicyKeys(args.InlinePolicies) {
+		if err := args.InlinePolicies[key].Validate(); err != nil {
+			return nil, fmt.Errorf("role %q: %w", name, err)
+		}
+	}
+	for _, key := range sortedPolicyKeys(args.ManagedPolicies) {
+		if err := args.ManagedPolicies[key].Validate(); err != nil {
+			return nil, fmt.Errorf("role %q: %w", name, err)
+		}
+	}
+	for key := range args.PolicyARNs {
+		if _, ok := args.ManagedPolicies[key]; ok {
+			return nil, fmt.Errorf("%w: role %q has a managed policy and policy ARN both named %q",
+				ErrInvalidPolicy, name, key)
+		}
+	}
+
+	r := &RoleWithPolicies{
+		InlinePolicies:  make(map[string]*iam.RolePolicy),
+		ManagedPolicies: make(map[string]*iam.Policy),
+		Attachments:     make(map[string]*iam.RolePolicyAttachment),
+	}
+	if err := ctx.RegisterComponentResource("pulutil:policy:RoleWithPolicies", name, r, opts...); err != nil {
+		return nil, err
+	}
+	parent := pulumi.Parent(r)
+
+	role, err := iam.NewRole(ctx, name+"-role", &iam.RoleArgs{
+		AssumeRolePolicy:    args.TrustPolicy.ToStringOutput(),
+		Description:         args.Description,
+		Path:                args.Path,
+		PermissionsBoundary: args.PermissionsBoundary,
+		MaxSessionDuration:  args.MaxSessionDuration,
+		Tags:                args.Tags,
+	}, parent)
+	if err != nil {
+		return nil, err
+	}
+	r.Role = role
+	r.Arn = role.Arn
+	r.Name = role.Name
+
+	for _, key := range sortedPolicyKeys(args.InlinePolicies) {
+		att, err := args.InlinePolicies[key].AttachToRole(ctx, name+"-"+key, role, ResourceOptions(parent))
+		if err != nil {
+			return nil, err
+		}
+		r.InlinePolicies[key] = att.Inline.(*iam.RolePolicy)
+	}
+	for _, key := range sortedPolicyKeys(args.ManagedPolicies) {
+		att, err := args.ManagedPolicies[key].AttachToRole(ctx, name+"-"+key, role, Managed(), ResourceOptions(parent))
+		if err != nil {
+			return nil, err
+		}
+		r.ManagedPolicies[key] = att.Policy
+		r.Attachments[key] = att.Attachment.(*iam.RolePolicyAttachment)
+	}
+
+	arns := make(map[string]pulumi.StringInput, len(args.PolicyARNs))
+	for key, arn := range args.PolicyARNs {
+		switch v := arn.(type) {
+		case string:
+			arns[key] = pulumi.String(v)
+		case pulumi.StringInput:
+			arns[key] = v
+		default:
+			panic(fmt.Sprintf("unexpected type passed as a policy ARN: %T: %#v", arn, arn))
+		}
+	}
+	keys := make([]string, 0, len(arns))
+	for key := range arns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		att, err := iam.NewRolePolicyAttachment(ctx, name+"-"+key, &iam.RolePolicyAttachmentArgs{
+			Role:      role.Name,
+			PolicyArn: arns[key],
+		}, parent)
+		if err != nil {
+			return nil, err
+		}
+		r.Attachments[key] = att
+	}
+
+	if err := ctx.RegisterResourceOutputs(r, pulumi.Map{
+		"arn":  r.Arn,
+		"name": r.Name,
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func sortedPolicyKeys(m map[string]*Policy) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
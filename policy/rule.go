@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrRuleViolation is wrapped by the RuleError returned from Validate when
+// one or more rules report findings.
+var ErrRuleViolation = errors.New("policy rule violation")
+
+// Rule implements a custom guardrail that is run by Validate.  It should
+// return a Finding for each problem it identifies, or nothing if the
+// policy is acceptable.
+//
+// Rules are run after the built-in checks have passed, so may assume the
+// policy is structurally valid.  Values supplied as outputs are unresolved
+// at that time.
+type Rule func(p Policy) []Finding
+
+// Built in analyses that may be used as rules.
+var (
+	// LintRule rejects policies that Lint reports findings for.
+	LintRule Rule = Policy.Lint
+
+	// ShadowedRule rejects policies with statements reported by Shadowed.
+	ShadowedRule Rule = Policy.Shadowed
+)
+
+// RuleError is returned by Validate when rules report findings.
+type RuleError struct {
+	PolicyID string
+	Findings []Finding
+
+	// sources maps the Sids of the policy's statements to the locations
+	// they were defined at, where known.
+	sources map[string]string
+}
+
+func (e *RuleError) Error() string {
+	msgs := make([]string, len(e.Findings))
+	for i, f := range e.Findings {
+		msgs[i] = f.String()
+		if src := e.sources[f.Sid]; src != "" {
+			msgs[i] += fmt.Sprintf(" (statement defined at %s)", src)
+		}
+	}
+	return fmt.Sprintf("%v: policy %q: %s", ErrRuleViolation, e.PolicyID, strings.Join(msgs, "; "))
+}
+
+// Unwrap allows RuleError to be matched against ErrRuleViolation with
+// errors.Is.
+func (e *RuleError) Unwrap() error {
+	return ErrRuleViolation
+}
+
+// WithRules adds rules that Validate will run against this policy.
+func WithRules(rules ...Rule) Opt {
+	return func(p *Policy) {
+		p.rules = append(p.rules, rules...)
+	}
+}
+
+var (
+	globalRulesMu sync.Mutex
+	globalRules   []Rule
+)
+
+// RegisterRules adds rules that Validate will run against every policy
+// built with the package, in addition to any supplied with WithRules.  It
+// is intended to be called during program initialization.
+func RegisterRules(rules ...Rule) {
+	globalRulesMu.Lock()
+	globalRules = append(globalRules, rules...)
+	globalRulesMu.Unlock()
+}
+
+func (p Policy) checkRules() error {
+	globalRulesMu.Lock()
+	rules := append(append([]Rule{}, globalRules...), p.rules...)
+	globalRulesMu.Unlock()
+
+	var findings []Finding
+	for _, rule := range rules {
+		findings = append(findings, rule(p)...)
+	}
+	if len(findings) > 0 {
+		sources := make(map[string]string, len(p.Statement))
+		for _, s := range p.Statement {
+			if s.Sid != "" {
+				sources[s.Sid] = s.source
+			}
+		}
+		return &RuleError{PolicyID: p.ID, Findings: findings, sources: sources}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Shadowed reports statements that are entirely subsumed by another
+// statement in the same policy with the same effect, such as an Allow for
+// s3:GetObject on a single key when another statement allows s3:* on every
+// object in the bucket.  Removing such statements does not change what the
+// policy grants or denies.
+//
+// A statement is only considered shadowed if every one of its actions,
+// resources and principals is matched by the broader statement, and the
+// broader statement has no Condition or exactly the same conditions.
+// Statements using NotAction, NotResource or NotPrincipal, or containing
+// outputs, are not analysed.  If two statements are identical then only
+// the later one is reported.
+func (p Policy) Shadowed() []Finding {
+	findings := []Finding{}
+	for i, s := range p.Statement {
+		for j, broader := range p.Statement {
+			if i == j || !broader.subsumes(s) {
+				continue
+			}
+			if j > i && s.subsumes(broader) {
+				// identical in scope; report the later statement only
+				continue
+			}
+			findings = append(findings, Finding{"shadowed-statement", s.Sid,
+				fmt.Sprintf("statement is entirely covered by statement %q and can be removed", broader.Sid)})
+			break
+		}
+	}
+	return findings
+}
+
+// subsumes reports whether every request matched by o is also matched by s.
+func (s Stmt) subsumes(o Stmt) bool {
+	if s.Effect != o.Effect || !s.isStatic() || !o.isStatic() {
+		return false
+	}
+	for _, st := range []Stmt{s, o} {
+		if len(st.NotAction) > 0 || len(st.NotResource) > 0 || len(st.NotPrincipal) > 0 {
+			return false
+		}
+	}
+	if len(s.Condition) > 0 && !reflect.DeepEqual(s.Condition, o.Condition) {
+		return false
+	}
+	if !covers(staticStrings(s.Action), staticStrings(o.Action), true) {
+		return false
+	}
+	if len(s.Resource) > 0 && !covers(staticStrings(s.Resource), staticStrings(o.Resource), false) {
+		return false
+	}
+	if len(s.Principal) > 0 || len(o.Principal) > 0 {
+		for pt, ids := range o.Principal {
+			broad := staticStrings(s.Principal[pt])
+			if !containsString(broad, "*") && !covers(broad, staticStrings(ids), false) {
+				return false
+			}
+		}
+		if len(o.Principal) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// covers reports whether each of the values in narrow is matched by one of
+// the patterns in broad.  Wildcards in narrow are treated as literal
+// characters, so "s3:*" covers "s3:Get*" but not the reverse.
+func covers(broad, narrow []string, fold bool) bool {
+	if len(narrow) == 0 {
+		return false
+	}
+	for _, n := range narrow {
+		if fold {
+			n = strings.ToLower(n)
+		}
+		if !matchAny(broad, n, fold) {
+			return false
+		}
+	}
+	return true
+}
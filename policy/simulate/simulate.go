@@ -0,0 +1,97 @@
+// Package simulate runs policies built with the policy package through the
+// IAM policy simulator, so that a stack can verify a policy grants (or
+// denies) the access it is expected to before dependent resources are
+// created.
+//
+// The simulation is performed by calling iam:SimulateCustomPolicy once
+// the policy's inputs have resolved.  The caller must have permission to
+// call that action.
+package simulate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/gwatts/pulutil/policy"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrUnexpectedDecision is returned via the output of CustomPolicy if a
+// Check has an Expect value that does not match the simulated decision.
+var ErrUnexpectedDecision = errors.New("unexpected simulation decision")
+
+// Check defines a single action and resource to simulate.
+type Check struct {
+	Action   string
+	Resource string
+
+	// Expect optionally specifies the decision the simulation must
+	// return.  If set and the simulator returns a different decision then
+	// the output returned by CustomPolicy will be rejected, failing any
+	// resources that depend upon it.
+	Expect policy.Decision
+}
+
+// Key returns the key used for this check in the map returned by
+// CustomPolicy.
+func (c Check) Key() string {
+	return c.Action + " " + c.Resource
+}
+
+// decisions maps the EvalDecision values returned by the simulator to the
+// policy package's equivalents.
+var decisions = map[string]policy.Decision{
+	"allowed":      policy.Allowed,
+	"explicitDeny": policy.ExplicitDeny,
+	"implicitDeny": policy.ImplicitDeny,
+}
+
+// CustomPolicy simulates each check against the rendered policy p using
+// iam:SimulateCustomPolicy and returns a map of each check's Key to the
+// resulting policy.Decision.
+//
+// client will usually be created with iam.NewFromConfig.
+func CustomPolicy(ctx context.Context, client iam.SimulateCustomPolicyAPIClient, p *policy.Policy, checks ...Check) pulumi.StringMapOutput {
+	return p.ToStringOutputWithContext(ctx).ApplyTWithContext(ctx, func(ctx context.Context, doc string) (map[string]string, error) {
+		results := make(map[string]string, len(checks))
+		for _, c := range checks {
+			d, err := simulate(ctx, client, doc, c)
+			if err != nil {
+				return nil, err
+			}
+			if c.Expect != "" && d != c.Expect {
+				return nil, fmt.Errorf("%w: policy %q: %s on %s was %s, expected %s",
+					ErrUnexpectedDecision, p.ID, c.Action, c.Resource, d, c.Expect)
+			}
+			results[c.Key()] = string(d)
+		}
+		return results, nil
+	}).(pulumi.StringMapOutput)
+}
+
+func simulate(ctx context.Context, client iam.SimulateCustomPolicyAPIClient, doc string, c Check) (policy.Decision, error) {
+	pager := iam.NewSimulateCustomPolicyPaginator(client, &iam.SimulateCustomPolicyInput{
+		PolicyInputList: []string{doc},
+		ActionNames:     []string{c.Action},
+		ResourceArns:    []string{c.Resource},
+	})
+
+	decision := policy.ImplicitDeny
+	for pager.HasMorePages() {
+		out, err := pager.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to simulate %s on %s: %w", c.Action, c.Resource, err)
+		}
+		for _, r := range out.EvaluationResults {
+			switch decisions[string(r.EvalDecision)] {
+			case policy.ExplicitDeny:
+				return policy.ExplicitDeny, nil
+			case policy.Allowed:
+				decision = policy.Allowed
+			}
+		}
+	}
+	return decision, nil
+}
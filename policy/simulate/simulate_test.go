@@ -0,0 +1,87 @@
+package simulate
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/gwatts/pulutil/policy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+// fakeClient evaluates requests using the policy package's local
+// evaluator, rather than calling AWS.
+type fakeClient struct {
+	p *policy.Policy
+}
+
+func (c fakeClient) SimulateCustomPolicy(ctx context.Context, in *iam.SimulateCustomPolicyInput, _ ...func(*iam.Options)) (*iam.SimulateCustomPolicyOutput, error) {
+	d, err := c.p.Evaluate(in.ActionNames[0], in.ResourceArns[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	decision := map[policy.Decision]types.PolicyEvaluationDecisionType{
+		policy.Allowed:      types.PolicyEvaluationDecisionTypeAllowed,
+		policy.ExplicitDeny: types.PolicyEvaluationDecisionTypeExplicitDeny,
+		policy.ImplicitDeny: types.PolicyEvaluationDecisionTypeImplicitDeny,
+	}[d]
+	return &iam.SimulateCustomPolicyOutput{
+		EvaluationResults: []types.EvaluationResult{{EvalDecision: decision}},
+	}, nil
+}
+
+var p = policy.New("id",
+	policy.Statement("read",
+		policy.Effect(policy.Allow),
+		policy.Action("s3:GetObject"),
+		policy.Resource("arn:aws:s3:::bucket/*"),
+	),
+)
+
+func TestCustomPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		results := CustomPolicy(context.Background(), fakeClient{p}, p,
+			Check{Action: "s3:GetObject", Resource: "arn:aws:s3:::bucket/key", Expect: policy.Allowed},
+			Check{Action: "s3:PutObject", Resource: "arn:aws:s3:::bucket/key"},
+		)
+		results.ApplyT(func(r map[string]string) int {
+			assert.Equal(map[string]string{
+				"s3:GetObject arn:aws:s3:::bucket/key": "Allowed",
+				"s3:PutObject arn:aws:s3:::bucket/key": "ImplicitDeny",
+			}, r)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(err)
+	wg.Wait()
+}
+
+func TestCustomPolicyExpect(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		ctx.Export("results", CustomPolicy(context.Background(), fakeClient{p}, p,
+			Check{Action: "s3:PutObject", Resource: "arn:aws:s3:::bucket/key", Expect: policy.Allowed},
+		))
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.ErrorIs(t, err, ErrUnexpectedDecision)
+}
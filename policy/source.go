@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// packagePrefix is the prefix of the names of functions in this package.
+var packagePrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	slash := strings.LastIndex(name, "/")
+	return name[:slash+strings.Index(name[slash:], ".")+1]
+}()
+
+// callerLocation returns the file:line of the innermost caller outside of
+// this package, ie. the call to Statement, or to a helper that built a
+// statement, in the program building the policy.
+func callerLocation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, packagePrefix) || strings.HasSuffix(f.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// withSource annotates err with the location the statement was defined,
+// if known.
+func (s Stmt) withSource(err error) error {
+	if err == nil || s.source == "" {
+		return err
+	}
+	return fmt.Errorf("%w (statement defined at %s)", err, s.source)
+}
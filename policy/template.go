@@ -0,0 +1,71 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// StatementTemplate defines the shape of a statement once, with named
+// parameters, so that it can be instantiated repeatedly with different
+// values.
+//
+// Parameters are referenced as {{Name}} placeholders within the Sid and
+// the values passed to the template's options:
+//
+//	read := policy.NewStatementTemplate("Read{{Name}}",
+//		policy.Effect(policy.Allow),
+//		policy.Action("s3:GetObject", "s3:ListBucket"),
+//		policy.Resource("{{Bucket}}", "{{Bucket}}/*"),
+//	)
+//	policy.New("readers",
+//		read.With(map[string]interface{}{"Name": "Assets", "Bucket": assets.Arn}),
+//		read.With(map[string]interface{}{"Name": "Logs", "Bucket": logs.Arn}),
+//	)
+type StatementTemplate struct {
+	sid  string
+	opts []StatementOpt
+}
+
+// NewStatementTemplate creates a template for statements with the
+// supplied Sid and options.
+func NewStatementTemplate(sid string, opts ...StatementOpt) *StatementTemplate {
+	return &StatementTemplate{sid: sid, opts: opts}
+}
+
+// With instantiates the template, replacing each placeholder with the
+// named entry of params.  Values may be strings or StringInputs, except for
+// those used within the Sid, which must be strings.
+//
+// Will panic if a placeholder has no value.
+func (t *StatementTemplate) With(params map[string]interface{}) Opt {
+	lookup := func(name string) interface{} {
+		v, ok := params[name]
+		if !ok {
+			panic(fmt.Sprintf("statement template %q: no value for parameter %q; have %v",
+				t.sid, name, paramNames(params)))
+		}
+		return v
+	}
+
+	var tmp Policy
+	Statement(t.sid, t.opts...)(&tmp)
+	s := tmp.Statement[0]
+	sid, ok := substitute(s.Sid, lookup).(string)
+	if !ok {
+		panic(fmt.Sprintf("statement template %q: parameters used in the Sid must be strings", t.sid))
+	}
+	s.Sid = sid
+	s.substitute(lookup)
+	return func(p *Policy) {
+		p.Statement = append(p.Statement, s)
+	}
+}
+
+func paramNames(params map[string]interface{}) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
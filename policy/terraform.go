@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ToTerraformHCL renders the policy as a Terraform aws_iam_policy_document
+// data source block with the supplied name, once all of its inputs have
+// resolved.
+//
+// This is intended to help keep a single definition of a policy in Go
+// while migrating between tools; the output is formatted as terraform fmt
+// would format it.
+func (p Policy) ToTerraformHCL(name string) pulumi.StringOutput {
+	if err := p.Validate(); err != nil {
+		panic(err)
+	}
+	return pulumi.ToOutput(p).ApplyT(func(data interface{}) string {
+		return data.(Policy).terraformHCL(name)
+	}).(pulumi.StringOutput)
+}
+
+func (p Policy) terraformHCL(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "data \"aws_iam_policy_document\" %s {\n", hclString(name))
+	writeHCLAttrs(&b, "  ", [][2]string{
+		{"policy_id", hclString(p.ID)},
+		{"version", hclString(p.Version)},
+	})
+
+	for _, s := range p.Statement {
+		b.WriteString("\n  statement {\n")
+		var attrs [][2]string
+		if s.Sid != "" {
+			attrs = append(attrs, [2]string{"sid", hclString(s.Sid)})
+		}
+		attrs = append(attrs, [2]string{"effect", hclString(string(s.Effect))})
+		for _, el := range []struct {
+			name   string
+			values Strings
+		}{
+			{"actions", s.Action},
+			{"not_actions", s.NotAction},
+			{"resources", s.Resource},
+			{"not_resources", s.NotResource},
+		} {
+			if len(el.values) > 0 {
+				attrs = append(attrs, [2]string{el.name, hclList(el.values.flatten())})
+			}
+		}
+		writeHCLAttrs(&b, "    ", attrs)
+
+		writeHCLPrincipals(&b, "principals", s.Principal)
+		writeHCLPrincipals(&b, "not_principals", s.NotPrincipal)
+
+		for _, op := range sortedKeys(s.Condition) {
+			for _, key := range sortedKeys(s.Condition[op]) {
+				fmt.Fprintf(&b, "\n    condition {\n")
+				writeHCLAttrs(&b, "      ", [][2]string{
+					{"test", hclString(op)},
+					{"variable", hclString(key)},
+					{"values", hclList(s.Condition[op][key].flatten())},
+				})
+				b.WriteString("    }\n")
+			}
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func writeHCLPrincipals(b *strings.Builder, block string, principals map[string]Strings) {
+	for _, pt := range sortedKeys(principals) {
+		fmt.Fprintf(b, "\n    %s {\n", block)
+		writeHCLAttrs(b, "      ", [][2]string{
+			{"type", hclString(pt)},
+			{"identifiers", hclList(principals[pt].flatten())},
+		})
+		b.WriteString("    }\n")
+	}
+}
+
+// writeHCLAttrs writes name/value pairs with their equals signs aligned.
+func writeHCLAttrs(b *strings.Builder, indent string, attrs [][2]string) {
+	width := 0
+	for _, a := range attrs {
+		if len(a[0]) > width {
+			width = len(a[0])
+		}
+	}
+	for _, a := range attrs {
+		fmt.Fprintf(b, "%s%-*s = %s\n", indent, width, a[0], a[1])
+	}
+}
+
+var hclReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"${", "$${",
+	"%{", "%%{",
+)
+
+// hclString quotes s as an HCL string literal, escaping template sequences
+// so that IAM policy variables such as ${aws:username} are passed through.
+func hclString(s string) string {
+	return `"` + hclReplacer.Replace(s) + `"`
+}
+
+func hclList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = hclString(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]Strings:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]map[string]Strings:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,265 @@
+// Package rbac provides a helper for generating Kubernetes Role and
+// ClusterRole rules from Pulumi outputs, such as namespaces, resource names
+// or CRD API groups created earlier in the stack.
+//
+// Rules can be rendered either as the YAML rules section of a Role
+// manifest, or resolved into a list of maps with the same keys, to be
+// converted into the pulumi-kubernetes rbac/v1 Role and ClusterRole args.
+//
+//	rules := rbac.New(
+//		rbac.Rule(
+//			rbac.APIGroups(crd.Spec.Group()),
+//			rbac.Resources("widgets", "widgets/status"),
+//			rbac.Verbs(rbac.ReadVerbs...),
+//		),
+//		rbac.Rule(
+//			rbac.APIGroups(rbac.CoreGroup),
+//			rbac.Resources("configmaps"),
+//			rbac.ResourceNames(cm.Metadata.Name().Elem()),
+//			rbac.Verbs("get", "update"),
+//		),
+//	)
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidRule is returned if a rule fails validation.
+var ErrInvalidRule = errors.New("invalid rbac rule")
+
+// CoreGroup is the API group of core resources such as pods and secrets.
+const CoreGroup = ""
+
+// ReadVerbs grants read-only access to resources.
+var ReadVerbs = []string{"get", "list", "watch"}
+
+// WriteVerbs grants modification access to resources.
+var WriteVerbs = []string{"create", "update", "patch", "delete", "deletecollection"}
+
+var validVerbs = map[string]bool{
+	"*": true, "get": true, "list": true, "watch": true, "create": true,
+	"update": true, "patch": true, "delete": true, "deletecollection": true,
+	"impersonate": true, "bind": true, "escalate": true, "use": true,
+	"approve": true, "sign": true,
+}
+
+// Strings holds a list of entries that may be strings, string slices or
+// their Pulumi input equivalents.  It always marshals to a JSON array.
+type Strings []interface{}
+
+// MarshalJSON implements json.Marshaler.
+func (s Strings) MarshalJSON() ([]byte, error) {
+	out := make([]string, 0, len(s))
+	for _, el := range s {
+		switch v := el.(type) {
+		case string:
+			out = append(out, v)
+		case []string:
+			out = append(out, v...)
+		default:
+			return nil, fmt.Errorf("unexpected type in list: %T", el)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// static returns the entries that are already known, skipping outputs.
+func (s Strings) static() (out []string) {
+	for _, el := range s {
+		switch v := el.(type) {
+		case string:
+			out = append(out, v)
+		case []string:
+			out = append(out, v...)
+		}
+	}
+	return out
+}
+
+// PolicyRule defines a single RBAC rule.
+type PolicyRule struct {
+	APIGroups       Strings `json:"apiGroups,omitempty"`
+	Resources       Strings `json:"resources,omitempty"`
+	ResourceNames   Strings `json:"resourceNames,omitempty"`
+	NonResourceURLs Strings `json:"nonResourceURLs,omitempty"`
+	Verbs           Strings `json:"verbs"`
+}
+
+// Rules holds the rules of a Role or ClusterRole.
+type Rules []PolicyRule
+
+// Opt is implemented by functions that can be passed to New.
+type Opt func(*Rules)
+
+// New creates a new set of rules.
+func New(opts ...Opt) *Rules {
+	r := &Rules{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// RuleOpt is implemented by functions that can be passed to Rule.
+type RuleOpt func(*PolicyRule)
+
+// Rule adds a rule to the set.
+func Rule(opts ...RuleOpt) Opt {
+	return func(r *Rules) {
+		var rule PolicyRule
+		for _, opt := range opts {
+			opt(&rule)
+		}
+		*r = append(*r, rule)
+	}
+}
+
+// APIGroups adds API groups to the rule.  Use CoreGroup for core
+// resources.
+//
+// group arguments may be string, []string, StringInput or StringArrayInput
+// slices and arrays will be flattened into a single list.
+func APIGroups(group ...interface{}) RuleOpt {
+	return func(r *PolicyRule) { r.APIGroups = append(r.APIGroups, group...) }
+}
+
+// Resources adds resource types, such as "pods" or "deployments/scale".
+func Resources(resource ...interface{}) RuleOpt {
+	return func(r *PolicyRule) { r.Resources = append(r.Resources, resource...) }
+}
+
+// ResourceNames restricts the rule to specific named resources.
+func ResourceNames(name ...interface{}) RuleOpt {
+	return func(r *PolicyRule) { r.ResourceNames = append(r.ResourceNames, name...) }
+}
+
+// NonResourceURLs adds non-resource URLs such as "/healthz".  These are
+// only valid in a ClusterRole.
+func NonResourceURLs(url ...interface{}) RuleOpt {
+	return func(r *PolicyRule) { r.NonResourceURLs = append(r.NonResourceURLs, url...) }
+}
+
+// Verbs adds the permitted verbs.
+func Verbs(verb ...interface{}) RuleOpt {
+	return func(r *PolicyRule) { r.Verbs = append(r.Verbs, verb...) }
+}
+
+// Validate performs a basic structural check of each rule.
+func (r Rules) Validate() error {
+	for i, rule := range r {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate performs a basic structural check of the rule.
+func (r PolicyRule) Validate() error {
+	if len(r.Verbs) == 0 {
+		return fmt.Errorf("%w: no verbs specified", ErrInvalidRule)
+	}
+	for _, verb := range r.Verbs.static() {
+		if !validVerbs[verb] {
+			return fmt.Errorf("%w: unknown verb %q", ErrInvalidRule, verb)
+		}
+	}
+	hasResources := len(r.Resources) > 0
+	if hasResources && len(r.NonResourceURLs) > 0 {
+		return fmt.Errorf("%w: resources and nonResourceURLs cannot be combined", ErrInvalidRule)
+	}
+	if !hasResources && len(r.NonResourceURLs) == 0 {
+		return fmt.Errorf("%w: either resources or nonResourceURLs must be specified", ErrInvalidRule)
+	}
+	if hasResources && len(r.APIGroups) == 0 {
+		return fmt.Errorf("%w: resources require at least one apiGroup", ErrInvalidRule)
+	}
+	if len(r.ResourceNames) > 0 && !hasResources {
+		return fmt.Errorf("%w: resourceNames require resources", ErrInvalidRule)
+	}
+	return nil
+}
+
+// ToYAMLOutput renders the rules as the YAML rules section of a Role or
+// ClusterRole manifest.
+func (r Rules) ToYAMLOutput() pulumi.StringOutput {
+	return r.ToYAMLOutputWithContext(context.Background())
+}
+
+// ToYAMLOutputWithContext renders the rules as the YAML rules section of a
+// Role or ClusterRole manifest.
+func (r Rules) ToYAMLOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := r.Validate(); err != nil {
+		panic(err)
+	}
+	return pulumi.ToOutput(map[string]interface{}{"rules": []PolicyRule(r)}).ApplyTWithContext(ctx,
+		func(_ context.Context, data interface{}) (string, error) {
+			js, err := json.Marshal(data)
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal rules: %w", err)
+			}
+			return jsonToYAML(js)
+		}).(pulumi.StringOutput)
+}
+
+// ToArrayOutput resolves the rules into a list of maps, keyed as in a
+// Role manifest.
+//
+// This package doesn't depend on pulumi-kubernetes, so the result is an
+// untyped ArrayOutput rather than an rbacv1.PolicyRuleArrayOutput; callers
+// must convert each map into an rbacv1.PolicyRuleArgs themselves, with
+// ApplyT, to use it as the Rules of a Role or ClusterRole.
+func (r Rules) ToArrayOutput() pulumi.ArrayOutput {
+	if err := r.Validate(); err != nil {
+		panic(err)
+	}
+	return pulumi.ToOutput([]PolicyRule(r)).ApplyT(func(data interface{}) ([]interface{}, error) {
+		js, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal rules: %w", err)
+		}
+		var out []interface{}
+		err = json.Unmarshal(js, &out)
+		return out, err
+	}).(pulumi.ArrayOutput)
+}
+
+// jsonToYAML converts a JSON document to block style YAML, preserving the
+// order of keys.
+func jsonToYAML(js []byte) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(js, &doc); err != nil {
+		return "", err
+	}
+	setBlockStyle(&doc)
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func setBlockStyle(n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode {
+		n.Style = 0
+	} else if n.Style == yaml.DoubleQuotedStyle && n.Value != "" {
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		setBlockStyle(c)
+	}
+}
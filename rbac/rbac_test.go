@@ -0,0 +1,130 @@
+package rbac
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func testRules() *Rules {
+	return New(
+		Rule(
+			APIGroups(pulumi.String("example.com").ToStringOutput()),
+			Resources("widgets", "widgets/status"),
+			Verbs(ReadVerbs),
+		),
+		Rule(
+			APIGroups(CoreGroup),
+			Resources("configmaps"),
+			ResourceNames(pulumi.StringArray{pulumi.String("true")}),
+			Verbs("get", "update"),
+		),
+	)
+}
+
+func TestYAML(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		expected := `rules:
+  - apiGroups:
+      - example.com
+    resources:
+      - widgets
+      - widgets/status
+    verbs:
+      - get
+      - list
+      - watch
+  - apiGroups:
+      - ""
+    resources:
+      - configmaps
+    resourceNames:
+      - "true"
+    verbs:
+      - get
+      - update
+`
+		testRules().ToYAMLOutput().ApplyT(func(y string) int {
+			assert.Equal(expected, y)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestArrayOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		testRules().ToArrayOutput().ApplyT(func(rules []interface{}) int {
+			assert.Equal([]interface{}{
+				map[string]interface{}{
+					"apiGroups": []interface{}{"example.com"},
+					"resources": []interface{}{"widgets", "widgets/status"},
+					"verbs":     []interface{}{"get", "list", "watch"},
+				},
+				map[string]interface{}{
+					"apiGroups":     []interface{}{""},
+					"resources":     []interface{}{"configmaps"},
+					"resourceNames": []interface{}{"true"},
+					"verbs":         []interface{}{"get", "update"},
+				},
+			}, rules)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []RuleOpt
+		expected error
+	}{
+		{"ok", []RuleOpt{APIGroups(CoreGroup), Resources("pods"), Verbs("get")}, nil},
+		{"non-resource", []RuleOpt{NonResourceURLs("/healthz"), Verbs("get")}, nil},
+		{"output-verb", []RuleOpt{APIGroups(CoreGroup), Resources("pods"), Verbs(pulumi.String("x").ToStringOutput())}, nil},
+		{"no-verbs", []RuleOpt{APIGroups(CoreGroup), Resources("pods")}, ErrInvalidRule},
+		{"bad-verb", []RuleOpt{APIGroups(CoreGroup), Resources("pods"), Verbs("read")}, ErrInvalidRule},
+		{"no-target", []RuleOpt{Verbs("get")}, ErrInvalidRule},
+		{"both", []RuleOpt{APIGroups(CoreGroup), Resources("pods"), NonResourceURLs("/healthz"), Verbs("get")}, ErrInvalidRule},
+		{"no-group", []RuleOpt{Resources("pods"), Verbs("get")}, ErrInvalidRule},
+		{"names-without-resources", []RuleOpt{NonResourceURLs("/x"), ResourceNames("a"), Verbs("get")}, ErrInvalidRule},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := New(Rule(test.opts...)).Validate()
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+			}
+		})
+	}
+}
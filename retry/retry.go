@@ -0,0 +1,174 @@
+// Package retry retries provider invokes, such as data source lookups,
+// that fail transiently, eg. because of eventual consistency after the
+// resource being looked up was created, or because of API throttling.
+//
+//	ami, err := retry.Invoke(ctx, func() (*ec2.LookupAmiResult, error) {
+//		return ec2.LookupAmi(ctx, &ec2.LookupAmiArgs{
+//			Owners:     []string{"self"},
+//			MostRecent: pulumi.BoolRef(true),
+//		})
+//	}, retry.Attempts(10), retry.RetryOn(retry.NotFound, retry.Throttled))
+//
+// Invokes are retried with exponential backoff, and each retry is logged
+// as a warning.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Defaults used unless overridden by options.
+const (
+	DefaultAttempts     = 5
+	DefaultInitialDelay = time.Second
+	DefaultMaxDelay     = 30 * time.Second
+)
+
+// Classifier reports whether an invoke that failed with err should be
+// retried.
+type Classifier func(err error) bool
+
+// Messages returns a Classifier that matches errors whose message contains
+// any of substrings, ignoring case.
+func Messages(substrings ...string) Classifier {
+	return func(err error) bool {
+		msg := strings.ToLower(err.Error())
+		for _, s := range substrings {
+			if strings.Contains(msg, strings.ToLower(s)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Classifiers for common transient failures.
+var (
+	// NotFound matches lookups of resources that don't exist yet, or
+	// aren't yet visible.
+	NotFound = Messages("not found", "notfound", "does not exist", "no matching", "your query returned no results")
+	// Throttled matches requests rejected by API rate limits.
+	Throttled = Messages("throttl", "rate exceeded", "requestlimitexceeded", "too many requests", "slowdown")
+	// Transient matches network failures and unavailable services.
+	Transient = Messages("timeout", "timed out", "connection reset", "connection refused", "service unavailable", "internal error", "eof")
+)
+
+// Any returns a Classifier that matches errors matched by any of
+// classifiers.
+func Any(classifiers ...Classifier) Classifier {
+	return func(err error) bool {
+		for _, c := range classifiers {
+			if c(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type config struct {
+	attempts     int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	jitter       bool
+	retryOn      Classifier
+}
+
+// Opt is implemented by functions that can be passed to Invoke.
+type Opt func(*config)
+
+// Attempts sets the maximum number of times the invoke is made, including
+// the first.
+func Attempts(n int) Opt {
+	return func(cfg *config) {
+		cfg.attempts = n
+	}
+}
+
+// Backoff sets the delay before the first retry, which doubles after each
+// subsequent failure up to max.
+func Backoff(initial, max time.Duration) Opt {
+	return func(cfg *config) {
+		cfg.initialDelay = initial
+		cfg.maxDelay = max
+	}
+}
+
+// NoJitter disables the randomization of delays between retries.
+func NoJitter() Opt {
+	return func(cfg *config) {
+		cfg.jitter = false
+	}
+}
+
+// RetryOn sets the classifiers used to decide whether a failure should be
+// retried.  By default NotFound, Throttled and Transient failures are
+// retried.
+func RetryOn(classifiers ...Classifier) Opt {
+	return func(cfg *config) {
+		cfg.retryOn = Any(classifiers...)
+	}
+}
+
+// Invoke calls f, retrying it while it fails with an error matched by the
+// configured classifiers, until it succeeds or the maximum number of
+// attempts have been made.  Retries stop early if the Pulumi program is
+// cancelled.
+//
+// If every attempt fails, the last error is returned, wrapped to include
+// the number of attempts made.
+func Invoke[T any](ctx *pulumi.Context, f func() (T, error), opts ...Opt) (T, error) {
+	cfg := config{
+		attempts:     DefaultAttempts,
+		initialDelay: DefaultInitialDelay,
+		maxDelay:     DefaultMaxDelay,
+		jitter:       true,
+		retryOn:      Any(NotFound, Throttled, Transient),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	done := context.Background().Done()
+	if ctx != nil {
+		done = ctx.Context().Done()
+	}
+
+	delay := cfg.initialDelay
+	for attempt := 1; ; attempt++ {
+		result, err := f()
+		if err == nil {
+			return result, nil
+		}
+		if !cfg.retryOn(err) {
+			return result, err
+		}
+		if attempt >= cfg.attempts {
+			return result, fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		wait := delay
+		if cfg.jitter && wait > 0 {
+			// wait between 50% and 100% of the delay
+			wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+		}
+		if ctx != nil {
+			_ = ctx.Log.Warn(fmt.Sprintf("invoke failed, retrying in %v (attempt %d of %d): %v", wait, attempt, cfg.attempts, err), nil)
+		}
+		select {
+		case <-time.After(wait):
+		case <-done:
+			return result, fmt.Errorf("cancelled after %d attempts: %w", attempt, err)
+		}
+
+		if delay *= 2; delay > cfg.maxDelay {
+			delay = cfg.maxDelay
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+// failing returns a function that fails with err n times before
+// succeeding, and a pointer to the count of calls made.
+func failing(n int, err error) (func() (string, error), *int) {
+	calls := 0
+	return func() (string, error) {
+		calls++
+		if calls <= n {
+			return "", err
+		}
+		return "ok", nil
+	}, &calls
+}
+
+func TestInvoke(t *testing.T) {
+	fast := Backoff(time.Millisecond, 2*time.Millisecond)
+	errNotFound := errors.New("InvalidAMIID.NotFound: The image id '[ami-1234]' does not exist")
+
+	tests := []struct {
+		name          string
+		failures      int
+		err           error
+		opts          []Opt
+		expectedCalls int
+		expectedErr   bool
+	}{
+		{"success", 0, nil, nil, 1, false},
+		{"retried", 2, errNotFound, nil, 3, false},
+		{"throttled", 1, errors.New("Throttling: Rate exceeded"), nil, 2, false},
+		{"exhausted", 10, errNotFound, []Opt{Attempts(3)}, 3, true},
+		{"not-retryable", 1, errors.New("AccessDenied"), nil, 1, true},
+		{"custom-classifier", 1, errors.New("AccessDenied"), []Opt{RetryOn(Messages("accessdenied"))}, 2, false},
+		{"classifier-excludes", 1, errNotFound, []Opt{RetryOn(Throttled)}, 1, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.Run(t, func(ctx *pulumi.Context) error {
+				f, calls := failing(test.failures, test.err)
+				result, err := Invoke(ctx, f, append([]Opt{fast, NoJitter()}, test.opts...)...)
+				assert.Equal(t, test.expectedCalls, *calls)
+				if test.expectedErr {
+					assert.True(t, errors.Is(err, test.err), "unexpected error %v", err)
+				} else {
+					assert.NoError(t, err)
+					assert.Equal(t, "ok", result)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestInvokeWithoutContext(t *testing.T) {
+	f, calls := failing(1, errors.New("connection reset by peer"))
+	result, err := Invoke(nil, f, Backoff(time.Millisecond, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestInvokeProvider(t *testing.T) {
+	mocks := testutil.Run(t, func(ctx *pulumi.Context) error {
+		_, err := Invoke(ctx, func() (*aws.GetCallerIdentityResult, error) {
+			return aws.GetCallerIdentity(ctx)
+		})
+		return err
+	})
+	assert.Len(t, mocks.Calls(), 1)
+}
+
+func TestClassifiers(t *testing.T) {
+	assert.True(t, NotFound(errors.New("no matching EC2 VPC found")))
+	assert.True(t, Throttled(errors.New("RequestLimitExceeded")))
+	assert.True(t, Transient(errors.New("dial tcp: i/o timeout")))
+	assert.False(t, Any(NotFound, Throttled, Transient)(errors.New("UnauthorizedOperation")))
+}
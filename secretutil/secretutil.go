@@ -0,0 +1,195 @@
+// Package secretutil provides helpers for composing values from secret
+// outputs, such as generated passwords, while ensuring the result remains
+// secret.
+//
+//	dsn := secretutil.URL(secretutil.URLArgs{
+//		Scheme:   "postgres",
+//		User:     "app",
+//		Password: password.Result,
+//		Host:     db.Address,
+//		Port:     db.Port,
+//		Path:     "orders",
+//		Query:    map[string]string{"sslmode": "require"},
+//	})
+//
+// Values are always marked as secret, regardless of whether any of their
+// inputs are.  Components may be strings or Pulumi inputs, such as
+// StringOutputs or IntOutputs.
+package secretutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Redacted replaces secret values in the output of Redact.
+const Redacted = "[REDACTED]"
+
+// Sprintf is equivalent to pulumi.Sprintf, but the result is always
+// secret.
+func Sprintf(format string, args ...interface{}) pulumi.StringOutput {
+	return pulumi.ToSecret(pulumi.Sprintf(format, args...)).(pulumi.StringOutput)
+}
+
+// URLArgs holds the components of a connection URL.  Only Scheme and Host
+// are required.
+type URLArgs struct {
+	Scheme   string
+	User     interface{}
+	Password interface{}
+	Host     interface{}
+	Port     interface{}
+	Path     interface{}
+	Query    map[string]string
+}
+
+// URL builds a connection URL, such as a PostgreSQL or Redis connection
+// string, escaping the user and password as required.
+func URL(args URLArgs) pulumi.StringOutput {
+	return secret(func(v []string) (string, error) {
+		user, password, host, port, path := v[0], v[1], v[2], v[3], v[4]
+		if args.Scheme == "" || host == "" {
+			return "", fmt.Errorf("secretutil: URL requires a scheme and host")
+		}
+		u := url.URL{Scheme: args.Scheme, Host: host}
+		if port != "" {
+			u.Host = net.JoinHostPort(host, port)
+		}
+		switch {
+		case password != "":
+			u.User = url.UserPassword(user, password)
+		case user != "":
+			u.User = url.User(user)
+		}
+		if path != "" {
+			u.Path = "/" + strings.TrimPrefix(path, "/")
+		}
+		u.RawQuery = encodeQuery(args.Query)
+		return u.String(), nil
+	}, args.User, args.Password, args.Host, args.Port, args.Path)
+}
+
+// MySQLDSN builds a data source name in the form used by the Go MySQL
+// driver, eg. "user:password@tcp(host:3306)/database?tls=true".
+func MySQLDSN(user, password, host, port, database interface{}, params map[string]string) pulumi.StringOutput {
+	return secret(func(v []string) (string, error) {
+		user, password, host, port, database := v[0], v[1], v[2], v[3], v[4]
+		if host == "" {
+			return "", fmt.Errorf("secretutil: MySQLDSN requires a host")
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s", user, password, host, database)
+		if q := encodeQuery(params); q != "" {
+			dsn += "?" + q
+		}
+		return dsn, nil
+	}, user, password, host, port, database)
+}
+
+// JSON marshals a map of values, such as the fields of a Secrets Manager
+// secret, to JSON once any inputs it contains have resolved.
+func JSON(values map[string]interface{}) pulumi.StringOutput {
+	return JSONWithContext(context.Background(), values)
+}
+
+// JSONWithContext marshals a map of values, such as the fields of a Secrets
+// Manager secret, to JSON once any inputs it contains have resolved.
+func JSONWithContext(ctx context.Context, values map[string]interface{}) pulumi.StringOutput {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	inputs := make([]interface{}, len(keys))
+	for i, k := range keys {
+		inputs[i] = values[k]
+	}
+	out := pulumi.AllWithContext(ctx, inputs...).ApplyTWithContext(ctx, func(_ context.Context, resolved []interface{}) (string, error) {
+		m := make(map[string]interface{}, len(keys))
+		for i, k := range keys {
+			m[k] = resolved[i]
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return "", fmt.Errorf("secretutil: failed to marshal json: %w", err)
+		}
+		return string(data), nil
+	})
+	return pulumi.ToSecretWithContext(ctx, out).(pulumi.StringOutput)
+}
+
+// Redact replaces each occurrence of the values of secrets within text
+// with Redacted, returning a result that is not secret, so that rendered
+// documents can be exported or logged for debugging.
+func Redact(text pulumi.StringInput, secrets ...pulumi.StringInput) pulumi.StringOutput {
+	inputs := make([]interface{}, 0, len(secrets)+1)
+	inputs = append(inputs, text)
+	for _, s := range secrets {
+		inputs = append(inputs, s)
+	}
+	out := pulumi.All(inputs...).ApplyT(func(v []interface{}) string {
+		result := v[0].(string)
+		for _, s := range v[1:] {
+			if s := s.(string); s != "" {
+				result = strings.ReplaceAll(result, s, Redacted)
+			}
+		}
+		return result
+	})
+	return pulumi.Unsecret(out).(pulumi.StringOutput)
+}
+
+// secret resolves components to strings, and calls f with them, marking
+// the result as secret.
+func secret(f func([]string) (string, error), components ...interface{}) pulumi.StringOutput {
+	for _, c := range components {
+		switch c.(type) {
+		case nil, string, int, pulumi.StringInput, pulumi.IntInput, pulumi.StringPtrInput, pulumi.IntPtrInput:
+		default:
+			panic(fmt.Sprintf("secretutil: unexpected type %T: %#v", c, c))
+		}
+	}
+	out := pulumi.All(components...).ApplyT(func(v []interface{}) (string, error) {
+		strs := make([]string, len(v))
+		for i, c := range v {
+			strs[i] = stringValue(c)
+		}
+		return f(strs)
+	})
+	return pulumi.ToSecret(out).(pulumi.StringOutput)
+}
+
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	}
+	return fmt.Sprint(v)
+}
+
+func encodeQuery(params map[string]string) string {
+	q := make(url.Values, len(params))
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	return q.Encode()
+}
@@ -0,0 +1,211 @@
+// Package stackutil exports structs containing Pulumi outputs as a single
+// structured stack output, and reads them back from other stacks using a
+// stack reference.
+//
+//	type Network struct {
+//		VpcID     pulumi.IDOutput          `json:"vpcId"`
+//		SubnetIDs pulumi.StringArrayOutput `json:"subnetIds"`
+//		Region    string                   `json:"region"`
+//	}
+//
+//	// in the network stack
+//	err := stackutil.ExportStruct(ctx, "network", Network{VpcID: vpc.ID(), ...})
+//
+//	// in a stack that depends on it, declaring the fields' resolved types
+//	type NetworkRef struct {
+//		VpcID     string   `json:"vpcId"`
+//		SubnetIDs []string `json:"subnetIds"`
+//		Region    string   `json:"region"`
+//	}
+//	ref, err := pulumi.NewStackReference(ctx, "acme/network/prod", nil)
+//	network := stackutil.ReadStruct[NetworkRef](ref, "network")
+//
+// Fields are named by their json tags.  Each field holding a secret output
+// remains secret within the exported value, rather than the whole value
+// being marked secret.
+package stackutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+var inputType = reflect.TypeOf((*pulumi.Input)(nil)).Elem()
+
+// ExportStruct exports v, a struct or map whose fields may be outputs, as
+// the stack output name.
+func ExportStruct(ctx *pulumi.Context, name string, v interface{}) error {
+	in, err := ToInput(v)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", name, err)
+	}
+	ctx.Export(name, in)
+	return nil
+}
+
+// ToInput converts v into a tree of pulumi.Map and pulumi.Array values
+// holding its outputs, without resolving them, so that the secrecy and
+// dependencies of each are preserved.
+func ToInput(v interface{}) (pulumi.Input, error) {
+	in, err := toInput(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if in == nil {
+		return nil, fmt.Errorf("cannot export nil value")
+	}
+	return in, nil
+}
+
+func toInput(v reflect.Value) (pulumi.Input, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type().Implements(inputType) {
+		if isNil(v) || v.IsZero() {
+			return nil, nil
+		}
+		return v.Interface().(pulumi.Input), nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return toInput(v.Elem())
+	case reflect.Struct:
+		return structInput(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		m := make(pulumi.Map, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			in, err := toInput(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			if in != nil {
+				m[fmt.Sprint(iter.Key().Interface())] = in
+			}
+		}
+		return m, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		a := make(pulumi.Array, v.Len())
+		for i := range a {
+			in, err := toInput(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			if in == nil {
+				in = pulumi.ToOutput(nil)
+			}
+			a[i] = in
+		}
+		return a, nil
+	case reflect.String:
+		return pulumi.String(v.String()), nil
+	case reflect.Bool:
+		return pulumi.Bool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return pulumi.Int(int(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return pulumi.Int(int(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return pulumi.Float64(v.Float()), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// structInput converts a struct's exported fields, as named by their json
+// tags, into a Map.  Fields tagged omitempty are skipped if they hold the
+// zero value, and the fields of embedded structs are promoted.
+func structInput(v reflect.Value) (pulumi.Map, error) {
+	m := make(pulumi.Map)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if f.Anonymous && name == "" && !f.Type.Implements(inputType) {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				embedded, err := structInput(fv)
+				if err != nil {
+					return nil, err
+				}
+				for k, e := range embedded {
+					if _, ok := m[k]; !ok {
+						m[k] = e
+					}
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && fv.IsZero() {
+			continue
+		}
+		in, err := toInput(fv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if in != nil {
+			m[name] = in
+		}
+	}
+	return m, nil
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// ReadStruct reads the stack output name from ref, decoding it into a T,
+// such as the struct passed to ExportStruct by the referenced stack.
+// Fields that hold outputs in the exporting stack should be declared as
+// their resolved types, eg. string rather than pulumi.StringOutput.
+//
+// The result is secret if any part of the output is secret.
+func ReadStruct[T any](ref *pulumi.StackReference, name string) pulumi.Output {
+	return ref.GetOutput(pulumi.String(name)).ApplyT(func(v interface{}) (T, error) {
+		var result T
+		if v == nil {
+			return result, fmt.Errorf("referenced stack has no output %q", name)
+		}
+		js, err := json.Marshal(v)
+		if err != nil {
+			return result, err
+		}
+		if err := json.Unmarshal(js, &result); err != nil {
+			return result, fmt.Errorf("failed to decode output %q: %w", name, err)
+		}
+		return result, nil
+	})
+}
@@ -0,0 +1,130 @@
+package stackutil
+
+import (
+	"testing"
+
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type Base struct {
+	Region string `json:"region"`
+}
+
+type Network struct {
+	Base
+	VpcID     pulumi.StringOutput      `json:"vpcId"`
+	SubnetIDs pulumi.StringArrayOutput `json:"subnetIds"`
+	Password  pulumi.StringOutput      `json:"password"`
+	Ports     []int                    `json:"ports"`
+	Tags      map[string]interface{}   `json:"tags,omitempty"`
+	Internal  string                   `json:"-"`
+	Unset     pulumi.StringOutput      `json:"unset"`
+	Named     *Base
+}
+
+func TestToInput(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		in, err := ToInput(Network{
+			Base:      Base{Region: "us-west-2"},
+			VpcID:     pulumi.String("vpc-1234").ToStringOutput(),
+			SubnetIDs: pulumi.ToStringArray([]string{"subnet-1"}).ToStringArrayOutput(),
+			Password:  pulumi.ToSecret(pulumi.String("hunter2")).(pulumi.StringOutput),
+			Ports:     []int{80, 443},
+			Internal:  "hidden",
+			Named:     &Base{Region: "us-east-1"},
+		})
+		assert.NoError(t, err)
+		m := in.(pulumi.Map)
+		assert.ElementsMatch(t, []string{"region", "vpcId", "subnetIds", "password", "ports", "Named"}, keys(m))
+
+		assert.True(t, testutil.IsSecret(t, m["password"].(pulumi.StringOutput)))
+		assert.False(t, testutil.IsSecret(t, m["vpcId"].(pulumi.StringOutput)))
+
+		m["password"] = pulumi.String("redacted")
+		assert.Equal(t, map[string]interface{}{
+			"region":    "us-west-2",
+			"vpcId":     "vpc-1234",
+			"subnetIds": []string{"subnet-1"},
+			"password":  "redacted",
+			"ports":     []interface{}{80, 443},
+			"Named":     map[string]interface{}{"region": "us-east-1"},
+		}, testutil.AwaitOutput[map[string]interface{}](t, m.ToMapOutput()))
+		return nil
+	})
+
+	_, err := ToInput(struct{ C chan int }{make(chan int)})
+	assert.Error(t, err)
+	_, err = ToInput(nil)
+	assert.Error(t, err)
+}
+
+func TestExportStruct(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		assert.NoError(t, ExportStruct(ctx, "network", map[string]interface{}{"vpcId": pulumi.String("vpc-1234")}))
+		assert.Error(t, ExportStruct(ctx, "bad", func() {}))
+		return nil
+	})
+}
+
+// refMocks implements a stack reference whose referenced stack exports
+// outputs.
+type refMocks struct {
+	testutil.Mocks
+	outputs resource.PropertyMap
+}
+
+func (m *refMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	if args.TypeToken == "pulumi:pulumi:StackReference" {
+		return args.Name, resource.PropertyMap{
+			"name":    resource.NewStringProperty(args.Name),
+			"outputs": resource.NewObjectProperty(m.outputs),
+		}, nil
+	}
+	return m.Mocks.NewResource(args)
+}
+
+func TestReadStruct(t *testing.T) {
+	mocks := &refMocks{outputs: resource.NewPropertyMapFromMap(map[string]interface{}{
+		"network": map[string]interface{}{
+			"vpcId":     "vpc-1234",
+			"subnetIds": []interface{}{"subnet-1", "subnet-2"},
+			"ports":     []interface{}{80},
+		},
+	})}
+	type NetworkRef struct {
+		VpcID     string   `json:"vpcId"`
+		SubnetIDs []string `json:"subnetIds"`
+		Ports     []int    `json:"ports"`
+	}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		ref, err := pulumi.NewStackReference(ctx, "acme/network/prod", nil)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, NetworkRef{
+			VpcID:     "vpc-1234",
+			SubnetIDs: []string{"subnet-1", "subnet-2"},
+			Ports:     []int{80},
+		}, testutil.AwaitOutput[NetworkRef](t, ReadStruct[NetworkRef](ref, "network")))
+
+		err = testutil.AwaitErr(t, ReadStruct[NetworkRef](ref, "missing"))
+		assert.Error(t, err)
+		err = testutil.AwaitErr(t, ReadStruct[struct {
+			VpcID int `json:"vpcId"`
+		}](ref, "network"))
+		assert.Error(t, err)
+		return nil
+	}, pulumi.WithMocks(testutil.Project, testutil.Stack, mocks))
+	assert.NoError(t, err)
+}
+
+func keys(m pulumi.Map) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
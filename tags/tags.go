@@ -0,0 +1,211 @@
+// Package tags provides a helper for building consistent resource tags,
+// merging a standard set of defaults, such as the project and stack
+// names, with tags specific to each resource.
+//
+// Tags are validated against the limits imposed by AWS, and may be
+// applied automatically to every taggable AWS resource in a stack using
+// AutoTag:
+//
+//	defaults := tags.Defaults(ctx, tags.Owner("platform"), tags.CostCenter("1234"))
+//	if err := tags.AutoTag(ctx, defaults); err != nil {
+//		return err
+//	}
+//
+//	s3.NewBucket(ctx, "logs", &s3.BucketArgs{
+//		Tags: defaults.With("Purpose", "logs").ToStringMap(),
+//	})
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Limits imposed by AWS on the tags applied to a resource.
+const (
+	MaxTags        = 50
+	MaxKeyLength   = 128
+	MaxValueLength = 256
+)
+
+// Standard tag keys set by Defaults and the corresponding options.
+const (
+	ProjectKey    = "Project"
+	StackKey      = "Stack"
+	OwnerKey      = "Owner"
+	CostCenterKey = "CostCenter"
+)
+
+// ErrInvalidTags is returned during validation of a set of tags.
+var ErrInvalidTags = errors.New("invalid tags")
+
+// Tags holds a set of tags keyed by name.
+type Tags map[string]string
+
+// Opt is implemented by functions that can be passed to New and Defaults.
+type Opt func(Tags)
+
+// New creates a new set of tags.
+func New(opts ...Opt) Tags {
+	t := make(Tags)
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Defaults creates a new set of tags holding the Pulumi project and stack
+// names, suitable for applying to every resource in the stack.
+func Defaults(ctx *pulumi.Context, opts ...Opt) Tags {
+	return New(append([]Opt{
+		Tag(ProjectKey, ctx.Project()),
+		Tag(StackKey, ctx.Stack()),
+	}, opts...)...)
+}
+
+// Tag sets a single tag.
+func Tag(key, value string) Opt {
+	return func(t Tags) {
+		t[key] = value
+	}
+}
+
+// FromMap sets each of the tags in m.
+func FromMap(m map[string]string) Opt {
+	return func(t Tags) {
+		for k, v := range m {
+			t[k] = v
+		}
+	}
+}
+
+// Owner sets the Owner tag.
+func Owner(owner string) Opt { return Tag(OwnerKey, owner) }
+
+// CostCenter sets the CostCenter tag.
+func CostCenter(costCenter string) Opt { return Tag(CostCenterKey, costCenter) }
+
+// With returns a copy of the tags with key set to value.
+func (t Tags) With(key, value string) Tags {
+	return t.Merge(map[string]string{key: value})
+}
+
+// Merge returns a copy of the tags with each of others applied in turn, so
+// that later tags take precedence.  The receiver is not modified.
+func (t Tags) Merge(others ...map[string]string) Tags {
+	result := make(Tags, len(t))
+	for k, v := range t {
+		result[k] = v
+	}
+	for _, other := range others {
+		for k, v := range other {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// Validate checks the tags against the limits imposed by AWS: no more than
+// MaxTags tags, non-empty keys of no more than MaxKeyLength characters that
+// don't use the reserved "aws:" prefix, and values of no more than
+// MaxValueLength characters.
+func (t Tags) Validate() error {
+	if len(t) > MaxTags {
+		return fmt.Errorf("%w: %d tags exceeds the limit of %d", ErrInvalidTags, len(t), MaxTags)
+	}
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch {
+		case k == "":
+			return fmt.Errorf("%w: empty key", ErrInvalidTags)
+		case len([]rune(k)) > MaxKeyLength:
+			return fmt.Errorf("%w: key %q exceeds %d characters", ErrInvalidTags, k, MaxKeyLength)
+		case strings.HasPrefix(strings.ToLower(k), "aws:"):
+			return fmt.Errorf("%w: key %q uses the reserved aws: prefix", ErrInvalidTags, k)
+		case len([]rune(t[k])) > MaxValueLength:
+			return fmt.Errorf("%w: value of %q exceeds %d characters", ErrInvalidTags, k, MaxValueLength)
+		}
+	}
+	return nil
+}
+
+// ToStringMap returns the tags as a StringMap, as accepted by the Tags
+// argument of AWS resources.
+//
+// Will panic if Validate returns an error.
+func (t Tags) ToStringMap() pulumi.StringMap {
+	if err := t.Validate(); err != nil {
+		panic(err)
+	}
+	m := make(pulumi.StringMap, len(t))
+	for k, v := range t {
+		m[k] = pulumi.String(v)
+	}
+	return m
+}
+
+// AutoTag registers a stack transformation that applies t to every AWS
+// resource in the stack with a Tags argument.  Tags supplied to a
+// resource take precedence over t.
+//
+// Resources that take tags in another form, such as autoscaling groups,
+// are left unchanged.
+func AutoTag(ctx *pulumi.Context, t Tags) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	return ctx.RegisterStackTransformation(t.Transformation())
+}
+
+var stringMapInputType = reflect.TypeOf((*pulumi.StringMapInput)(nil)).Elem()
+
+// Transformation returns a resource transformation that applies t to AWS
+// resources, as registered by AutoTag.  It may also be supplied to
+// individual resources or components using pulumi.Transformations.
+func (t Tags) Transformation() pulumi.ResourceTransformation {
+	return func(args *pulumi.ResourceTransformationArgs) *pulumi.ResourceTransformationResult {
+		if !strings.HasPrefix(args.Type, "aws:") || args.Props == nil {
+			return nil
+		}
+		props := reflect.ValueOf(args.Props)
+		if props.Kind() != reflect.Ptr || props.IsNil() || props.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		field, ok := props.Elem().Type().FieldByName("Tags")
+		if !ok || field.Type != stringMapInputType {
+			return nil
+		}
+
+		// copy the arguments rather than modifying those supplied by the caller
+		newProps := reflect.New(props.Elem().Type())
+		newProps.Elem().Set(props.Elem())
+		tags := newProps.Elem().FieldByIndex(field.Index)
+		if tags.IsNil() {
+			tags.Set(reflect.ValueOf(t.ToStringMap()))
+		} else {
+			existing := tags.Interface().(pulumi.StringMapInput)
+			merged := existing.ToStringMapOutput().ApplyT(func(m map[string]string) (map[string]string, error) {
+				merged := t.Merge(m)
+				if err := merged.Validate(); err != nil {
+					return nil, fmt.Errorf("%s %s: %w", args.Type, args.Name, err)
+				}
+				return merged, nil
+			}).(pulumi.StringMapOutput)
+			tags.Set(reflect.ValueOf(merged))
+		}
+
+		return &pulumi.ResourceTransformationResult{
+			Props: newProps.Interface().(pulumi.Input),
+			Opts:  args.Opts,
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package tags
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+// mocks records the tags supplied to each resource.
+type mocks struct {
+	m    sync.Mutex
+	tags map[string]map[string]string
+}
+
+func (mk *mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	mk.m.Lock()
+	defer mk.m.Unlock()
+	if tags, ok := args.Inputs["tags"]; ok && tags.IsObject() {
+		m := make(map[string]string)
+		for k, v := range tags.ObjectValue() {
+			m[string(k)] = v.StringValue()
+		}
+		mk.tags[args.Name] = m
+	}
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mk *mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestDefaults(t *testing.T) {
+	assert := assert.New(t)
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		tags := Defaults(ctx, Owner("platform"), CostCenter("1234"), Tag("Env", "prod"))
+		assert.Equal(Tags{
+			"Project":    "project",
+			"Stack":      "stack",
+			"Owner":      "platform",
+			"CostCenter": "1234",
+			"Env":        "prod",
+		}, tags)
+		return nil
+	}, pulumi.WithMocks("project", "stack", &mocks{}))
+	assert.NoError(err)
+}
+
+func TestMerge(t *testing.T) {
+	assert := assert.New(t)
+	base := New(Tag("a", "1"), FromMap(map[string]string{"b": "2"}))
+	merged := base.Merge(map[string]string{"b": "3"}, map[string]string{"c": "4"})
+	assert.Equal(Tags{"a": "1", "b": "3", "c": "4"}, merged)
+	assert.Equal(Tags{"a": "1", "b": "2"}, base, "receiver should be unchanged")
+	assert.Equal(Tags{"a": "1", "b": "2", "d": "5"}, base.With("d", "5"))
+}
+
+func TestValidate(t *testing.T) {
+	tooMany := New()
+	for i := 0; i <= MaxTags; i++ {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+	tests := []struct {
+		name  string
+		tags  Tags
+		valid bool
+	}{
+		{"ok", New(Tag("Name", "web")), true},
+		{"empty-value", New(Tag("Name", "")), true},
+		{"empty-key", New(Tag("", "web")), false},
+		{"long-key", New(Tag(strings.Repeat("k", MaxKeyLength+1), "v")), false},
+		{"long-value", New(Tag("Name", strings.Repeat("v", MaxValueLength+1))), false},
+		{"reserved-prefix", New(Tag("AWS:Name", "v")), false},
+		{"too-many", tooMany, false},
+	}
+	for _, test := range tests {
+		err := test.tags.Validate()
+		if test.valid {
+			assert.NoError(t, err, test.name)
+		} else {
+			assert.True(t, errors.Is(err, ErrInvalidTags), "%s: unexpected error %v", test.name, err)
+		}
+	}
+}
+
+func TestToStringMapPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		New(Tag("", "v")).ToStringMap()
+	})
+}
+
+func TestAutoTag(t *testing.T) {
+	assert := assert.New(t)
+	mk := &mocks{tags: make(map[string]map[string]string)}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		if err := AutoTag(ctx, Defaults(ctx, Owner("platform"))); err != nil {
+			return err
+		}
+		if _, err := s3.NewBucket(ctx, "untagged", &s3.BucketArgs{}); err != nil {
+			return err
+		}
+		_, err := s3.NewBucket(ctx, "tagged", &s3.BucketArgs{
+			Tags: pulumi.StringMap{
+				"Owner":   pulumi.String("data"),
+				"Purpose": pulumi.String("logs"),
+			},
+		})
+		return err
+	}, pulumi.WithMocks("project", "stack", mk))
+	assert.NoError(err)
+
+	assert.Equal(map[string]string{
+		"Project": "project",
+		"Stack":   "stack",
+		"Owner":   "platform",
+	}, mk.tags["untagged"])
+	assert.Equal(map[string]string{
+		"Project": "project",
+		"Stack":   "stack",
+		"Owner":   "data",
+		"Purpose": "logs",
+	}, mk.tags["tagged"])
+}
+
+func TestAutoTagInvalid(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		return AutoTag(ctx, New(Tag("aws:reserved", "v")))
+	}, pulumi.WithMocks("project", "stack", &mocks{}))
+	assert.True(t, errors.Is(err, ErrInvalidTags), "unexpected error %v", err)
+}
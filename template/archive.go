@@ -0,0 +1,75 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
+)
+
+// NewArchive renders every file in fsys as a template, supplying each with
+// the same vars, and returns an AssetArchive containing the results keyed
+// by their path relative to the root of fsys.  Use os.DirFS to render a
+// directory on disk, or fs.Sub to render a subdirectory of an embedded
+// filesystem.
+//
+// The archive can be used anywhere an Archive is accepted, eg. as the Code
+// of a Lambda layer, or the Source of an S3 bucket object.  opts are applied
+// to every file; the output is secret if any of the rendered files are.
+//
+// NewArchive will panic if fsys cannot be read, or if any file fails to
+// compile or execute.
+func NewArchive(vars map[string]interface{}, fsys fs.FS, opts ...Opt) pulumi.ArchiveOutput {
+	out, err := renderArchive(fsys, func(text string) (pulumi.StringOutput, error) {
+		return renderTemplate(context.Background(), vars, text, nil, opts), nil
+	})
+	if err != nil {
+		newConfig(opts).fail(err)
+		return pulumi.NewAssetArchive(nil).ToArchiveOutput()
+	}
+	return out
+}
+
+// TryNewArchive is equivalent to NewArchive, but returns an error if fsys
+// cannot be read or a file fails to compile rather than panicking.
+// Execution errors are returned through the output's error mechanism.
+func TryNewArchive(vars map[string]interface{}, fsys fs.FS, opts ...Opt) (pulumi.ArchiveOutput, error) {
+	return renderArchive(fsys, func(text string) (pulumi.StringOutput, error) {
+		return tryRenderTemplate(context.Background(), vars, text, nil, opts)
+	})
+}
+
+// renderArchive calls render for each file in fsys and combines the results
+// into an AssetArchive.
+func renderArchive(fsys fs.FS, render func(text string) (pulumi.StringOutput, error)) (pulumi.ArchiveOutput, error) {
+	var names []string
+	var rendered []interface{}
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		text, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		out, err := render(string(text))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		names = append(names, path)
+		rendered = append(rendered, out)
+		return nil
+	})
+	if err != nil {
+		return pulumi.ArchiveOutput{}, err
+	}
+
+	return pulumi.All(rendered...).ApplyT(func(rendered []interface{}) pulumi.Archive {
+		assets := make(map[string]interface{}, len(rendered))
+		for i, text := range rendered {
+			assets[names[i]] = pulumi.NewStringAsset(text.(string))
+		}
+		return pulumi.NewAssetArchive(assets)
+	}).(pulumi.ArchiveOutput), nil
+}
@@ -0,0 +1,92 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
+)
+
+// Names of the variables supplied to the template when the WithConfig
+// option is supplied.
+const (
+	ConfigVar = "Config"
+	PulumiVar = "Pulumi"
+)
+
+// envOrganization is the environment variable used by the engine to pass
+// the stack's organization to the program, which the Pulumi context
+// doesn't otherwise expose.
+const envOrganization = "PULUMI_ORGANIZATION"
+
+// StackInfo describes the stack being deployed, and is supplied to the
+// template as .Pulumi when the WithConfig option is supplied.
+type StackInfo struct {
+	Project      string
+	Stack        string
+	Organization string
+}
+
+// WithConfig exposes the stack's configuration to the template as a map
+// named .Config, so configuration driven documents don't need each key
+// copied into vars.  Keys belonging to the current project are available
+// by their short name, eg. {{.Config.bucketName}}, and every key is
+// available by its full name, eg. {{index .Config "aws:region"}}.
+//
+// The project and stack names are also made available as a StackInfo
+// named .Pulumi, eg. {{.Pulumi.Stack}}.  Organization is empty if the
+// engine doesn't supply it.
+//
+// "Config" or "Pulumi" entries supplied in vars take precedence.  Secret
+// configuration values are supplied in plaintext, so AsSecret should be
+// used if the template references them.
+func WithConfig(ctx *pulumi.Context) Opt {
+	return func(c *config) {
+		c.pulumiCtx = ctx
+	}
+}
+
+// stackConfig returns the configuration of the stack ctx belongs to.
+//
+// The Pulumi context doesn't provide a way to enumerate its configuration,
+// so the keys are read from the environment supplied by the engine and
+// their values looked up via the context.
+func stackConfig(ctx *pulumi.Context) map[string]interface{} {
+	var keys map[string]string
+	if env := os.Getenv(pulumi.EnvConfig); env != "" {
+		_ = json.Unmarshal([]byte(env), &keys)
+	}
+	result := make(map[string]interface{}, len(keys))
+	prefix := ctx.Project() + ":"
+	for key := range keys {
+		value, ok := ctx.GetConfig(key)
+		if !ok {
+			continue
+		}
+		result[key] = value
+		if strings.HasPrefix(key, prefix) {
+			result[strings.TrimPrefix(key, prefix)] = value
+		}
+	}
+	return result
+}
+
+// contextVars returns vars extended with any variables derived from the
+// Pulumi context supplied by WithConfig.  vars is not modified.
+func (cfg config) contextVars(vars map[string]interface{}) map[string]interface{} {
+	if cfg.pulumiCtx == nil {
+		return vars
+	}
+	result := make(map[string]interface{}, len(vars)+2)
+	result[ConfigVar] = stackConfig(cfg.pulumiCtx)
+	result[PulumiVar] = StackInfo{
+		Project:      cfg.pulumiCtx.Project(),
+		Stack:        cfg.pulumiCtx.Stack(),
+		Organization: os.Getenv(envOrganization),
+	}
+	for k, v := range vars {
+		result[k] = v
+	}
+	return result
+}
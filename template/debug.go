@@ -0,0 +1,92 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
+)
+
+// redacted replaces secret values in debug logs.
+const redacted = "[secret]"
+
+// WithDebug logs the resolved variables supplied to the template, and the
+// rendered result, at debug level via ctx.Log, to help diagnose templates
+// that don't render as expected.  Logs are shown by pulumi up --debug.
+//
+// Variables resolved from secret outputs are redacted, as is the rendered
+// result if any variable is secret or AsSecret is supplied.
+func WithDebug(ctx *pulumi.Context) Opt {
+	return func(c *config) {
+		c.debugLog = func(msg string) {
+			_ = ctx.Log.Debug(msg, nil)
+		}
+	}
+}
+
+// debug wraps f to log the variables it's called with, along with its
+// result.  It returns the vars that must be resolved for the wrapped
+// function, which retain the outputs needed to check for secrets.
+func (r *renderer) debug(vars map[string]interface{}, f func(map[string]interface{}) (string, error)) (map[string]interface{}, func(map[string]interface{}) (string, error)) {
+	inputs := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		inputs[k] = deepInputs(v)
+	}
+	return inputs, func(finalVars map[string]interface{}) (string, error) {
+		names := make([]string, 0, len(finalVars))
+		for k := range finalVars {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "template %q resolved vars:", r.name)
+		secret := r.cfg.secret
+		for _, k := range names {
+			if isSecretOutput(inputs[k]) {
+				secret = true
+				fmt.Fprintf(&b, "\n  %s: %s", k, redacted)
+				continue
+			}
+			fmt.Fprintf(&b, "\n  %s: %#v", k, finalVars[k])
+		}
+		r.cfg.debugLog(b.String())
+
+		result, err := f(finalVars)
+		switch {
+		case err != nil:
+			r.cfg.debugLog(fmt.Sprintf("template %q failed: %v", r.name, err))
+		case secret:
+			r.cfg.debugLog(fmt.Sprintf("template %q rendered: %s", r.name, redacted))
+		default:
+			r.cfg.debugLog(fmt.Sprintf("template %q rendered:\n%s", r.name, result))
+		}
+		return result, err
+	}
+}
+
+// isSecretOutput reports whether v is a resolved output marked as secret.
+//
+// The SDK doesn't expose an output's secretness, so it's read from the
+// OutputState embedded in each output type; outputs that can't be
+// inspected are assumed to be secret.
+func isSecretOutput(v interface{}) bool {
+	if _, ok := v.(pulumi.Output); !ok {
+		return false
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return true
+	}
+	state := rv.FieldByName("OutputState")
+	if !state.IsValid() || state.Kind() != reflect.Ptr || state.IsNil() {
+		return true
+	}
+	secret := state.Elem().FieldByName("secret")
+	if !secret.IsValid() || secret.Kind() != reflect.Bool {
+		return true
+	}
+	return secret.Bool()
+}
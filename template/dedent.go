@@ -0,0 +1,67 @@
+package template
+
+import (
+	"strings"
+)
+
+// Dedent causes the template text, including the templates supplied to
+// NewSet and partials registered with WithPartial, to be passed through
+// Heredoc before being parsed, so that templates written as indented raw
+// string literals don't carry that indentation into the rendered output.
+func Dedent() Opt {
+	return func(c *config) {
+		c.dedent = true
+	}
+}
+
+// Heredoc removes the leading whitespace common to every non-blank line of
+// text, along with a leading newline, so that an indented raw string
+// literal can be used for a template or other multi-line string, eg.
+//
+//	text := template.Heredoc(`
+//		[Service]
+//		ExecStart={{.command}}
+//	`)
+//
+// yields "[Service]\nExecStart={{.command}}\n".  Lines containing only
+// whitespace are emptied.
+func Heredoc(text string) string {
+	text = strings.TrimPrefix(text, "\n")
+	lines := strings.Split(text, "\n")
+
+	var prefix string
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		prefix = commonPrefix(prefix, indent)
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = line[len(prefix):]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func commonPrefix(a, b string) string {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return a[:i]
+		}
+	}
+	return a[:n]
+}
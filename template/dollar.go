@@ -0,0 +1,43 @@
+package template
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var (
+	dollarPlaceholder = regexp.MustCompile(`\$\{(!?)([A-Za-z0-9_][A-Za-z0-9_:.-]*)\}`)
+	fieldPath         = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+)
+
+// DollarDelims causes the template to use ${ and } as its action
+// delimiters, in place of {{ and }}, so that CloudFormation Fn::Sub strings
+// and shell style configuration files can be used without being rewritten.
+//
+// Placeholders naming a variable are looked up in vars, so ${Name} is
+// equivalent to {{.Name}} and ${Bucket.Arn} to {{.Bucket.Arn}}.  Names
+// that aren't valid field names, such as ${AWS::Region}, are looked up
+// with index.  As with Fn::Sub, ${!Literal} renders as ${Literal}.  Any
+// other action may be used within the delimiters, eg. ${.Name | printf "%q"}.
+func DollarDelims() Opt {
+	return func(c *config) {
+		c.dollarDelims = true
+	}
+}
+
+// rewriteDollarPlaceholders converts the bare variable names supported by
+// DollarDelims into template actions.
+func rewriteDollarPlaceholders(text string) string {
+	return dollarPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		m := dollarPlaceholder.FindStringSubmatch(match)
+		name := m[2]
+		switch {
+		case m[1] == "!":
+			return "${" + strconv.Quote("${"+name+"}") + "}"
+		case fieldPath.MatchString(name):
+			return "${." + name + "}"
+		default:
+			return "${index . " + strconv.Quote(name) + "}"
+		}
+	})
+}
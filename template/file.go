@@ -0,0 +1,56 @@
+package template
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
+)
+
+// WithOutputDir sets the directory NewFileAsset writes rendered files to.
+// It defaults to a "pulutil-template" directory within os.TempDir().
+func WithOutputDir(dir string) Opt {
+	return func(c *config) {
+		c.outputDir = dir
+	}
+}
+
+// NewFileAsset renders a template to a file, returning a FileAsset
+// referencing it along with the hex encoded SHA256 hash of its content.
+// This keeps large rendered artifacts, such as bundled configuration or
+// seed data, out of resource inputs and state; the hash can be used to
+// trigger replacements or as an object's source hash.
+//
+// Files are named after their hash, so the asset's path is stable for
+// identical content.  NewFileAsset will panic if the template fails to
+// compile or execute; errors writing the file are returned through the
+// outputs' error mechanism.
+func NewFileAsset(vars map[string]interface{}, templateText string, opts ...Opt) (pulumi.AssetOutput, pulumi.StringOutput) {
+	cfg := newConfig(opts)
+	dir := cfg.outputDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "pulutil-template")
+	}
+
+	written := renderTemplate(context.Background(), vars, templateText, nil, opts).ApplyT(
+		func(rendered string) (string, error) {
+			sum := sha256.Sum256([]byte(rendered))
+			hash := hex.EncodeToString(sum[:])
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return "", fmt.Errorf("failed to create template output directory: %w", err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, hash), []byte(rendered), 0o644); err != nil {
+				return "", fmt.Errorf("failed to write rendered template: %w", err)
+			}
+			return hash, nil
+		}).(pulumi.StringOutput)
+
+	asset := written.ApplyT(func(hash string) pulumi.Asset {
+		return pulumi.NewFileAsset(filepath.Join(dir, hash))
+	}).(pulumi.AssetOutput)
+	return asset, written
+}
@@ -0,0 +1,89 @@
+package template
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	tpl "text/template"
+)
+
+// defaultFuncs holds the helpers enabled by WithDefaultFuncs.  Names and
+// argument order match their sprig equivalents, so templates continue to
+// work if WithSprig is used instead.
+var defaultFuncs = tpl.FuncMap{
+	"toJson":       toJSON,
+	"toPrettyJson": toPrettyJSON,
+	"b64enc":       b64enc,
+	"b64dec":       b64dec,
+	"indent":       indent,
+	"nindent":      nindent,
+	"quote":        quote,
+	"squote":       squote,
+}
+
+// WithDefaultFuncs makes a small set of commonly needed helper functions
+// available to the template:
+//
+//	toJson        JSON encode a value, eg. {{ .Tags | toJson }}
+//	toPrettyJson  JSON encode a value with indentation
+//	b64enc        base64 encode a string
+//	b64dec        base64 decode a string
+//	indent        indent every line of a string, eg. {{ .Script | indent 4 }}
+//	nindent       as indent, but preceded by a newline
+//	quote         double quote and escape a string
+//	squote        single quote a string
+//
+// Functions registered by a later WithFuncs or WithSprig option take
+// precedence.
+func WithDefaultFuncs() Opt {
+	return WithFuncs(defaultFuncs)
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func toPrettyJSON(v interface{}) (string, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	return string(b), err
+}
+
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func b64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	return string(b), err
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func nindent(spaces int, s string) string {
+	return "\n" + indent(spaces, s)
+}
+
+func quote(v ...interface{}) string {
+	out := make([]string, 0, len(v))
+	for _, s := range v {
+		if s != nil {
+			out = append(out, fmt.Sprintf("%q", fmt.Sprint(s)))
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func squote(v ...interface{}) string {
+	out := make([]string, 0, len(v))
+	for _, s := range v {
+		if s != nil {
+			out = append(out, fmt.Sprintf("'%v'", s))
+		}
+	}
+	return strings.Join(out, " ")
+}
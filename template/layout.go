@@ -0,0 +1,58 @@
+package template
+
+import (
+	"fmt"
+	tpl "text/template"
+)
+
+// WithBase supplies a base layout that's rendered in place of the template
+// text passed to New, which instead overrides blocks declared by the base
+// with {{define}}, eg.
+//
+//	base := `listen {{.port}};
+//	{{block "tls" .}}ssl off;{{end}}`
+//	prod := template.New(vars, `{{define "tls"}}ssl on;{{end}}`, template.WithBase(base))
+//
+// Any other content in the overriding text is ignored.
+func WithBase(text string) Opt {
+	return func(c *config) {
+		c.base = &text
+	}
+}
+
+// WithOverrides supplies templates that override blocks of the template
+// being rendered, using {{define}}.  Overrides are parsed after the
+// template, any base supplied by WithBase and any partials, in the order
+// they're supplied, so the last definition of a block wins.  They may also
+// be supplied to NewSet.
+func WithOverrides(texts ...string) Opt {
+	return func(c *config) {
+		c.overrides = append(c.overrides, texts...)
+	}
+}
+
+// parseLayout parses text into a new template, first parsing any base
+// layout to be executed in its place.
+func (cfg config) parseLayout(text string) (*tpl.Template, error) {
+	t := cfg.newTemplate("tpl")
+	if cfg.base != nil {
+		if _, err := t.Parse(cfg.templateText(*cfg.base)); err != nil {
+			return nil, fmt.Errorf("%w: base: %v", ErrCompileError, err)
+		}
+		t = t.New("main")
+	}
+	if _, err := t.Parse(cfg.templateText(text)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCompileError, err)
+	}
+	return t.Lookup("tpl"), nil
+}
+
+// addOverrides parses any registered overrides into t.
+func (cfg config) addOverrides(t *tpl.Template) error {
+	for i, text := range cfg.overrides {
+		if _, err := t.New(fmt.Sprintf("override-%d", i)).Parse(cfg.templateText(text)); err != nil {
+			return fmt.Errorf("%w: override %d: %v", ErrCompileError, i, err)
+		}
+	}
+	return nil
+}
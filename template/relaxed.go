@@ -0,0 +1,78 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RelaxedJSON allows the rendered template to contain comments and
+// trailing commas, as permitted by HuJSON and JSON5, which are removed to
+// produce strict JSON before it's validated.  Templates that emit list
+// items with {{range}} can then end every item with a comma.
+//
+// Comments may be written as // line comments or /* block comments */.
+// Other JSON5 extensions, such as unquoted keys, are not supported.
+func RelaxedJSON() Opt {
+	return func(c *config) {
+		c.relaxedJSON = true
+	}
+}
+
+// standardizeJSON removes comments and trailing commas from result.
+// Other content is passed through unchanged, to be validated later.
+func standardizeJSON(result string) (string, error) {
+	out := make([]byte, 0, len(result))
+	lastComma := -1 // index in out of a comma that may be trailing
+	for i := 0; i < len(result); i++ {
+		c := result[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(result) && result[end] != '"' {
+				if result[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(result) {
+				return "", fmt.Errorf("%w: unterminated string at byte %d", ErrInvalidJSON, i)
+			}
+			out = append(out, result[i:end+1]...)
+			i = end
+			lastComma = -1
+
+		case c == '/' && strings.HasPrefix(result[i:], "//"):
+			end := strings.IndexByte(result[i:], '\n')
+			if end < 0 {
+				end = len(result) - i
+			}
+			i += end - 1
+
+		case c == '/' && strings.HasPrefix(result[i:], "/*"):
+			end := strings.Index(result[i+2:], "*/")
+			if end < 0 {
+				return "", fmt.Errorf("%w: unterminated comment at byte %d", ErrInvalidJSON, i)
+			}
+			i += end + 3
+
+		case c == ',':
+			lastComma = len(out)
+			out = append(out, c)
+
+		case c == '}' || c == ']':
+			if lastComma >= 0 {
+				out = append(out[:lastComma], out[lastComma+1:]...)
+			}
+			out = append(out, c)
+			lastComma = -1
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			out = append(out, c)
+
+		default:
+			out = append(out, c)
+			lastComma = -1
+		}
+	}
+	return string(out), nil
+}
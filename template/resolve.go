@@ -0,0 +1,140 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
+)
+
+var (
+	inputType       = reflect.TypeOf((*pulumi.Input)(nil)).Elem()
+	stringInputType = reflect.TypeOf((*pulumi.StringInput)(nil)).Elem()
+)
+
+// deepInputs prepares a template variable for resolution by pulumi.All,
+// which resolves outputs held within maps and slices, but not those held
+// in the fields of structs.
+//
+// Any struct (or pointer to a struct) that contains an input at any depth
+// is converted to a map of its exported fields, so that its outputs are
+// resolved too; the template can still access them as {{.Var.Field}}.
+// Unset outputs, which would otherwise never resolve, are converted to nil.
+// Values that contain no inputs are returned unchanged.
+func deepInputs(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if !containsInput(rv, 0) {
+		return v
+	}
+	return convertValue(rv, 0)
+}
+
+// maxDepth bounds recursion through self-referencing structures.
+const maxDepth = 32
+
+func containsInput(v reflect.Value, depth int) bool {
+	if !v.IsValid() || depth > maxDepth || isNil(v) {
+		return false
+	}
+	if v.Type().Implements(inputType) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return !v.IsNil() && containsInput(v.Elem(), depth+1)
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if containsInput(iter.Value(), depth+1) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if containsInput(v.Index(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" && containsInput(v.Field(i), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func convertValue(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() || isNil(v) {
+		return nil
+	}
+	if v.Type().Implements(inputType) {
+		if v.IsZero() {
+			// an unset output would never resolve
+			return nil
+		}
+		return v.Interface()
+	}
+	if !containsInput(v, depth) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return convertValue(v.Elem(), depth+1)
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = convertValue(iter.Value(), depth+1)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if arr, ok := stringArray(v); ok {
+			return arr
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = convertValue(v.Index(i), depth+1)
+		}
+		return out
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.PkgPath == "" {
+				out[f.Name] = convertValue(v.Field(i), depth+1)
+			}
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// stringArray converts a slice of StringInputs, such as a
+// []pulumi.StringOutput, to a StringArray so that it's supplied to the
+// template as a []string.
+func stringArray(v reflect.Value) (pulumi.StringArray, bool) {
+	if !v.Type().Elem().Implements(stringInputType) {
+		return nil, false
+	}
+	arr := make(pulumi.StringArray, v.Len())
+	for i := range arr {
+		el := v.Index(i)
+		if isNil(el) || el.IsZero() {
+			return nil, false
+		}
+		arr[i] = el.Interface().(pulumi.StringInput)
+	}
+	return arr, true
+}
@@ -0,0 +1,81 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	tpl "text/template"
+
+	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
+)
+
+// Set holds a collection of templates that may reference each other, such
+// as shared header and footer fragments defined with {{define}} blocks,
+// any of which can be executed by name.
+type Set struct {
+	t   *tpl.Template
+	cfg config
+}
+
+// NewSet parses a set of templates, keyed by name.  Each template may also
+// contain {{define "name"}} blocks, which are added to the set.  Templates
+// are parsed in order of their name, so that a later definition of a block
+// reliably replaces an earlier one.
+//
+// NewSet will panic if any of the templates fail to compile.
+func NewSet(templates map[string]string, opts ...Opt) *Set {
+	s, err := TryNewSet(templates, opts...)
+	if err != nil {
+		newConfig(opts).fail(err)
+	}
+	return s
+}
+
+// TryNewSet is equivalent to NewSet, but returns an error if any template
+// fails to compile rather than panicking.
+func TryNewSet(templates map[string]string, opts ...Opt) (*Set, error) {
+	cfg := newConfig(opts)
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	root := cfg.newTemplate("")
+	for _, name := range names {
+		if _, err := root.New(name).Parse(cfg.templateText(templates[name])); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCompileError, err)
+		}
+	}
+	if err := cfg.addPartials(root); err != nil {
+		return nil, err
+	}
+	if err := cfg.addOverrides(root); err != nil {
+		return nil, err
+	}
+	return &Set{t: root, cfg: cfg}, nil
+}
+
+// Execute renders the named template with vars, once they become
+// available.  vars is handled in the same way as by New.
+func (s *Set) Execute(name string, vars map[string]interface{}) pulumi.StringOutput {
+	return s.execute(name, vars, nil)
+}
+
+// ExecuteJSON renders the named template as with Execute, but will panic
+// if it does not parse as valid JSON.
+func (s *Set) ExecuteJSON(name string, vars map[string]interface{}) pulumi.StringOutput {
+	return s.execute(name, vars, validator(validateJSON))
+}
+
+func (s *Set) execute(name string, vars map[string]interface{}, process postProcessor) pulumi.StringOutput {
+	if s == nil {
+		// NewSet failed to compile and the error was passed to a handler.
+		return pulumi.String("").ToStringOutput()
+	}
+	if s.t.Lookup(name) == nil {
+		return pulumi.String(s.cfg.fail(fmt.Errorf("%w: no template named %q in set", ErrExecuteError, name))).ToStringOutput()
+	}
+	r := &renderer{t: s.t, name: name, process: s.cfg.processor(process), cfg: s.cfg}
+	return r.render(context.Background(), vars)
+}
@@ -0,0 +1,40 @@
+package template
+
+import (
+	"context"
+
+	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
+)
+
+// Sprintf renders templateText with args supplied as a slice, once any
+// outputs among them have resolved, for one-off interpolations where
+// building a vars map would be overkill.  Arguments are referenced by
+// position, eg.
+//
+//	template.Sprintf(`{{index . 0}}:{{index . 1 | printf "%05d"}}`, host.PublicIp, port)
+//
+// Sprintf will panic if the template fails to compile or execute.
+func Sprintf(templateText string, args ...interface{}) pulumi.StringOutput {
+	return SprintfWithOpts(templateText, nil, args...)
+}
+
+// SprintfWithOpts is equivalent to Sprintf, but accepts options such as
+// WithFuncs or WithErrorHandler.
+func SprintfWithOpts(templateText string, opts []Opt, args ...interface{}) pulumi.StringOutput {
+	cfg := newConfig(opts)
+	r, err := compileTemplate(templateText, nil, cfg)
+	if err != nil {
+		return pulumi.String(cfg.fail(err)).ToStringOutput()
+	}
+	if args == nil {
+		args = []interface{}{}
+	}
+	vars := map[string]interface{}{"args": args}
+	return r.apply(context.Background(), vars, func(finalVars map[string]interface{}) (string, error) {
+		result, err := r.executeData(finalVars["args"])
+		if err != nil {
+			return cfg.fail(err), nil
+		}
+		return result, nil
+	})
+}
@@ -9,46 +9,246 @@
 package template
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"strings"
-	"sync"
 	tpl "text/template"
 
+	"github.com/BurntSushi/toml"
+	"github.com/Masterminds/sprig/v3"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
 )
 
+// Errors raised via panic may instead be passed to a handler registered
+// with the WithErrorHandler option.
 var (
 	// ErrCompileError is raised via panic if the template generates an
-	// error during the compile process.
+	// error during the compile process, or returned by TryNew and
+	// TryNewJSON.
 	ErrCompileError = errors.New("template compile error")
 
 	// ErrExecuteError is raised during panic if template generates an error
 	// while it is being executed (eg. due to an incorrectly supplied argument)
+	// For TryNew and TryNewJSON it's returned as the output's error instead.
 	ErrExecuteError = errors.New("template execution error")
 
 	// ErrInvalidJSON is raised during panic if the output from the template
 	// does not validate as JSON.
 	ErrInvalidJSON = errors.New("template produced invalid JSON")
+
+	// ErrInvalidTOML is raised during panic if the output from the template
+	// does not validate as TOML.
+	ErrInvalidTOML = errors.New("template produced invalid TOML")
+
+	// ErrInvalidXML is raised during panic if the output from the template
+	// is not well-formed XML.
+	ErrInvalidXML = errors.New("template produced invalid XML")
+
+	// ErrInvalidHCL is raised during panic if the output from the template
+	// does not parse as HCL.
+	ErrInvalidHCL = errors.New("template produced invalid HCL")
+
+	// ErrMissingVariable is raised during panic if a variable declared by
+	// the Required option is not supplied to the template.
+	ErrMissingVariable = errors.New("required template variable missing")
 )
 
+// Opt is implemented by functions that can be passed to New and NewJSON
+// to modify how the template is compiled or rendered.
+type Opt func(*config)
+
+type config struct {
+	funcs         tpl.FuncMap
+	normalizeJSON bool
+	jsonIndent    string
+	partials      []partial
+	missingKeyErr bool
+	required      []string
+	secret        bool
+	cloudConfig   bool
+	noGzip        bool
+	onError       func(error)
+	validators    []Validator
+	outputDir     string
+	pulumiCtx     *pulumi.Context
+	debugLog      func(msg string)
+	maxSize       int
+	dedent        bool
+	base          *string
+	overrides     []string
+	relaxedJSON   bool
+	dollarDelims  bool
+}
+
+// fail reports err to the configured error handler, or panics if there
+// isn't one.  It returns the value the rendered template resolves to.
+func (cfg config) fail(err error) string {
+	if cfg.onError == nil {
+		panic(err)
+	}
+	cfg.onError(err)
+	return ""
+}
+
+// partial holds either the text of a single named partial, or a set of
+// files to be loaded from fsys.
+type partial struct {
+	name     string
+	text     string
+	fsys     fs.FS
+	patterns []string
+}
+
+// WithFuncs registers additional functions that can be called by the
+// template, as with text/template's Template.Funcs.  It may be supplied
+// more than once; later definitions of a name replace earlier ones.
+func WithFuncs(funcs tpl.FuncMap) Opt {
+	return func(c *config) {
+		if c.funcs == nil {
+			c.funcs = make(tpl.FuncMap)
+		}
+		for name, f := range funcs {
+			c.funcs[name] = f
+		}
+	}
+}
+
+// WithSprig makes the sprig function library available to the template,
+// providing helpers such as default, b64enc, trim and toJson.  See
+// https://masterminds.github.io/sprig/ for the full list.
+//
+// Functions registered by a later WithFuncs option take precedence.
+func WithSprig() Opt {
+	return WithFuncs(sprig.TxtFuncMap())
+}
+
+// WithPartial registers a partial template that may be included by the
+// main template using {{template "name" .}}.
+func WithPartial(name, text string) Opt {
+	return func(c *config) {
+		c.partials = append(c.partials, partial{name: name, text: text})
+	}
+}
+
+// WithPartialsFS registers each file in fsys matching patterns as a
+// partial template, named by the file's base name, as with
+// text/template's ParseFS.  eg. a file "partials/header.tpl" can be
+// included using {{template "header.tpl" .}}.
+func WithPartialsFS(fsys fs.FS, patterns ...string) Opt {
+	return func(c *config) {
+		c.partials = append(c.partials, partial{fsys: fsys, patterns: patterns})
+	}
+}
+
+// MissingKeyError causes execution of the template to fail with
+// ErrExecuteError if it references a variable that was not supplied,
+// rather than rendering it as "<no value>".
+func MissingKeyError() Opt {
+	return func(c *config) {
+		c.missingKeyErr = true
+	}
+}
+
+// Required declares variables that must be present in the vars map
+// supplied to the template.  If any are absent, New will panic with
+// ErrMissingVariable before waiting for any outputs to resolve, and TryNew
+// will return the error.
+func Required(names ...string) Opt {
+	return func(c *config) {
+		c.required = append(c.required, names...)
+	}
+}
+
+// WithErrorHandler causes errors that would otherwise cause New and its
+// variants to panic to be passed to handler instead, in which case the
+// rendered template resolves to an empty string.  handler may be called
+// from a different goroutine once the template's variables have resolved.
+func WithErrorHandler(handler func(error)) Opt {
+	return func(c *config) {
+		c.onError = handler
+	}
+}
+
+// Validator checks the rendered output of a template, returning an error
+// if it isn't acceptable.
+type Validator func(rendered string) error
+
+// Validators for the formats supported by the New variants, which may be
+// combined with WithValidator, eg. to check the output of a template set.
 var (
-	noPanic           bool
-	m                 sync.Mutex
-	testTemplateError error
+	ValidJSON Validator = validateJSON
+	ValidTOML Validator = validateTOML
+	ValidXML  Validator = validateXML
+	ValidHCL  Validator = validateHCL
 )
 
-func templateError(msg string, args ...interface{}) string {
-	err := fmt.Errorf(msg, args...)
-	if noPanic {
-		m.Lock()
-		testTemplateError = err
-		m.Unlock()
-	} else {
-		panic(err)
+// ErrValidation is raised during panic if a validator supplied by the
+// WithValidator option rejects the rendered template.  The validator's own
+// error is available via errors.Unwrap.
+var ErrValidation = errors.New("template failed validation")
+
+// ValidationError wraps an error returned by a Validator.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrValidation, e.Err)
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Unwrap returns the validator's error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// WithValidator adds a check, such as a schema or linter, that the
+// rendered template must pass before it's returned.  Validators run in the
+// order they're supplied, before any format specific processing such as
+// the compression performed by UserData.
+func WithValidator(v Validator) Opt {
+	return func(c *config) {
+		c.validators = append(c.validators, v)
+	}
+}
+
+// AsSecret marks the rendered template as a secret, regardless of whether
+// any of the variables supplied to it are secret.
+//
+// Without this option the rendered template is secret only if at least one
+// of the supplied outputs is secret, such as a generated password, in which
+// case its secretness is always propagated.
+func AsSecret() Opt {
+	return func(c *config) {
+		c.secret = true
+	}
+}
+
+// NormalizeJSON causes the rendered template to be parsed as JSON and
+// re-marshaled with sorted keys, so that the output is stable regardless of
+// whitespace or key ordering within the template and previews only show
+// real changes.  Output is indented using indent, or compacted if indent is
+// empty.
+//
+// It's intended for use with NewJSON; when supplied to another variant the
+// rendered template must still be valid JSON.
+func NormalizeJSON(indent string) Opt {
+	return func(c *config) {
+		c.normalizeJSON = true
+		c.jsonIndent = indent
 	}
-	return err.Error()
 }
 
 // New compiles a Go text/template and provides the specified variables
@@ -59,51 +259,437 @@ func templateError(msg string, args ...interface{}) string {
 //
 // vars specifies a map of values to pass as data to the template; this may
 // include any mix of regular values, or Pulumi outputs, which will have their
-// values resolved before being supplied to the template.
-func New(vars map[string]interface{}, templateText string) pulumi.StringOutput {
-	return renderTemplate(vars, templateText, false)
+// values resolved before being supplied to the template.  Outputs may be
+// nested at any depth within maps, slices and structs; structs containing
+// outputs are supplied to the template as maps of their exported fields.
+// Slices of string outputs, such as []pulumi.StringOutput, and
+// StringArrayOutputs are both supplied as a []string, so may be iterated
+// with {{range}}.
+func New(vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	return renderTemplate(context.Background(), vars, templateText, nil, opts)
 }
 
 // NewJSON wraps Template, but will panic if the rendered template does not
 // parse as valid JSON.
-func NewJSON(vars map[string]interface{}, templateText string) pulumi.StringOutput {
-	return renderTemplate(vars, templateText, true)
+func NewJSON(vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	return renderTemplate(context.Background(), vars, templateText, validator(validateJSON), opts)
+}
+
+// NewWithContext is equivalent to New, but uses ctx when waiting for the
+// supplied variables to resolve.
+func NewWithContext(ctx context.Context, vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	return renderTemplate(ctx, vars, templateText, nil, opts)
 }
 
-func renderTemplate(vars map[string]interface{}, templateText string, validateJSON bool) pulumi.StringOutput {
-	tpl, err := tpl.New("tpl").Parse(templateText)
+// NewJSONWithContext is equivalent to NewJSON, but uses ctx when waiting
+// for the supplied variables to resolve.
+func NewJSONWithContext(ctx context.Context, vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	return renderTemplate(ctx, vars, templateText, validator(validateJSON), opts)
+}
+
+// NewJSONMap wraps NewJSON, but returns the rendered JSON parsed into a
+// MapOutput, so the result can be supplied to resource arguments that take
+// structured values.  It will panic if the rendered template is not a JSON
+// object.
+func NewJSONMap(vars map[string]interface{}, templateText string, opts ...Opt) pulumi.MapOutput {
+	return renderTemplate(context.Background(), vars, templateText, validator(validateJSONObject), opts).ApplyT(
+		func(result string) (map[string]interface{}, error) {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(result), &m); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+			}
+			return m, nil
+		}).(pulumi.MapOutput)
+}
+
+// NewTOML wraps Template, but will panic if the rendered template does not
+// parse as valid TOML.
+func NewTOML(vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	return renderTemplate(context.Background(), vars, templateText, validator(validateTOML), opts)
+}
+
+// NewXML wraps Template, but will panic if the rendered template is not a
+// well-formed XML document with a single root element.
+func NewXML(vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	return renderTemplate(context.Background(), vars, templateText, validator(validateXML), opts)
+}
+
+// NewHCL wraps Template, but will panic if the rendered template does not
+// parse as HCL, such as a Nomad job, Consul configuration or Vault policy.
+//
+// Only the syntax is checked; the rendered configuration isn't validated
+// against any particular schema.
+func NewHCL(vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	return renderTemplate(context.Background(), vars, templateText, validator(validateHCL), opts)
+}
+
+// NewJSONMinified wraps NewJSON, but re-emits the validated JSON with
+// insignificant whitespace removed, so that indentation within the template
+// doesn't count towards the size limits imposed by services on documents
+// such as policies.
+func NewJSONMinified(vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	return renderTemplate(context.Background(), vars, templateText, minifyJSON, opts)
+}
+
+// TryNew is equivalent to New, but returns an error if the template fails
+// to compile rather than panicking.  Errors that occur while the template
+// is executed are returned through the output's error mechanism, causing
+// any resource or export that depends on it to fail.
+func TryNew(vars map[string]interface{}, templateText string, opts ...Opt) (pulumi.StringOutput, error) {
+	return tryRenderTemplate(context.Background(), vars, templateText, nil, opts)
+}
+
+// TryNewJSON is equivalent to NewJSON, but returns an error if the
+// template fails to compile rather than panicking.  If the rendered
+// template isn't valid JSON then the output will resolve to an error
+// wrapping ErrInvalidJSON.
+func TryNewJSON(vars map[string]interface{}, templateText string, opts ...Opt) (pulumi.StringOutput, error) {
+	return tryRenderTemplate(context.Background(), vars, templateText, validator(validateJSON), opts)
+}
+
+func renderTemplate(ctx context.Context, vars map[string]interface{}, templateText string, process postProcessor, opts []Opt) pulumi.StringOutput {
+	cfg := newConfig(opts)
+	r, err := compileTemplate(templateText, process, cfg)
+	if err != nil {
+		return pulumi.String(cfg.fail(err)).ToStringOutput()
+	}
+	return r.render(ctx, vars)
+}
+
+func tryRenderTemplate(ctx context.Context, vars map[string]interface{}, templateText string, process postProcessor, opts []Opt) (pulumi.StringOutput, error) {
+	r, err := compileTemplate(templateText, process, newConfig(opts))
 	if err != nil {
-		return pulumi.String(templateError("%w: %v", ErrCompileError, err)).ToStringOutput()
+		return pulumi.StringOutput{}, err
+	}
+	if err := r.checkRequired(vars); err != nil {
+		return pulumi.StringOutput{}, err
+	}
+	return r.apply(ctx, vars, r.execute), nil
+}
+
+// renderer holds a compiled template along with the processing to apply
+// to its output.
+type renderer struct {
+	t       *tpl.Template
+	name    string
+	process postProcessor
+	cfg     config
+}
+
+// render executes the template once vars have resolved, passing any
+// errors to cfg.fail.
+func (r *renderer) render(ctx context.Context, vars map[string]interface{}) pulumi.StringOutput {
+	if err := r.checkRequired(vars); err != nil {
+		return pulumi.String(r.cfg.fail(err)).ToStringOutput()
+	}
+	return r.apply(ctx, vars, func(finalVars map[string]interface{}) (string, error) {
+		result, err := r.execute(finalVars)
+		if err != nil {
+			return r.cfg.fail(err), nil
+		}
+		return result, nil
+	})
+}
+
+// apply calls f with vars once they have resolved, marking the result as
+// secret if required.
+func (r *renderer) apply(ctx context.Context, vars map[string]interface{}, f func(map[string]interface{}) (string, error)) pulumi.StringOutput {
+	vars = r.cfg.contextVars(vars)
+	if r.cfg.debugLog != nil {
+		vars, f = r.debug(vars, f)
+	}
+	out := applyVars(ctx, vars, f)
+	if r.cfg.secret {
+		return pulumi.ToSecretWithContext(ctx, out).(pulumi.StringOutput)
+	}
+	return out
+}
+
+// checkRequired verifies that every required variable is present in vars.
+func (r *renderer) checkRequired(vars map[string]interface{}) error {
+	var missing []string
+	for _, name := range r.cfg.required {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingVariable, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func newConfig(opts []Opt) config {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// processor returns process combined with any processing required by
+// the config.
+func (cfg config) processor(process postProcessor) postProcessor {
+	processors := make([]postProcessor, 0, len(cfg.validators)+4)
+	if cfg.relaxedJSON {
+		processors = append(processors, standardizeJSON)
+	}
+	for _, v := range cfg.validators {
+		v := v
+		processors = append(processors, validator(func(result string) error {
+			if err := v(result); err != nil {
+				return &ValidationError{Err: err}
+			}
+			return nil
+		}))
+	}
+	processors = append(processors, process)
+	if cfg.normalizeJSON {
+		processors = append(processors, normalizeJSON(cfg.jsonIndent))
+	}
+	if cfg.maxSize > 0 {
+		processors = append(processors, checkSize(cfg.maxSize))
+	}
+	return chain(processors...)
+}
+
+// newTemplate creates an empty template with the config's functions and
+// options applied.
+func (cfg config) newTemplate(name string) *tpl.Template {
+	t := tpl.New(name).Funcs(cfg.funcs)
+	if cfg.dollarDelims {
+		t.Delims("${", "}")
+	}
+	if cfg.missingKeyErr {
+		t.Option("missingkey=error")
+	}
+	return t
+}
+
+// templateText returns text as it should be parsed under the config.
+func (cfg config) templateText(text string) string {
+	if cfg.dedent {
+		text = Heredoc(text)
+	}
+	if cfg.dollarDelims {
+		text = rewriteDollarPlaceholders(text)
+	}
+	return text
+}
+
+// addPartials parses any registered partials into t.
+func (cfg config) addPartials(t *tpl.Template) error {
+	for _, p := range cfg.partials {
+		var err error
+		if p.fsys != nil {
+			_, err = t.ParseFS(p.fsys, p.patterns...)
+		} else {
+			_, err = t.New(p.name).Parse(cfg.templateText(p.text))
+		}
+		if err != nil {
+			return fmt.Errorf("%w: partial: %v", ErrCompileError, err)
+		}
+	}
+	return nil
+}
 
+func compileTemplate(templateText string, process postProcessor, cfg config) (*renderer, error) {
+	t, err := cfg.parseLayout(templateText)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.addPartials(t); err != nil {
+		return nil, err
 	}
+	if err := cfg.addOverrides(t); err != nil {
+		return nil, err
+	}
+	return &renderer{t: t, process: cfg.processor(process), cfg: cfg}, nil
+}
+
+// applyVars calls f with vars once all of its values have resolved.  The
+// result is secret if any of the values are secret.
+func applyVars(ctx context.Context, vars map[string]interface{}, f func(map[string]interface{}) (string, error)) pulumi.StringOutput {
 	args := make([]interface{}, 0, len(vars))
 	names := make([]string, 0, len(vars))
 	for k, v := range vars {
 		names = append(names, k)
-		args = append(args, v)
+		args = append(args, deepInputs(v))
 	}
 
-	return pulumi.All(args...).ApplyT(func(args []interface{}) string {
+	return pulumi.AllWithContext(ctx, args...).ApplyTWithContext(ctx, func(_ context.Context, args []interface{}) (string, error) {
 		finalVars := make(map[string]interface{})
 		for i, v := range args {
 			finalVars[names[i]] = v
 		}
-		var compiled strings.Builder
-		if err := tpl.Execute(&compiled, finalVars); err != nil {
-			return templateError("%w: %v", ErrExecuteError, err)
-		}
-		result := compiled.String()
-		if validateJSON {
-			var tmp interface{}
-			if err := json.Unmarshal([]byte(result), &tmp); err != nil {
-				if jerr, ok := err.(*json.SyntaxError); ok {
-					return templateError("%w: Template does not compile to valid JSON with syntax error at byte %d: %v\n%s",
-						ErrInvalidJSON, jerr.Offset, jerr, result)
-				}
-				return templateError("%w: Template does not compile to valid JSON: %v\n%s",
-					ErrInvalidJSON, err, result)
+		return f(finalVars)
+	}).(pulumi.StringOutput)
+}
+
+// postProcessor validates, and optionally transforms, the output of a
+// template.
+type postProcessor func(result string) (string, error)
+
+// validator converts a validation function to a postProcessor that returns
+// its input unchanged.
+func validator(validate func(string) error) postProcessor {
+	return func(result string) (string, error) {
+		if err := validate(result); err != nil {
+			return "", err
+		}
+		return result, nil
+	}
+}
+
+// chain returns a postProcessor that calls each non-nil processor in turn.
+func chain(processors ...postProcessor) postProcessor {
+	return func(result string) (string, error) {
+		var err error
+		for _, process := range processors {
+			if process == nil {
+				continue
+			}
+			if result, err = process(result); err != nil {
+				return "", err
 			}
 		}
-		return result
-	}).(pulumi.StringOutput)
+		return result, nil
+	}
+}
+
+func (r *renderer) execute(vars map[string]interface{}) (string, error) {
+	return r.executeData(vars)
+}
+
+// executeData executes the template with data, which need not be a map.
+func (r *renderer) executeData(data interface{}) (string, error) {
+	var compiled strings.Builder
+	var err error
+	if r.name != "" {
+		err = r.t.ExecuteTemplate(&compiled, r.name, data)
+	} else {
+		err = r.t.Execute(&compiled, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrExecuteError, err)
+	}
+	result := compiled.String()
+	if r.process != nil {
+		return r.process(result)
+	}
+	return result, nil
+}
+
+func validateJSON(result string) error {
+	var tmp interface{}
+	if err := json.Unmarshal([]byte(result), &tmp); err != nil {
+		if jerr, ok := err.(*json.SyntaxError); ok {
+			return fmt.Errorf("%w: Template does not compile to valid JSON with syntax error at byte %d: %v\n%s",
+				ErrInvalidJSON, jerr.Offset, jerr, result)
+		}
+		return fmt.Errorf("%w: Template does not compile to valid JSON: %v\n%s",
+			ErrInvalidJSON, err, result)
+	}
+	return nil
+}
+
+func validateJSONObject(result string) error {
+	if err := validateJSON(result); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(strings.TrimSpace(result), "{") {
+		return fmt.Errorf("%w: Template does not compile to a JSON object\n%s", ErrInvalidJSON, result)
+	}
+	return nil
+}
+
+func minifyJSON(result string) (string, error) {
+	if err := validateJSON(result); err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := json.Compact(&out, []byte(result)); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+	return out.String(), nil
+}
+
+func normalizeJSON(indent string) postProcessor {
+	return func(result string) (string, error) {
+		if err := validateJSON(result); err != nil {
+			return "", err
+		}
+		dec := json.NewDecoder(strings.NewReader(result))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+		}
+		var out bytes.Buffer
+		enc := json.NewEncoder(&out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", indent)
+		if err := enc.Encode(v); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+		}
+		return strings.TrimSuffix(out.String(), "\n"), nil
+	}
+}
+
+func validateTOML(result string) error {
+	var tmp map[string]interface{}
+	if _, err := toml.Decode(result, &tmp); err != nil {
+		return fmt.Errorf("%w: Template does not compile to valid TOML: %v\n%s",
+			ErrInvalidTOML, err, result)
+	}
+	return nil
+}
+
+func validateXML(result string) error {
+	dec := xml.NewDecoder(strings.NewReader(result))
+	var depth, roots int
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: Template does not compile to valid XML at line %d: %v\n%s",
+				ErrInvalidXML, lineOf(dec.InputOffset(), result), err, result)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(tok)) > 0 {
+				return fmt.Errorf("%w: Template contains text outside of the root element\n%s", ErrInvalidXML, result)
+			}
+		}
+	}
+	if roots != 1 {
+		return fmt.Errorf("%w: Template must contain exactly one root element, found %d\n%s",
+			ErrInvalidXML, roots, result)
+	}
+	return nil
+}
+
+func validateHCL(result string) error {
+	_, diags := hclsyntax.ParseConfig([]byte(result), "template.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("%w: Template does not compile to valid HCL: %v\n%s",
+			ErrInvalidHCL, diags, result)
+	}
+	return nil
+}
+
+func lineOf(offset int64, s string) int {
+	if offset > int64(len(s)) {
+		offset = int64(len(s))
+	}
+	return strings.Count(s[:offset], "\n") + 1
 }
@@ -1,17 +1,41 @@
 package template
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
 	"errors"
+	"io"
+	"os"
+	"strings"
 	"sync"
 	"testing"
+	"testing/fstest"
+	tpl "text/template"
 
 	"github.com/pulumi/pulumi/sdk/v2/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
 	"github.com/tj/assert"
 )
 
-func init() {
-	noPanic = true
+// errorRecorder records the last error passed to its handle method, for
+// use with WithErrorHandler.
+type errorRecorder struct {
+	m   sync.Mutex
+	err error
+}
+
+func (r *errorRecorder) handle(err error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.err = err
+}
+
+func (r *errorRecorder) get() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.err
 }
 
 type mocks int
@@ -28,6 +52,11 @@ type tplTest struct {
 	testName       string
 	tplText        string
 	asJSON         bool
+	asTOML         bool
+	asXML          bool
+	asHCL          bool
+	minified       bool
+	opts           []Opt
 	expectedError  error
 	expectedResult string
 }
@@ -44,20 +73,41 @@ func trap(err chan error, f func()) {
 }
 
 func (tt *tplTest) run(t *testing.T) {
-	testTemplateError = nil
+	var rec errorRecorder
+	opts := append([]Opt{WithErrorHandler(rec.handle)}, tt.opts...)
 	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
 		var wg sync.WaitGroup
 		var tpl pulumi.StringOutput
-		if tt.asJSON {
+		if tt.minified {
+			tpl = NewJSONMinified(map[string]interface{}{
+				"StringOut":    pulumi.String("ok!").ToStringOutput(),
+				"NormalString": "normal",
+			}, tt.tplText, opts...)
+		} else if tt.asHCL {
+			tpl = NewHCL(map[string]interface{}{
+				"StringOut":    pulumi.String("ok!").ToStringOutput(),
+				"NormalString": "normal",
+			}, tt.tplText, opts...)
+		} else if tt.asXML {
+			tpl = NewXML(map[string]interface{}{
+				"StringOut":    pulumi.String("ok!").ToStringOutput(),
+				"NormalString": "normal",
+			}, tt.tplText, opts...)
+		} else if tt.asTOML {
+			tpl = NewTOML(map[string]interface{}{
+				"StringOut":    pulumi.String("ok!").ToStringOutput(),
+				"NormalString": "normal",
+			}, tt.tplText, opts...)
+		} else if tt.asJSON {
 			tpl = NewJSON(map[string]interface{}{
 				"StringOut":    pulumi.String("ok!").ToStringOutput(),
 				"NormalString": "normal",
-			}, tt.tplText)
+			}, tt.tplText, opts...)
 		} else {
 			tpl = New(map[string]interface{}{
 				"StringOut":    pulumi.String("ok!").ToStringOutput(),
 				"NormalString": "normal",
-			}, tt.tplText)
+			}, tt.tplText, opts...)
 		}
 
 		wg.Add(1)
@@ -74,16 +124,19 @@ func (tt *tplTest) run(t *testing.T) {
 	}, pulumi.WithMocks("project", "stack", mocks(0)))
 	assert.NoError(t, err)
 
+	templateErr := rec.get()
 	if tt.expectedError == nil {
-		if testTemplateError != nil {
-			assert.Fail(t, "unexpected error", "[%s] Unexpected error: %v", tt.testName, testTemplateError)
+		if templateErr != nil {
+			assert.Fail(t, "unexpected error", "[%s] Unexpected error: %v", tt.testName, templateErr)
 		}
-	} else if !errors.Is(testTemplateError, tt.expectedError) {
+	} else if !errors.Is(templateErr, tt.expectedError) {
 		assert.Fail(t, "incorrect error", "[%s] Expected error %q, got %q",
-			tt.testName, tt.expectedError, testTemplateError)
+			tt.testName, tt.expectedError, templateErr)
 	}
 }
 
+var errNoTLS = errors.New("tls not configured")
+
 var tests = []tplTest{
 	{
 		testName:       "simple-ok",
@@ -111,6 +164,275 @@ var tests = []tplTest{
 		tplText:       `result: {{.StringOut}}`,
 		expectedError: ErrInvalidJSON,
 	},
+	{
+		testName:       "json-minified",
+		minified:       true,
+		tplText:        "{\n    \"field\": \"{{.StringOut}}\",\n    \"list\": [ 1, 2 ]\n}\n",
+		expectedResult: `{"field":"ok!","list":[1,2]}`,
+	},
+	{
+		testName:      "json-minified-invalid",
+		minified:      true,
+		tplText:       `{"field": {{.StringOut}}}`,
+		expectedError: ErrInvalidJSON,
+	},
+	{
+		testName:       "json-normalized-compact",
+		asJSON:         true,
+		opts:           []Opt{NormalizeJSON("")},
+		tplText:        "{\n  \"z\": \"{{.StringOut}}\", \"a\": [1.50, 2],\n  \"html\": \"<b>\"\n}",
+		expectedResult: `{"a":[1.50,2],"html":"<b>","z":"ok!"}`,
+	},
+	{
+		testName:       "json-normalized-indent",
+		asJSON:         true,
+		opts:           []Opt{NormalizeJSON("  ")},
+		tplText:        `{"z": "{{.StringOut}}", "a": {"y": 1, "x": 2}}`,
+		expectedResult: "{\n  \"a\": {\n    \"x\": 2,\n    \"y\": 1\n  },\n  \"z\": \"ok!\"\n}",
+	},
+	{
+		testName:      "json-normalized-invalid",
+		opts:          []Opt{NormalizeJSON("")},
+		tplText:       `result: {{.StringOut}}`,
+		expectedError: ErrInvalidJSON,
+	},
+	{
+		testName:       "toml-ok",
+		asTOML:         true,
+		tplText:        "[server]\nname = \"{{.StringOut}}\"\nmode = \"{{.NormalString}}\"\n",
+		expectedResult: "[server]\nname = \"ok!\"\nmode = \"normal\"\n",
+	},
+	{
+		testName:      "invalid-toml",
+		asTOML:        true,
+		tplText:       `name = {{.StringOut}}`,
+		expectedError: ErrInvalidTOML,
+	},
+	{
+		testName:       "xml-ok",
+		asXML:          true,
+		tplText:        `<?xml version="1.0"?><RoutingRules><Rule id="{{.StringOut}}">{{.NormalString}}</Rule></RoutingRules>`,
+		expectedResult: `<?xml version="1.0"?><RoutingRules><Rule id="ok!">normal</Rule></RoutingRules>`,
+	},
+	{
+		testName:      "invalid-xml",
+		asXML:         true,
+		tplText:       `<a><b>{{.StringOut}}</a>`,
+		expectedError: ErrInvalidXML,
+	},
+	{
+		testName:      "xml-multiple-roots",
+		asXML:         true,
+		tplText:       `<a>{{.StringOut}}</a><b/>`,
+		expectedError: ErrInvalidXML,
+	},
+	{
+		testName:      "xml-no-root",
+		asXML:         true,
+		tplText:       `{{.StringOut}}`,
+		expectedError: ErrInvalidXML,
+	},
+	{
+		testName:       "hcl-ok",
+		asHCL:          true,
+		tplText:        "path \"secret/{{.NormalString}}\" {\n  capabilities = [\"{{.StringOut}}\"]\n}\n",
+		expectedResult: "path \"secret/normal\" {\n  capabilities = [\"ok!\"]\n}\n",
+	},
+	{
+		testName:      "invalid-hcl",
+		asHCL:         true,
+		tplText:       "job \"{{.StringOut}}\" {\n  datacenters = [\"dc1\"\n",
+		expectedError: ErrInvalidHCL,
+	},
+	{
+		testName:       "with-funcs",
+		tplText:        `result: {{upper .StringOut}} {{repeat .NormalString 2}}`,
+		opts:           []Opt{WithFuncs(tpl.FuncMap{"upper": strings.ToUpper}), WithFuncs(tpl.FuncMap{"repeat": strings.Repeat})},
+		expectedResult: `result: OK! normalnormal`,
+	},
+	{
+		testName:       "with-sprig",
+		tplText:        `result: {{.StringOut | upper | b64enc}} {{.Missing | default "dflt"}}`,
+		opts:           []Opt{WithSprig()},
+		expectedResult: `result: T0sh dflt`,
+	},
+	{
+		testName:       "funcs-override-sprig",
+		tplText:        `result: {{upper .StringOut}}`,
+		opts:           []Opt{WithSprig(), WithFuncs(tpl.FuncMap{"upper": strings.ToLower})},
+		expectedResult: `result: ok!`,
+	},
+	{
+		testName:       "with-partial",
+		tplText:        `{{template "header" .}} {{.NormalString}}`,
+		opts:           []Opt{WithPartial("header", `[{{.StringOut}}]`)},
+		expectedResult: `[ok!] normal`,
+	},
+	{
+		testName: "with-partials-fs",
+		tplText:  `{{template "a.tpl" .}}{{template "b.tpl" .}}`,
+		opts: []Opt{WithPartialsFS(fstest.MapFS{
+			"partials/a.tpl": {Data: []byte(`a={{.StringOut}} `)},
+			"partials/b.tpl": {Data: []byte(`b={{.NormalString}}`)},
+		}, "partials/*.tpl")},
+		expectedResult: `a=ok! b=normal`,
+	},
+	{
+		testName:      "invalid-partial",
+		tplText:       `{{template "header" .}}`,
+		opts:          []Opt{WithPartial("header", `{{.StringOut}`)},
+		expectedError: ErrCompileError,
+	},
+	{
+		testName:      "missing-partial",
+		tplText:       `{{template "header" .}}`,
+		expectedError: ErrExecuteError,
+	},
+	{
+		testName:       "missing-key-default",
+		tplText:        `result: {{.Missing}}`,
+		expectedResult: `result: <no value>`,
+	},
+	{
+		testName:      "missing-key-error",
+		tplText:       `result: {{.Missing}}`,
+		opts:          []Opt{MissingKeyError()},
+		expectedError: ErrExecuteError,
+	},
+	{
+		testName:       "required-ok",
+		tplText:        `result: {{.StringOut}}`,
+		opts:           []Opt{Required("StringOut", "NormalString")},
+		expectedResult: `result: ok!`,
+	},
+	{
+		testName:      "required-missing",
+		tplText:       `result: {{.StringOut}}`,
+		opts:          []Opt{Required("StringOut", "Missing")},
+		expectedError: ErrMissingVariable,
+	},
+	{
+		testName:       "default-funcs",
+		tplText:        `{{.StringOut | quote}} {{.NormalString | squote}} {{.StringOut | b64enc}} {{"b2sh" | b64dec}} {{.NormalString | toJson}}`,
+		opts:           []Opt{WithDefaultFuncs()},
+		expectedResult: `"ok!" 'normal' b2sh ok! "normal"`,
+	},
+	{
+		testName:       "default-funcs-indent",
+		tplText:        "script:{{\"a\\nb\" | nindent 2}}",
+		opts:           []Opt{WithDefaultFuncs()},
+		expectedResult: "script:\n  a\n  b",
+	},
+	{
+		testName:      "default-funcs-bad-b64",
+		tplText:       `{{"!!" | b64dec}}`,
+		opts:          []Opt{WithDefaultFuncs()},
+		expectedError: ErrExecuteError,
+	},
+	{
+		testName:       "validator-ok",
+		tplText:        `{"v": "{{.StringOut}}"}`,
+		opts:           []Opt{WithValidator(ValidJSON), WithValidator(func(s string) error { return nil })},
+		expectedResult: `{"v": "ok!"}`,
+	},
+	{
+		testName:      "validator-builtin",
+		tplText:       `v = {{.StringOut}}`,
+		opts:          []Opt{WithValidator(ValidTOML)},
+		expectedError: ErrInvalidTOML,
+	},
+	{
+		testName:      "validator-custom",
+		tplText:       `listen {{.NormalString}};`,
+		opts:          []Opt{WithValidator(func(s string) error { return errNoTLS })},
+		expectedError: errNoTLS,
+	},
+	{
+		testName:      "validator-is-validation",
+		tplText:       `listen {{.NormalString}};`,
+		opts:          []Opt{WithValidator(func(s string) error { return errNoTLS })},
+		expectedError: ErrValidation,
+	},
+	{
+		testName:       "max-size-ok",
+		tplText:        `{{.StringOut}}`,
+		opts:           []Opt{WithMaxSize(len("ok!"))},
+		expectedResult: "ok!",
+	},
+	{
+		testName:      "max-size-exceeded",
+		tplText:       `{{.StringOut}}!`,
+		opts:          []Opt{WithMaxSize(len("ok!"))},
+		expectedError: ErrOutputTooLarge,
+	},
+	{
+		testName:       "dedent",
+		tplText:        "\n\t\tname: {{.StringOut}}\n\t\tlist:\n\t\t  - {{.NormalString}}\n\t",
+		opts:           []Opt{Dedent()},
+		expectedResult: "name: ok!\nlist:\n  - normal\n",
+	},
+	{
+		testName:       "base-default-block",
+		tplText:        ``,
+		opts:           []Opt{WithBase(`name: {{.StringOut}} {{block "tls" .}}off{{end}}`)},
+		expectedResult: "name: ok! off",
+	},
+	{
+		testName:       "base-override-block",
+		tplText:        `ignored {{define "tls"}}on{{end}}`,
+		opts:           []Opt{WithBase(`name: {{.StringOut}} {{block "tls" .}}off{{end}}`)},
+		expectedResult: "name: ok! on",
+	},
+	{
+		testName: "overrides-in-order",
+		tplText:  `{{block "a" .}}a{{end}}-{{block "b" .}}b{{end}}`,
+		opts: []Opt{WithOverrides(
+			`{{define "a"}}A{{end}}{{define "b"}}B{{end}}`,
+			`{{define "b"}}{{.NormalString}}{{end}}`,
+		)},
+		expectedResult: "A-normal",
+	},
+	{
+		testName:      "base-compile-error",
+		tplText:       `{{define "tls"}}on{{end}}`,
+		opts:          []Opt{WithBase(`{{block "tls" .}}`)},
+		expectedError: ErrCompileError,
+	},
+	{
+		testName: "relaxed-json",
+		tplText: `{
+			// the items
+			"items": ["{{.StringOut}}", "{{.NormalString}}",],
+			"name": "{{.StringOut}}", /* trailing */
+		}`,
+		asJSON:         true,
+		opts:           []Opt{RelaxedJSON(), NormalizeJSON("")},
+		expectedResult: `{"items":["ok!","normal"],"name":"ok!"}`,
+	},
+	{
+		testName:      "relaxed-json-invalid",
+		tplText:       `{"name": {{.StringOut}},}`,
+		asJSON:        true,
+		opts:          []Opt{RelaxedJSON()},
+		expectedError: ErrInvalidJSON,
+	},
+	{
+		testName:       "dollar-delims",
+		tplText:        `echo ${StringOut} {{.NormalString}} ${.NormalString | printf "%q"} ${!Literal}`,
+		opts:           []Opt{DollarDelims()},
+		expectedResult: `echo ok! {{.NormalString}} "normal" ${Literal}`,
+	},
+	{
+		testName:       "dollar-delims-partial",
+		tplText:        `${template "p" .}`,
+		opts:           []Opt{DollarDelims(), WithPartial("p", "${NormalString}")},
+		expectedResult: "normal",
+	},
+	{
+		testName:      "undefined-func",
+		tplText:       `result: {{upper .StringOut}}`,
+		expectedError: ErrCompileError,
+	},
 }
 
 func TestTemplates(t *testing.T) {
@@ -118,3 +440,648 @@ func TestTemplates(t *testing.T) {
 		test.run(t)
 	}
 }
+
+func TestTryNew(t *testing.T) {
+	vars := map[string]interface{}{
+		"StringOut": pulumi.String("ok!").ToStringOutput(),
+	}
+
+	_, err := TryNew(vars, `result: {{.StringOut}`)
+	assert.True(t, errors.Is(err, ErrCompileError), "unexpected error %v", err)
+
+	var result string
+	err = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		out, err := TryNew(vars, `result: {{.StringOut}}`)
+		if err != nil {
+			return err
+		}
+		var wg sync.WaitGroup
+		wg.Add(1)
+		out.ApplyString(func(s string) string {
+			defer wg.Done()
+			result = s
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "result: ok!", result)
+}
+
+func TestTryNewRequired(t *testing.T) {
+	_, err := TryNew(map[string]interface{}{"A": "a"}, `{{.A}}{{.B}}`, Required("A", "B", "C"))
+	assert.True(t, errors.Is(err, ErrMissingVariable), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "B, C")
+}
+
+func TestTryNewOutputErrors(t *testing.T) {
+	tests := []struct {
+		testName      string
+		tplText       string
+		asJSON        bool
+		expectedError error
+	}{
+		{"invalid-reference", `result: {{.StringOut.Foo}}`, false, ErrExecuteError},
+		{"invalid-json", `result: {{.StringOut}}`, true, ErrInvalidJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				vars := map[string]interface{}{"StringOut": pulumi.String("ok!").ToStringOutput()}
+				try := TryNew
+				if tt.asJSON {
+					try = TryNewJSON
+				}
+				out, err := try(vars, tt.tplText)
+				if err != nil {
+					return err
+				}
+				ctx.Export("result", out)
+				return nil
+			}, pulumi.WithMocks("project", "stack", mocks(0)))
+			assert.True(t, errors.Is(err, tt.expectedError), "unexpected error %v", err)
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	var rec errorRecorder
+	set := NewSet(map[string]string{
+		"layout": `{{define "header"}}# {{.Title}}{{end}}{{define "footer"}}-- {{.Owner}}{{end}}`,
+		"readme": "{{template \"header\" .}}\nbody\n{{template \"footer\" .}}",
+		"json":   `{"title": "{{.Title}}"}`,
+	}, WithErrorHandler(rec.handle))
+
+	tests := []struct {
+		testName       string
+		name           string
+		asJSON         bool
+		expectedError  error
+		expectedResult string
+	}{
+		{testName: "readme", name: "readme", expectedResult: "# ok!\nbody\n-- normal"},
+		{testName: "define-block", name: "header", expectedResult: "# ok!"},
+		{testName: "json", name: "json", asJSON: true, expectedResult: `{"title": "ok!"}`},
+		{testName: "missing", name: "missing", expectedError: ErrExecuteError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			rec.handle(nil)
+			var result string
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				vars := map[string]interface{}{
+					"Title": pulumi.String("ok!").ToStringOutput(),
+					"Owner": "normal",
+				}
+				var out pulumi.StringOutput
+				if tt.asJSON {
+					out = set.ExecuteJSON(tt.name, vars)
+				} else {
+					out = set.Execute(tt.name, vars)
+				}
+				var wg sync.WaitGroup
+				wg.Add(1)
+				out.ApplyString(func(s string) string {
+					defer wg.Done()
+					result = s
+					return s
+				})
+				wg.Wait()
+				return nil
+			}, pulumi.WithMocks("project", "stack", mocks(0)))
+			assert.NoError(t, err)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(rec.get(), tt.expectedError), "unexpected error %v", rec.get())
+				return
+			}
+			assert.NoError(t, rec.get())
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
+
+func TestSetCompileError(t *testing.T) {
+	_, err := TryNewSet(map[string]string{"bad": `{{.Foo}`})
+	assert.True(t, errors.Is(err, ErrCompileError), "unexpected error %v", err)
+}
+
+type nestedVars struct {
+	Name  pulumi.StringOutput
+	Tags  map[string]interface{}
+	Plain string
+	inner pulumi.StringOutput
+}
+
+func TestNestedOutputs(t *testing.T) {
+	var result string
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		New(map[string]interface{}{
+			"Map":  map[string]interface{}{"x": pulumi.String("a").ToStringOutput()},
+			"List": []interface{}{pulumi.String("b").ToStringOutput(), "c"},
+			"Deep": map[string][]nestedVars{"k": {{Name: pulumi.String("d").ToStringOutput()}}},
+			"Struct": nestedVars{
+				Name:  pulumi.String("e").ToStringOutput(),
+				Tags:  map[string]interface{}{"t": pulumi.String("f").ToStringOutput()},
+				Plain: "g",
+			},
+			"Ptr":    &nestedVars{Name: pulumi.String("h").ToStringOutput()},
+			"Static": nestedVars{Plain: "i"},
+		}, `{{.Map.x}} {{index .List 0}} {{(index .Deep.k 0).Name}} {{.Struct.Name}} {{.Struct.Tags.t}} {{.Struct.Plain}} {{.Ptr.Name}} {{.Static.Plain}}`).ApplyString(func(s string) string {
+			defer wg.Done()
+			result = s
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "a b d e f g h i", result)
+}
+
+func TestStringSlices(t *testing.T) {
+	var result string
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		New(map[string]interface{}{
+			"Subnets": []pulumi.StringOutput{pulumi.String("a").ToStringOutput(), pulumi.String("b").ToStringOutput()},
+			"Zones":   pulumi.StringArray{pulumi.String("c"), pulumi.String("d")}.ToStringArrayOutput(),
+			"Inputs":  []pulumi.StringInput{pulumi.String("e"), pulumi.String("f").ToStringOutput()},
+		}, `{{range .Subnets}}{{.}},{{end}} {{range .Zones}}{{.}},{{end}} {{printf "%T" .Inputs}}`).ApplyString(func(s string) string {
+			defer wg.Done()
+			result = s
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b, c,d, []string", result)
+}
+
+// secretMocks records the inputs of each resource registered.
+type secretMocks struct {
+	inputs chan resource.PropertyMap
+}
+
+func (m secretMocks) NewResource(typeToken, name string, inputs resource.PropertyMap, provider, id string) (string, resource.PropertyMap, error) {
+	m.inputs <- inputs
+	return name + "_id", inputs, nil
+}
+
+func (secretMocks) Call(token string, args resource.PropertyMap, provider string) (resource.PropertyMap, error) {
+	return args, nil
+}
+
+type testResource struct {
+	pulumi.CustomResourceState
+}
+
+func TestSecrets(t *testing.T) {
+	tests := []struct {
+		testName string
+		vars     map[string]interface{}
+		opts     []Opt
+		secret   bool
+	}{
+		{"plain", map[string]interface{}{"V": pulumi.String("v").ToStringOutput()}, nil, false},
+		{"secret-var", map[string]interface{}{"V": pulumi.ToSecret(pulumi.String("pw")).(pulumi.StringOutput)}, nil, true},
+		{"nested-secret-var", map[string]interface{}{"V": map[string]interface{}{"pw": pulumi.ToSecret(pulumi.String("pw"))}}, nil, true},
+		{"as-secret", map[string]interface{}{"V": "v"}, []Opt{AsSecret()}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			m := secretMocks{inputs: make(chan resource.PropertyMap, 1)}
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				out := New(tt.vars, `{{.V}}`, tt.opts...)
+				var r testResource
+				return ctx.RegisterResource("test:index:Resource", "r", pulumi.Map{"value": out}, &r)
+			}, pulumi.WithMocks("project", "stack", m))
+			assert.NoError(t, err)
+			inputs := <-m.inputs
+			assert.Equal(t, tt.secret, inputs["value"].IsSecret())
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.conf":  {Data: []byte(`name={{.StringOut}}`)},
+		"config/partial":   {Data: []byte(`{{define "x"}}unused{{end}}`)},
+		"scripts/start.sh": {Data: []byte("#!/bin/sh\necho {{.NormalString}}\n")},
+	}
+	var assets map[string]interface{}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		NewArchive(map[string]interface{}{
+			"StringOut":    pulumi.String("ok!").ToStringOutput(),
+			"NormalString": "normal",
+		}, fsys).ApplyT(func(a pulumi.Archive) int {
+			defer wg.Done()
+			assets = a.Assets()
+			return 0
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+
+	texts := make(map[string]string)
+	for name, a := range assets {
+		texts[name] = a.(pulumi.Asset).Text()
+	}
+	assert.Equal(t, map[string]string{
+		"config/app.conf":  "name=ok!",
+		"config/partial":   "",
+		"scripts/start.sh": "#!/bin/sh\necho normal\n",
+	}, texts)
+}
+
+func TestArchiveCompileError(t *testing.T) {
+	_, err := TryNewArchive(nil, fstest.MapFS{"bad.tpl": {Data: []byte(`{{.Foo}`)}})
+	assert.True(t, errors.Is(err, ErrCompileError), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "bad.tpl")
+}
+
+func TestUserData(t *testing.T) {
+	tests := []struct {
+		testName      string
+		tplText       string
+		opts          []Opt
+		raw           bool
+		expectedError error
+		expected      string
+	}{
+		{testName: "gzip", tplText: "#!/bin/sh\necho {{.V}}\n", expected: "#!/bin/sh\necho ok!\n"},
+		{testName: "no-gzip", tplText: "#!/bin/sh\necho {{.V}}\n", opts: []Opt{NoGzip()}, raw: true, expected: "#!/bin/sh\necho ok!\n"},
+		{testName: "cloud-config", tplText: "#cloud-config\nhostname: {{.V}}\n", opts: []Opt{ValidateCloudConfig()}, expected: "#cloud-config\nhostname: ok!\n"},
+		{testName: "cloud-config-header", tplText: "hostname: {{.V}}\n", opts: []Opt{ValidateCloudConfig()}, expectedError: ErrInvalidCloudConfig},
+		{testName: "cloud-config-yaml", tplText: "#cloud-config\nhostname: [{{.V}}\n", opts: []Opt{ValidateCloudConfig()}, expectedError: ErrInvalidCloudConfig},
+		{testName: "too-large", tplText: strings.Repeat("x", MaxUserDataSize+1), opts: []Opt{NoGzip()}, expectedError: ErrUserDataTooLarge},
+		{testName: "compressed-fits", tplText: strings.Repeat("x", MaxUserDataSize+1), expected: strings.Repeat("x", MaxUserDataSize+1)},
+		{testName: "encoded-too-large", tplText: strings.Repeat("x", MaxUserDataSize), opts: []Opt{NoGzip(), WithMaxSize(MaxLaunchTemplateUserDataSize)}, expectedError: ErrOutputTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			var rec errorRecorder
+			opts := append([]Opt{WithErrorHandler(rec.handle)}, tt.opts...)
+			var result string
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				var wg sync.WaitGroup
+				wg.Add(1)
+				UserData(map[string]interface{}{"V": pulumi.String("ok!").ToStringOutput()}, tt.tplText, opts...).ApplyString(func(s string) string {
+					defer wg.Done()
+					result = s
+					return s
+				})
+				wg.Wait()
+				return nil
+			}, pulumi.WithMocks("project", "stack", mocks(0)))
+			assert.NoError(t, err)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(rec.get(), tt.expectedError), "unexpected error %v", rec.get())
+				return
+			}
+			assert.NoError(t, rec.get())
+
+			data, err := base64.StdEncoding.DecodeString(result)
+			assert.NoError(t, err)
+			if !tt.raw {
+				zr, err := gzip.NewReader(bytes.NewReader(data))
+				assert.NoError(t, err)
+				data, err = io.ReadAll(zr)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestJSONMap(t *testing.T) {
+	tests := []struct {
+		testName      string
+		tplText       string
+		expectedError error
+		expected      map[string]interface{}
+	}{
+		{
+			testName: "object",
+			tplText:  `{"name": "{{.V}}", "count": 2, "tags": {"a": ["b"]}}`,
+			expected: map[string]interface{}{"name": "ok!", "count": 2.0, "tags": map[string]interface{}{"a": []interface{}{"b"}}},
+		},
+		{testName: "array", tplText: `["{{.V}}"]`, expectedError: ErrInvalidJSON},
+		{testName: "invalid", tplText: `{"name": {{.V}}}`, expectedError: ErrInvalidJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			var rec errorRecorder
+			var result map[string]interface{}
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				out := NewJSONMap(map[string]interface{}{"V": pulumi.String("ok!").ToStringOutput()}, tt.tplText,
+					WithErrorHandler(rec.handle))
+				if tt.expectedError != nil {
+					// the output will be rejected, so wait for it via an export
+					ctx.Export("result", out)
+					return nil
+				}
+				var wg sync.WaitGroup
+				wg.Add(1)
+				out.ApplyT(func(m map[string]interface{}) int {
+					defer wg.Done()
+					result = m
+					return 0
+				})
+				wg.Wait()
+				return nil
+			}, pulumi.WithMocks("project", "stack", mocks(0)))
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError), "unexpected error %v", err)
+				assert.True(t, errors.Is(rec.get(), tt.expectedError), "unexpected error %v", rec.get())
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, rec.get())
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	var result string
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		vars := map[string]interface{}{"V": pulumi.String("ok!").ToStringOutput()}
+		NewJSONWithContext(context.Background(), vars, `{"v": "{{.V}}"}`).ApplyString(func(s string) string {
+			defer wg.Done()
+			result = s
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"v": "ok!"}`, result)
+}
+
+func TestWithContextCancelled(t *testing.T) {
+	cctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		out, _, _ := pulumi.NewOutput()
+		vars := map[string]interface{}{"V": out}
+		ctx.Export("result", NewWithContext(cctx, vars, `{{.V}}`))
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.True(t, errors.Is(err, context.Canceled), "unexpected error %v", err)
+}
+
+func TestPanicWithoutHandler(t *testing.T) {
+	errs := make(chan error, 1)
+	trap(errs, func() {
+		New(nil, `{{.Foo}`)
+	})
+	assert.True(t, errors.Is(<-errs, ErrCompileError))
+}
+
+func TestConcurrentErrorHandlers(t *testing.T) {
+	var okRec, badRec errorRecorder
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		vars := map[string]interface{}{"V": pulumi.String("ok!").ToStringOutput()}
+		for _, out := range []pulumi.StringOutput{
+			NewJSON(vars, `{"v": "{{.V}}"}`, WithErrorHandler(okRec.handle)),
+			NewJSON(vars, `{"v": {{.V}}}`, WithErrorHandler(badRec.handle)),
+		} {
+			out.ApplyString(func(s string) string {
+				defer wg.Done()
+				return s
+			})
+		}
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.NoError(t, okRec.get())
+	assert.True(t, errors.Is(badRec.get(), ErrInvalidJSON))
+}
+
+func TestSprintf(t *testing.T) {
+	var result, noArgs string
+	var rec errorRecorder
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(3)
+		Sprintf(`{{index . 0}}:{{index . 1 | printf "%05d"}}`, pulumi.String("host").ToStringOutput(), 80).ApplyString(func(s string) string {
+			defer wg.Done()
+			result = s
+			return s
+		})
+		Sprintf(`static`).ApplyString(func(s string) string {
+			defer wg.Done()
+			noArgs = s
+			return s
+		})
+		SprintfWithOpts(`{{index . 5}}`, []Opt{WithErrorHandler(rec.handle)}, "a").ApplyString(func(s string) string {
+			defer wg.Done()
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "host:00080", result)
+	assert.Equal(t, "static", noArgs)
+	assert.True(t, errors.Is(rec.get(), ErrExecuteError), "unexpected error %v", rec.get())
+}
+
+func TestFileAsset(t *testing.T) {
+	dir := t.TempDir()
+	var path, hash string
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		asset, sum := NewFileAsset(map[string]interface{}{
+			"name": pulumi.String("world").ToStringOutput(),
+		}, `hello {{.name}}`, WithOutputDir(dir))
+		asset.ApplyT(func(a pulumi.Asset) pulumi.Asset {
+			defer wg.Done()
+			path = a.Path()
+			return a
+		})
+		sum.ApplyString(func(s string) string {
+			defer wg.Done()
+			hash = s
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	// sha256 of "hello world"
+	expectedHash := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	assert.Equal(t, expectedHash, hash)
+	assert.Equal(t, dir+"/"+expectedHash, path)
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+}
+
+func TestWithConfig(t *testing.T) {
+	t.Setenv(pulumi.EnvConfig, `{"project:bucket": "my-bucket", "aws:region": "us-west-2"}`)
+	var result, override string
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		New(map[string]interface{}{
+			"env": pulumi.String("prod").ToStringOutput(),
+		}, `{{.env}}/{{.Config.bucket}}/{{index .Config "project:bucket"}}/{{index .Config "aws:region"}}`, WithConfig(ctx)).ApplyString(func(s string) string {
+			defer wg.Done()
+			result = s
+			return s
+		})
+		New(map[string]interface{}{
+			"Config": map[string]string{"bucket": "other"},
+		}, `{{.Config.bucket}}`, WithConfig(ctx)).ApplyString(func(s string) string {
+			defer wg.Done()
+			override = s
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "prod/my-bucket/my-bucket/us-west-2", result)
+	assert.Equal(t, "other", override)
+}
+
+func TestStackInfo(t *testing.T) {
+	t.Setenv(envOrganization, "acme")
+	var result string
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		New(nil, `{{.Pulumi.Organization}}/{{.Pulumi.Project}}/{{.Pulumi.Stack}}`, WithConfig(ctx)).ApplyString(func(s string) string {
+			defer wg.Done()
+			result = s
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, "acme/project/stack", result)
+}
+
+func TestDebug(t *testing.T) {
+	var m sync.Mutex
+	var logs []string
+	record := func(c *config) {
+		c.debugLog = func(msg string) {
+			m.Lock()
+			defer m.Unlock()
+			logs = append(logs, msg)
+		}
+	}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		var wg sync.WaitGroup
+		wg.Add(3)
+		New(map[string]interface{}{
+			"host": pulumi.String("example.com").ToStringOutput(),
+			"port": 80,
+		}, `{{.host}}:{{.port}}`, record).ApplyString(func(s string) string {
+			defer wg.Done()
+			return s
+		})
+		New(map[string]interface{}{
+			"user":     "admin",
+			"password": pulumi.ToSecret(pulumi.String("hunter2")).(pulumi.StringOutput),
+		}, `{{.user}}:{{.password}}`, record).ApplyString(func(s string) string {
+			defer wg.Done()
+			return s
+		})
+		New(nil, `static`, WithDebug(ctx)).ApplyString(func(s string) string {
+			defer wg.Done()
+			return s
+		})
+		wg.Wait()
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+
+	all := strings.Join(logs, "\n")
+	assert.Contains(t, all, `host: "example.com"`)
+	assert.Contains(t, all, "port: 80")
+	assert.Contains(t, all, "rendered:\nexample.com:80")
+	assert.Contains(t, all, `user: "admin"`)
+	assert.Contains(t, all, "password: [secret]")
+	assert.Contains(t, all, "rendered: [secret]")
+	assert.NotContains(t, all, "hunter2")
+}
+
+func TestHeredoc(t *testing.T) {
+	tests := []struct {
+		name, text, expected string
+	}{
+		{"empty", "", ""},
+		{"no-indent", "a\nb", "a\nb"},
+		{"tabs", "\n\t\ta\n\t\t\tb\n\t", "a\n\tb\n"},
+		{"spaces", "    a\n      b\n", "a\n  b\n"},
+		{"blank-lines", "\n\ta\n  \n\tb", "a\n\nb"},
+		{"mixed", "\t a\n\t\tb", " a\n\tb"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Heredoc(test.text), test.name)
+	}
+}
+
+func TestStandardizeJSON(t *testing.T) {
+	tests := []struct {
+		name, input, expected string
+		err                   error
+	}{
+		{name: "strict", input: `{"a": [1, 2]}`, expected: `{"a": [1, 2]}`},
+		{name: "trailing-commas", input: `{"a": [1, 2, ], }`, expected: `{"a": [1, 2 ] }`},
+		{name: "line-comment", input: "{\"a\": 1 // one\n}", expected: "{\"a\": 1 \n}"},
+		{name: "block-comment", input: `[1, /* two */ 3]`, expected: `[1,  3]`},
+		{name: "comment-after-comma", input: "[1, // more\n]", expected: "[1 \n]"},
+		{name: "strings-untouched", input: `["a,]", "// b", "/* \" */"]`, expected: `["a,]", "// b", "/* \" */"]`},
+		{name: "unterminated-comment", input: `[1 /* two`, err: ErrInvalidJSON},
+		{name: "unterminated-string", input: `["a]`, err: ErrInvalidJSON},
+	}
+	for _, test := range tests {
+		result, err := standardizeJSON(test.input)
+		if test.err != nil {
+			assert.True(t, errors.Is(err, test.err), test.name)
+			continue
+		}
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, result, test.name)
+	}
+}
+
+func TestRewriteDollarPlaceholders(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"${Name}", "${.Name}"},
+		{"${Bucket.Arn}", "${.Bucket.Arn}"},
+		{"${AWS::Region}", `${index . "AWS::Region"}`},
+		{"${!Name}", `${"${Name}"}`},
+		{"${.Name}", "${.Name}"},
+		{"$Name {Name}", "$Name {Name}"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, rewriteDollarPlaceholders(test.input), test.input)
+	}
+}
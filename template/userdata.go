@@ -0,0 +1,132 @@
+package template
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v2/go/pulumi"
+	"gopkg.in/yaml.v3"
+)
+
+// Size limits that may be supplied to WithMaxSize.
+const (
+	// MaxUserDataSize is the maximum size, in bytes, of EC2 user data
+	// before it's base64 encoded, as supplied to ec2.Instance's UserData.
+	MaxUserDataSize = 16384
+
+	// MaxLaunchTemplateUserDataSize is the maximum size, in bytes, of the
+	// base64 encoded user data supplied to an ec2.LaunchTemplate, such as
+	// the output of UserData.
+	MaxLaunchTemplateUserDataSize = 16384
+)
+
+var (
+	// ErrInvalidCloudConfig is raised during panic if the output from a
+	// UserData template is validated as cloud-init configuration and isn't
+	// valid.
+	ErrInvalidCloudConfig = errors.New("template produced invalid cloud-config")
+
+	// ErrUserDataTooLarge is raised during panic if the output from a
+	// UserData template exceeds MaxUserDataSize once compressed.
+	ErrUserDataTooLarge = errors.New("user data exceeds maximum size")
+
+	// ErrOutputTooLarge is raised during panic if the output from a
+	// template exceeds the limit set by WithMaxSize.
+	ErrOutputTooLarge = errors.New("rendered template exceeds maximum size")
+)
+
+// ValidateCloudConfig causes UserData to check that the rendered template
+// is a cloud-init configuration: a YAML document beginning with the line
+// "#cloud-config".
+func ValidateCloudConfig() Opt {
+	return func(c *config) {
+		c.cloudConfig = true
+	}
+}
+
+// NoGzip causes UserData to base64 encode the rendered template without
+// first compressing it.
+func NoGzip() Opt {
+	return func(c *config) {
+		c.noGzip = true
+	}
+}
+
+// WithMaxSize limits the size of a template's final output to max bytes,
+// such as MaxUserDataSize or MaxLaunchTemplateUserDataSize, so that
+// oversized output fails when rendered rather than when the resource it's
+// supplied to is created.  The limit applies after any processing, so the
+// output of UserData is measured once base64 encoded.
+func WithMaxSize(max int) Opt {
+	return func(c *config) {
+		c.maxSize = max
+	}
+}
+
+func checkSize(max int) postProcessor {
+	return validator(func(result string) error {
+		if len(result) > max {
+			return fmt.Errorf("%w: %d bytes is %d bytes over the limit of %d bytes",
+				ErrOutputTooLarge, len(result), len(result)-max, max)
+		}
+		return nil
+	})
+}
+
+// UserData renders a template for use as the base64 encoded user data of
+// an ec2.Instance (via UserDataBase64) or ec2.LaunchTemplate.
+//
+// The rendered template is gzip compressed, unless the NoGzip option is
+// supplied, and base64 encoded.  UserData will panic with
+// ErrUserDataTooLarge if the result exceeds MaxUserDataSize before being
+// base64 encoded.
+func UserData(vars map[string]interface{}, templateText string, opts ...Opt) pulumi.StringOutput {
+	cfg := newConfig(opts)
+	var process []postProcessor
+	if cfg.cloudConfig {
+		process = append(process, validator(validateCloudConfig))
+	}
+	process = append(process, encodeUserData(!cfg.noGzip))
+	return renderTemplate(context.Background(), vars, templateText, chain(process...), opts)
+}
+
+func validateCloudConfig(result string) error {
+	if !strings.HasPrefix(result, "#cloud-config\n") && strings.TrimSpace(result) != "#cloud-config" {
+		return fmt.Errorf("%w: first line must be #cloud-config\n%s", ErrInvalidCloudConfig, result)
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal([]byte(result), &doc); err != nil {
+		return fmt.Errorf("%w: %v\n%s", ErrInvalidCloudConfig, err, result)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok && doc != nil {
+		return fmt.Errorf("%w: document must be a YAML mapping\n%s", ErrInvalidCloudConfig, result)
+	}
+	return nil
+}
+
+func encodeUserData(compress bool) postProcessor {
+	return func(result string) (string, error) {
+		data := []byte(result)
+		if compress {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if _, err := zw.Write(data); err != nil {
+				return "", err
+			}
+			if err := zw.Close(); err != nil {
+				return "", err
+			}
+			data = buf.Bytes()
+		}
+		if len(data) > MaxUserDataSize {
+			return "", fmt.Errorf("%w: %d bytes is %d bytes over the limit of %d bytes",
+				ErrUserDataTooLarge, len(data), len(data)-MaxUserDataSize, MaxUserDataSize)
+		}
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+}
@@ -0,0 +1,153 @@
+// Package testutil provides helpers for unit testing Pulumi programs and
+// components with mocks, replacing the WaitGroup and ApplyT boilerplate
+// otherwise needed to inspect the value of an output.
+//
+//	func TestBucketPolicy(t *testing.T) {
+//		testutil.Run(t, func(ctx *pulumi.Context) error {
+//			doc := buildPolicy(pulumi.String("my-bucket").ToStringOutput())
+//			assert.Contains(t, testutil.AwaitOutput[string](t, doc), "arn:aws:s3:::my-bucket")
+//			return nil
+//		})
+//	}
+package testutil
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/internals"
+)
+
+// DefaultTimeout bounds how long the Await functions wait for an output to
+// resolve, so that an output that never resolves fails the test rather
+// than hanging it.
+var DefaultTimeout = 30 * time.Second
+
+// Project and Stack are the names supplied to the Pulumi context by Run.
+const (
+	Project = "project"
+	Stack   = "stack"
+)
+
+// Mocks implements pulumi.MockResourceMonitor, returning a resource's
+// inputs as its outputs, and a function call's arguments as its result.
+// Each resource is assigned the ID "<name>_id".
+//
+// Resources and calls are recorded, so that tests can check their inputs.
+type Mocks struct {
+	m         sync.Mutex
+	resources []pulumi.MockResourceArgs
+	calls     []pulumi.MockCallArgs
+}
+
+// NewResource implements pulumi.MockResourceMonitor.
+func (mk *Mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	mk.m.Lock()
+	defer mk.m.Unlock()
+	mk.resources = append(mk.resources, args)
+	return args.Name + "_id", args.Inputs, nil
+}
+
+// Call implements pulumi.MockResourceMonitor.
+func (mk *Mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	mk.m.Lock()
+	defer mk.m.Unlock()
+	mk.calls = append(mk.calls, args)
+	return args.Args, nil
+}
+
+// Resources returns the arguments of each resource registered so far.
+func (mk *Mocks) Resources() []pulumi.MockResourceArgs {
+	mk.m.Lock()
+	defer mk.m.Unlock()
+	return append([]pulumi.MockResourceArgs(nil), mk.resources...)
+}
+
+// Resource returns the arguments of the resource registered with name,
+// and whether there is one.
+func (mk *Mocks) Resource(name string) (pulumi.MockResourceArgs, bool) {
+	for _, r := range mk.Resources() {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return pulumi.MockResourceArgs{}, false
+}
+
+// Calls returns the arguments of each function call made so far.
+func (mk *Mocks) Calls() []pulumi.MockCallArgs {
+	mk.m.Lock()
+	defer mk.m.Unlock()
+	return append([]pulumi.MockCallArgs(nil), mk.calls...)
+}
+
+// Run runs f as a Pulumi program using a new Mocks, failing the test if
+// it returns an error.  The mocks are returned so that the resources the
+// program registered can be inspected.
+func Run(t testing.TB, f pulumi.RunFunc) *Mocks {
+	t.Helper()
+	mocks := &Mocks{}
+	if err := pulumi.RunErr(f, pulumi.WithMocks(Project, Stack, mocks)); err != nil {
+		t.Fatalf("pulumi program failed: %v", err)
+	}
+	return mocks
+}
+
+// AwaitOutput waits for o to resolve, returning its value, which must be
+// a T.  It must be called from within a Pulumi program, such as one run by
+// Run.  The test fails if the output resolves to an error, doesn't resolve
+// within DefaultTimeout, or isn't a T.
+//
+// Unknown values, as seen during previews, are returned as the zero value
+// of T.
+func AwaitOutput[T any](t testing.TB, o pulumi.Output) T {
+	t.Helper()
+	result := await(t, o)
+	if result.Value == nil {
+		var zero T
+		return zero
+	}
+	v, ok := result.Value.(T)
+	if !ok {
+		var zero T
+		t.Fatalf("output resolved to %T, expected %T", result.Value, zero)
+	}
+	return v
+}
+
+// AwaitErr waits for o to resolve, returning the error it resolved to, or
+// nil if it resolved successfully.
+func AwaitErr(t testing.TB, o pulumi.Output) error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	_, err := internals.UnsafeAwaitOutput(ctx, o)
+	if ctx.Err() != nil {
+		t.Fatalf("output did not resolve within %v", DefaultTimeout)
+	}
+	return err
+}
+
+// IsSecret waits for o to resolve, returning whether it's secret.
+func IsSecret(t testing.TB, o pulumi.Output) bool {
+	t.Helper()
+	return await(t, o).Secret
+}
+
+func await(t testing.TB, o pulumi.Output) internals.UnsafeAwaitOutputResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	result, err := internals.UnsafeAwaitOutput(ctx, o)
+	if ctx.Err() != nil {
+		t.Fatalf("output did not resolve within %v", DefaultTimeout)
+	}
+	if err != nil {
+		t.Fatalf("output resolved to an error: %v", err)
+	}
+	return result
+}
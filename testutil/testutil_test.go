@@ -0,0 +1,61 @@
+package testutil
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAwaitOutput(t *testing.T) {
+	Run(t, func(ctx *pulumi.Context) error {
+		assert.Equal(t, "value", AwaitOutput[string](t, pulumi.String("value").ToStringOutput()))
+		assert.Equal(t, 42, AwaitOutput[int](t, pulumi.Int(42).ToIntOutput()))
+		assert.Equal(t, []string{"a", "b"}, AwaitOutput[[]string](t, pulumi.ToStringArray([]string{"a", "b"}).ToStringArrayOutput()))
+		assert.Equal(t, "ab", AwaitOutput[string](t, pulumi.Sprintf("%s%s", "a", pulumi.String("b"))))
+		return nil
+	})
+}
+
+func TestAwaitErr(t *testing.T) {
+	errBad := errors.New("bad")
+	Run(t, func(ctx *pulumi.Context) error {
+		failed := pulumi.String("x").ToStringOutput().ApplyT(func(string) (string, error) {
+			return "", errBad
+		})
+		assert.True(t, errors.Is(AwaitErr(t, failed), errBad))
+		assert.NoError(t, AwaitErr(t, pulumi.String("ok").ToStringOutput()))
+		return nil
+	})
+}
+
+func TestIsSecret(t *testing.T) {
+	Run(t, func(ctx *pulumi.Context) error {
+		assert.True(t, IsSecret(t, pulumi.ToSecret(pulumi.String("x"))))
+		assert.False(t, IsSecret(t, pulumi.String("x").ToStringOutput()))
+		return nil
+	})
+}
+
+func TestMocks(t *testing.T) {
+	mocks := Run(t, func(ctx *pulumi.Context) error {
+		bucket, err := s3.NewBucket(ctx, "logs", &s3.BucketArgs{Acl: pulumi.String("private")})
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, pulumi.ID("logs_id"), AwaitOutput[pulumi.ID](t, bucket.ID()))
+		assert.Equal(t, "private", *AwaitOutput[*string](t, bucket.Acl))
+		return nil
+	})
+
+	r, ok := mocks.Resource("logs")
+	assert.True(t, ok)
+	assert.Equal(t, "aws:s3/bucket:Bucket", r.TypeToken)
+	assert.Equal(t, "private", r.Inputs["acl"].StringValue())
+	_, ok = mocks.Resource("missing")
+	assert.False(t, ok)
+	assert.Len(t, mocks.Resources(), 1)
+	assert.Empty(t, mocks.Calls())
+}
@@ -0,0 +1,208 @@
+// Package transform provides reusable resource transformations for
+// enforcing conventions across every resource in a stack, such as default
+// tags, approved regions and protection of stateful resources.
+//
+// The transformations are registered together as a single stack
+// transformation by Apply:
+//
+//	err := transform.Apply(ctx,
+//		transform.DefaultTags(tags.Defaults(ctx, tags.Owner("platform"))),
+//		transform.RestrictRegions("us-west-2", "us-east-1"),
+//		transform.ProtectStateful(),
+//		transform.Renamed(map[string]string{"web-logs": "logs"}),
+//	)
+//
+// Each transformation may also be supplied to individual resources or
+// components using pulumi.Transformations.
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/gwatts/pulutil/alias"
+	"github.com/gwatts/pulutil/tags"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrRegionNotAllowed is returned when registering a resource, or a
+// provider, that's configured for a region that isn't approved.
+var ErrRegionNotAllowed = errors.New("region not allowed")
+
+// StatefulTypes holds the resource types protected by ProtectStateful when
+// it's called without arguments.
+var StatefulTypes = []string{
+	"aws:docdb/cluster:Cluster",
+	"aws:dynamodb/table:Table",
+	"aws:efs/fileSystem:FileSystem",
+	"aws:elasticache/replicationGroup:ReplicationGroup",
+	"aws:kms/key:Key",
+	"aws:neptune/cluster:Cluster",
+	"aws:rds/cluster:Cluster",
+	"aws:rds/instance:Instance",
+	"aws:s3/bucket:Bucket",
+	"aws:s3/bucketV2:BucketV2",
+}
+
+var (
+	stringInputType    = reflect.TypeOf((*pulumi.StringInput)(nil)).Elem()
+	stringPtrInputType = reflect.TypeOf((*pulumi.StringPtrInput)(nil)).Elem()
+	boolPtrInputType   = reflect.TypeOf((*pulumi.BoolPtrInput)(nil)).Elem()
+)
+
+// Apply registers transforms as a single stack transformation, applied to
+// every resource in the stack in the order supplied.
+func Apply(ctx *pulumi.Context, transforms ...pulumi.ResourceTransformation) error {
+	return ctx.RegisterStackTransformation(Chain(transforms...))
+}
+
+// Chain combines transforms into a single transformation that applies
+// each in turn, passing the result of one to the next.
+func Chain(transforms ...pulumi.ResourceTransformation) pulumi.ResourceTransformation {
+	return func(args *pulumi.ResourceTransformationArgs) *pulumi.ResourceTransformationResult {
+		current := *args
+		var result *pulumi.ResourceTransformationResult
+		for _, transform := range transforms {
+			res := transform(&current)
+			if res == nil {
+				continue
+			}
+			current.Props, current.Opts = res.Props, res.Opts
+			result = res
+		}
+		if result == nil {
+			return nil
+		}
+		return &pulumi.ResourceTransformationResult{Props: current.Props, Opts: current.Opts}
+	}
+}
+
+// DefaultTags applies t to every AWS resource with a Tags argument, as
+// done by tags.AutoTag.
+//
+// Will panic if t.Validate returns an error.
+func DefaultTags(t tags.Tags) pulumi.ResourceTransformation {
+	if err := t.Validate(); err != nil {
+		panic(err)
+	}
+	return t.Transformation()
+}
+
+// RestrictRegions causes the registration of providers and resources with
+// a Region or Location argument to fail with ErrRegionNotAllowed unless
+// it's unset or set to one of regions.
+//
+// The default provider is configured outside of the program, so its
+// region, eg. aws:region, is not checked.
+func RestrictRegions(regions ...string) pulumi.ResourceTransformation {
+	allowed := make(map[string]bool, len(regions))
+	for _, r := range regions {
+		allowed[r] = true
+	}
+	check := func(args *pulumi.ResourceTransformationArgs, region string) error {
+		if !allowed[region] {
+			return fmt.Errorf("%s %s: %w: %q", args.Type, args.Name, ErrRegionNotAllowed, region)
+		}
+		return nil
+	}
+
+	return func(args *pulumi.ResourceTransformationArgs) *pulumi.ResourceTransformationResult {
+		props, ok := copyProps(args.Props)
+		if !ok {
+			return nil
+		}
+		changed := false
+		for _, name := range []string{"Region", "Location"} {
+			field := props.Elem().FieldByName(name)
+			if !field.IsValid() || field.IsNil() {
+				continue
+			}
+			switch field.Type() {
+			case stringInputType:
+				value := field.Interface().(pulumi.StringInput)
+				field.Set(reflect.ValueOf(value.ToStringOutput().ApplyT(func(region string) (string, error) {
+					return region, check(args, region)
+				}).(pulumi.StringOutput)))
+			case stringPtrInputType:
+				value := field.Interface().(pulumi.StringPtrInput)
+				field.Set(reflect.ValueOf(value.ToStringPtrOutput().ApplyT(func(region *string) (*string, error) {
+					if region == nil || *region == "" {
+						return region, nil
+					}
+					return region, check(args, *region)
+				}).(pulumi.StringPtrOutput)))
+			default:
+				continue
+			}
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		return &pulumi.ResourceTransformationResult{
+			Props: props.Interface().(pulumi.Input),
+			Opts:  args.Opts,
+		}
+	}
+}
+
+// ProtectStateful protects resources of the given types from deletion,
+// both by Pulumi, using the Protect resource option, and by the cloud
+// provider, by enabling deletion protection where the resource supports
+// it and the program doesn't set it explicitly.  StatefulTypes are
+// protected if no types are supplied.
+func ProtectStateful(types ...string) pulumi.ResourceTransformation {
+	if len(types) == 0 {
+		types = StatefulTypes
+	}
+	protected := make(map[string]bool, len(types))
+	for _, t := range types {
+		protected[t] = true
+	}
+
+	return func(args *pulumi.ResourceTransformationArgs) *pulumi.ResourceTransformationResult {
+		if !protected[args.Type] {
+			return nil
+		}
+		result := &pulumi.ResourceTransformationResult{
+			Props: args.Props,
+			Opts:  append(args.Opts[:len(args.Opts):len(args.Opts)], pulumi.Protect(true)),
+		}
+		props, ok := copyProps(args.Props)
+		if !ok {
+			return result
+		}
+		for _, name := range []string{"DeletionProtection", "DeletionProtectionEnabled"} {
+			field := props.Elem().FieldByName(name)
+			if field.IsValid() && field.Type() == boolPtrInputType && field.IsNil() {
+				field.Set(reflect.ValueOf(pulumi.Bool(true)))
+				result.Props = props.Interface().(pulumi.Input)
+			}
+		}
+		return result
+	}
+}
+
+// Renamed adds an alias to each resource whose name is a key of renames,
+// naming the resource by the corresponding value, so that resources
+// renamed in the program are updated rather than replaced.  See the alias
+// package for resources moved between components.
+func Renamed(renames map[string]string) pulumi.ResourceTransformation {
+	return alias.Renamed(renames).Transformation()
+}
+
+// copyProps returns a copy of the argument struct props points to, rather
+// than modifying the arguments supplied by the caller.
+func copyProps(props pulumi.Input) (reflect.Value, bool) {
+	if props == nil {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(props)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	return c, true
+}
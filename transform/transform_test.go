@@ -0,0 +1,83 @@
+package transform
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gwatts/pulutil/tags"
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/rds"
+	"github.com/pulumi/pulumi-aws/sdk/v5/go/aws/s3"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	mocks := testutil.Run(t, func(ctx *pulumi.Context) error {
+		if err := Apply(ctx,
+			DefaultTags(tags.New(tags.Owner("platform"))),
+			ProtectStateful(),
+			Renamed(map[string]string{"web-logs": "logs"}),
+		); err != nil {
+			return err
+		}
+		if _, err := s3.NewBucket(ctx, "web-logs", &s3.BucketArgs{
+			Tags: pulumi.StringMap{"Purpose": pulumi.String("logs")},
+		}); err != nil {
+			return err
+		}
+		if _, err := rds.NewInstance(ctx, "db", &rds.InstanceArgs{InstanceClass: pulumi.String("db.t3.micro")}); err != nil {
+			return err
+		}
+		_, err := rds.NewInstance(ctx, "scratch", &rds.InstanceArgs{
+			InstanceClass:      pulumi.String("db.t3.micro"),
+			DeletionProtection: pulumi.Bool(false),
+		})
+		return err
+	})
+
+	bucket, ok := mocks.Resource("web-logs")
+	assert.True(t, ok)
+	assert.Equal(t, "platform", bucket.Inputs["tags"].ObjectValue()["Owner"].StringValue())
+	assert.Equal(t, "logs", bucket.Inputs["tags"].ObjectValue()["Purpose"].StringValue())
+	assert.True(t, bucket.RegisterRPC.Protect)
+	assert.Equal(t, []string{"urn:pulumi:stack::project::aws:s3/bucket:Bucket::logs"}, bucket.RegisterRPC.AliasURNs)
+
+	db, _ := mocks.Resource("db")
+	assert.True(t, db.RegisterRPC.Protect)
+	assert.True(t, db.Inputs["deletionProtection"].BoolValue())
+
+	scratch, _ := mocks.Resource("scratch")
+	assert.True(t, scratch.RegisterRPC.Protect)
+	assert.False(t, scratch.Inputs["deletionProtection"].BoolValue(), "explicit setting should be kept")
+}
+
+func TestRestrictRegions(t *testing.T) {
+	mocks := testutil.Run(t, func(ctx *pulumi.Context) error {
+		if err := Apply(ctx, RestrictRegions("us-west-2")); err != nil {
+			return err
+		}
+		if _, err := aws.NewProvider(ctx, "west", &aws.ProviderArgs{Region: pulumi.String("us-west-2")}); err != nil {
+			return err
+		}
+		_, err := aws.NewProvider(ctx, "default", &aws.ProviderArgs{})
+		return err
+	})
+	assert.Len(t, mocks.Resources(), 2)
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		if err := Apply(ctx, RestrictRegions("us-west-2")); err != nil {
+			return err
+		}
+		_, err := aws.NewProvider(ctx, "europe", &aws.ProviderArgs{Region: pulumi.String("eu-west-1")})
+		return err
+	}, pulumi.WithMocks(testutil.Project, testutil.Stack, &testutil.Mocks{}))
+	assert.True(t, errors.Is(err, ErrRegionNotAllowed), "unexpected error %v", err)
+}
+
+func TestChain(t *testing.T) {
+	assert.Nil(t, Chain()(&pulumi.ResourceTransformationArgs{Type: "aws:s3/bucket:Bucket", Name: "b"}))
+	assert.Nil(t, Renamed(nil)(&pulumi.ResourceTransformationArgs{Name: "b"}))
+	assert.Panics(t, func() { DefaultTags(tags.New(tags.Tag("aws:reserved", "x"))) })
+}
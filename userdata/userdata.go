@@ -0,0 +1,264 @@
+// Package userdata assembles multi-part MIME user data for cloud-init,
+// combining cloud-config documents and shell scripts whose content may
+// contain Pulumi outputs.  It complements template.UserData, which renders
+// a single document.
+//
+//	ud := userdata.New(
+//		userdata.CloudConfig(cloudConfig),
+//		userdata.ShellScript(pulumi.Sprintf("#!/bin/sh\necho %s > /etc/cluster\n", cluster.Name)),
+//	)
+//	ec2.NewLaunchTemplate(ctx, "web", &ec2.LaunchTemplateArgs{
+//		UserData: ud.ToStringOutput(),
+//	})
+//
+// The document is gzip compressed and base64 encoded by ToStringOutput,
+// and its size is checked against the limit imposed by EC2.
+package userdata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// MaxSize is the maximum size, in bytes, of EC2 user data before it's
+// base64 encoded.
+const MaxSize = 16384
+
+// MIME content types understood by cloud-init.
+const (
+	CloudConfigType = "text/cloud-config"
+	ShellScriptType = "text/x-shellscript"
+	BoothookType    = "text/cloud-boothook"
+	IncludeURLType  = "text/x-include-url"
+)
+
+// Package errors returned during validation and rendering.
+var (
+	ErrNoParts  = errors.New("user data has no parts")
+	ErrTooLarge = errors.New("user data exceeds maximum size")
+)
+
+// Part is a single part of a multi-part document.
+type Part struct {
+	ContentType string
+	Filename    string
+	Content     interface{}
+}
+
+// Document holds the parts of a multi-part user data document.
+type Document struct {
+	Parts   []Part
+	noGzip  bool
+	maxSize int
+}
+
+// Opt is implemented by functions that can be passed to New.
+type Opt func(*Document)
+
+// New creates a new Document.  Parts are included in the order they're
+// supplied, which is the order in which cloud-init processes them.
+func New(opts ...Opt) *Document {
+	d := &Document{maxSize: MaxSize}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// AddPart adds a part with the given MIME content type and content, which
+// may be a string or StringInput.  The filename is shown in cloud-init's
+// logs, and is generated if empty.
+func AddPart(contentType, filename string, content interface{}) Opt {
+	return func(d *Document) {
+		switch content.(type) {
+		case string, pulumi.StringInput:
+		default:
+			panic(fmt.Sprintf("unexpected type passed to AddPart: %T: %#v", content, content))
+		}
+		if filename == "" {
+			filename = fmt.Sprintf("part-%03d", len(d.Parts)+1)
+		}
+		d.Parts = append(d.Parts, Part{ContentType: contentType, Filename: filename, Content: content})
+	}
+}
+
+// CloudConfig adds a cloud-config document.
+func CloudConfig(content interface{}) Opt { return AddPart(CloudConfigType, "", content) }
+
+// ShellScript adds a script to be run on first boot.
+func ShellScript(content interface{}) Opt { return AddPart(ShellScriptType, "", content) }
+
+// NoGzip causes ToStringOutput to base64 encode the document without
+// first compressing it.
+func NoGzip() Opt {
+	return func(d *Document) {
+		d.noGzip = true
+	}
+}
+
+// WithMaxSize overrides the maximum size of the document, as supplied to
+// EC2 before it's base64 encoded.
+func WithMaxSize(max int) Opt {
+	return func(d *Document) {
+		d.maxSize = max
+	}
+}
+
+// Validate performs a basic structural check of the document.
+func (d *Document) Validate() error {
+	if len(d.Parts) == 0 {
+		return ErrNoParts
+	}
+	return nil
+}
+
+// ToStringOutput renders the document, gzip compressed unless NoGzip was
+// supplied, and base64 encoded, for use as the UserData of an
+// ec2.LaunchTemplate or the UserDataBase64 of an ec2.Instance.
+//
+// Will panic if Validate returns an error.  If the document exceeds the
+// maximum size the output resolves to an error wrapping ErrTooLarge.
+func (d *Document) ToStringOutput() pulumi.StringOutput {
+	return d.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext renders the document as with ToStringOutput.
+func (d *Document) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	return d.render(ctx, func(doc []byte) (string, error) {
+		if !d.noGzip {
+			var err error
+			if doc, err = compress(doc); err != nil {
+				return "", err
+			}
+		}
+		if err := d.checkSize(doc); err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(doc), nil
+	})
+}
+
+// ToMIMEOutput renders the document as plain MIME text, for use as the
+// UserData of an ec2.Instance.
+//
+// Will panic if Validate returns an error.  If the document exceeds the
+// maximum size the output resolves to an error wrapping ErrTooLarge.
+func (d *Document) ToMIMEOutput() pulumi.StringOutput {
+	return d.render(context.Background(), func(doc []byte) (string, error) {
+		if err := d.checkSize(doc); err != nil {
+			return "", err
+		}
+		return string(doc), nil
+	})
+}
+
+func (d *Document) render(ctx context.Context, encode func([]byte) (string, error)) pulumi.StringOutput {
+	if err := d.Validate(); err != nil {
+		panic(err)
+	}
+	contents := make([]interface{}, len(d.Parts))
+	for i, p := range d.Parts {
+		contents[i] = p.Content
+	}
+	return pulumi.AllWithContext(ctx, contents...).ApplyTWithContext(ctx, func(_ context.Context, resolved []interface{}) (string, error) {
+		parts := make([]string, len(resolved))
+		for i, c := range resolved {
+			parts[i] = c.(string)
+		}
+		doc, err := d.build(parts)
+		if err != nil {
+			return "", err
+		}
+		return encode(doc)
+	}).(pulumi.StringOutput)
+}
+
+// build assembles the MIME document from the resolved content of each
+// part.
+func (d *Document) build(contents []string) ([]byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.SetBoundary(boundary(contents)); err != nil {
+		return nil, err
+	}
+	for i, content := range contents {
+		p := d.Parts[i]
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%q", p.ContentType, "us-ascii"))
+		h.Set("MIME-Version", "1.0")
+		h.Set("Content-Transfer-Encoding", "7bit")
+		h.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", p.Filename))
+		pw, err := w.CreatePart(h)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pw.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var doc bytes.Buffer
+	fmt.Fprintf(&doc, "Content-Type: multipart/mixed; boundary=%q\r\nMIME-Version: 1.0\r\n\r\n", w.Boundary())
+	doc.Write(body.Bytes())
+	return doc.Bytes(), nil
+}
+
+// boundary generates a MIME boundary derived from the content of the
+// parts, so that it's stable between deployments, that doesn't appear
+// within any of them.
+func boundary(contents []string) string {
+	h := sha256.New()
+	for _, c := range contents {
+		h.Write([]byte(c))
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	for n := 16; ; n += 8 {
+		b := "MIMEBOUNDARY-" + sum[:n]
+		if n >= len(sum) || !containsAny(contents, b) {
+			return b
+		}
+	}
+}
+
+func containsAny(contents []string, s string) bool {
+	for _, c := range contents {
+		if strings.Contains(c, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *Document) checkSize(data []byte) error {
+	if d.maxSize > 0 && len(data) > d.maxSize {
+		return fmt.Errorf("%w: %d bytes is %d bytes over the limit of %d bytes",
+			ErrTooLarge, len(data), len(data)-d.maxSize, d.maxSize)
+	}
+	return nil
+}
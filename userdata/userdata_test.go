@@ -0,0 +1,116 @@
+package userdata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/gwatts/pulutil/testutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type part struct {
+	contentType, filename, content string
+}
+
+// parseMIME parses a multi-part document into its parts.
+func parseMIME(t *testing.T, doc string) []part {
+	msg, err := mail.ReadMessage(strings.NewReader(doc))
+	assert.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+
+	var parts []part
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		content, err := io.ReadAll(p)
+		assert.NoError(t, err)
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts = append(parts, part{ct, p.FileName(), string(content)})
+	}
+	return parts
+}
+
+func TestToMIMEOutput(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		d := New(
+			CloudConfig("#cloud-config\npackages: [nginx]\n"),
+			ShellScript(pulumi.Sprintf("#!/bin/sh\necho %s\n", pulumi.String("cluster").ToStringOutput())),
+			AddPart(BoothookType, "hook.sh", pulumi.String("#cloud-boothook\ntrue\n")),
+		)
+		doc := testutil.AwaitOutput[string](t, d.ToMIMEOutput())
+		assert.Equal(t, []part{
+			{CloudConfigType, "part-001", "#cloud-config\npackages: [nginx]\n"},
+			{ShellScriptType, "part-002", "#!/bin/sh\necho cluster\n"},
+			{BoothookType, "hook.sh", "#cloud-boothook\ntrue\n"},
+		}, parseMIME(t, doc))
+		assert.Equal(t, doc, testutil.AwaitOutput[string](t, d.ToMIMEOutput()), "rendering should be deterministic")
+		return nil
+	})
+}
+
+func TestToStringOutput(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		d := New(ShellScript("#!/bin/sh\ntrue\n"))
+		encoded := testutil.AwaitOutput[string](t, d.ToStringOutput())
+		compressed, err := base64.StdEncoding.DecodeString(encoded)
+		assert.NoError(t, err)
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		assert.NoError(t, err)
+		doc, err := io.ReadAll(zr)
+		assert.NoError(t, err)
+		assert.Equal(t, []part{{ShellScriptType, "part-001", "#!/bin/sh\ntrue\n"}}, parseMIME(t, string(doc)))
+
+		plain := testutil.AwaitOutput[string](t, New(ShellScript("#!/bin/sh\ntrue\n"), NoGzip()).ToStringOutput())
+		decoded, err := base64.StdEncoding.DecodeString(plain)
+		assert.NoError(t, err)
+		assert.Equal(t, string(doc), string(decoded))
+		return nil
+	})
+}
+
+func TestSize(t *testing.T) {
+	testutil.Run(t, func(ctx *pulumi.Context) error {
+		big := strings.Repeat("x", MaxSize)
+		err := testutil.AwaitErr(t, New(ShellScript(big), NoGzip()).ToStringOutput())
+		assert.True(t, errors.Is(err, ErrTooLarge), "unexpected error %v", err)
+
+		// compresses to fit
+		assert.NoError(t, testutil.AwaitErr(t, New(ShellScript(big)).ToStringOutput()))
+
+		err = testutil.AwaitErr(t, New(ShellScript("#!/bin/sh\n"), WithMaxSize(10)).ToMIMEOutput())
+		assert.True(t, errors.Is(err, ErrTooLarge), "unexpected error %v", err)
+		return nil
+	})
+}
+
+func TestBoundary(t *testing.T) {
+	contents := []string{"a", "b"}
+	b := boundary(contents)
+	assert.Equal(t, b, boundary(contents))
+	assert.NotEqual(t, b, boundary([]string{"a", "c"}))
+
+	clash := []string{"a", "b", b}
+	assert.False(t, containsAny(clash, boundary(clash)))
+}
+
+func TestValidate(t *testing.T) {
+	assert.True(t, errors.Is(New().Validate(), ErrNoParts))
+	assert.Panics(t, func() { New().ToStringOutput() })
+	assert.Panics(t, func() { New(CloudConfig(42)) })
+}
@@ -0,0 +1,251 @@
+// Package vaultpolicy provides a helper for generating HashiCorp Vault
+// policies from Pulumi outputs, such as the paths of secret engine mounts
+// created earlier in the stack.
+//
+//	p := vaultpolicy.New(
+//		vaultpolicy.Path(vaultpolicy.Join(kv.Path, "data", "app/*"),
+//			vaultpolicy.Capabilities(vaultpolicy.Read, vaultpolicy.List),
+//		),
+//		vaultpolicy.Path("sys/leases/renew",
+//			vaultpolicy.Capabilities(vaultpolicy.Update),
+//		),
+//	)
+//	vault.NewPolicy(ctx, "app", &vault.PolicyArgs{Policy: p.ToStringOutput()})
+package vaultpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// ErrInvalidPolicy is returned if a policy fails validation.
+var ErrInvalidPolicy = errors.New("invalid vault policy")
+
+// Capabilities that may be granted on a path.
+const (
+	Create    = "create"
+	Read      = "read"
+	Update    = "update"
+	Patch     = "patch"
+	Delete    = "delete"
+	List      = "list"
+	Sudo      = "sudo"
+	Deny      = "deny"
+	Subscribe = "subscribe"
+)
+
+var validCapabilities = map[string]bool{
+	Create: true, Read: true, Update: true, Patch: true, Delete: true,
+	List: true, Sudo: true, Deny: true, Subscribe: true,
+}
+
+// Policy defines a Vault policy made up of path blocks.
+type Policy struct {
+	Paths []PathBlock
+}
+
+// PathBlock defines the capabilities granted on a single path.
+type PathBlock struct {
+	Path               interface{}
+	Capabilities       []string
+	RequiredParameters []string
+	AllowedParameters  map[string][]string
+	DeniedParameters   map[string][]string
+}
+
+// Opt is implemented by functions that can be passed to New.
+type Opt func(*Policy)
+
+// New creates a new Vault policy.
+func New(opts ...Opt) *Policy {
+	p := &Policy{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// PathOpt is implemented by functions that can be passed to Path.
+type PathOpt func(*PathBlock)
+
+// Path adds a path block to the policy.  path may be a string or a
+// StringInput; use Join to build a path from several segments.
+func Path(path interface{}, opts ...PathOpt) Opt {
+	return func(p *Policy) {
+		b := PathBlock{Path: path}
+		for _, opt := range opts {
+			opt(&b)
+		}
+		p.Paths = append(p.Paths, b)
+	}
+}
+
+// Capabilities adds capabilities to a path block.
+func Capabilities(capability ...string) PathOpt {
+	return func(b *PathBlock) {
+		b.Capabilities = append(b.Capabilities, capability...)
+	}
+}
+
+// RequiredParameters lists parameters that must be supplied by a request.
+func RequiredParameters(param ...string) PathOpt {
+	return func(b *PathBlock) {
+		b.RequiredParameters = append(b.RequiredParameters, param...)
+	}
+}
+
+// AllowedParameter permits a parameter, optionally restricted to the
+// supplied values.
+func AllowedParameter(param string, values ...string) PathOpt {
+	return func(b *PathBlock) {
+		if b.AllowedParameters == nil {
+			b.AllowedParameters = make(map[string][]string)
+		}
+		b.AllowedParameters[param] = append(b.AllowedParameters[param], values...)
+	}
+}
+
+// DeniedParameter denies a parameter, optionally only when it has one of
+// the supplied values.
+func DeniedParameter(param string, values ...string) PathOpt {
+	return func(b *PathBlock) {
+		if b.DeniedParameters == nil {
+			b.DeniedParameters = make(map[string][]string)
+		}
+		b.DeniedParameters[param] = append(b.DeniedParameters[param], values...)
+	}
+}
+
+// Join joins path segments with a "/", returning a string if every
+// segment is a string, or a StringOutput if any are StringInputs.
+func Join(segment ...interface{}) interface{} {
+	var inputs []interface{}
+	static := true
+	for _, s := range segment {
+		switch v := s.(type) {
+		case string:
+			inputs = append(inputs, v)
+		case pulumi.StringInput:
+			static = false
+			inputs = append(inputs, v)
+		default:
+			panic(fmt.Sprintf("unexpected type passed to Join: %T: %#v", s, s))
+		}
+	}
+	if static {
+		parts := make([]string, len(inputs))
+		for i, s := range inputs {
+			parts[i] = s.(string)
+		}
+		return joinSegments(parts)
+	}
+	return pulumi.All(inputs...).ApplyT(func(args []interface{}) string {
+		parts := make([]string, len(args))
+		for i, s := range args {
+			parts[i] = s.(string)
+		}
+		return joinSegments(parts)
+	}).(pulumi.StringOutput)
+}
+
+func joinSegments(parts []string) string {
+	var out []string
+	for _, p := range parts {
+		if p = strings.Trim(p, "/"); p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, "/")
+}
+
+// Validate performs a basic structural check of the policy.
+func (p Policy) Validate() error {
+	if len(p.Paths) == 0 {
+		return fmt.Errorf("%w: policy has no paths", ErrInvalidPolicy)
+	}
+	for i, b := range p.Paths {
+		if s, ok := b.Path.(string); ok && s == "" {
+			return fmt.Errorf("%w: path block %d has an empty path", ErrInvalidPolicy, i)
+		}
+		if len(b.Capabilities) == 0 {
+			return fmt.Errorf("%w: path block %d has no capabilities", ErrInvalidPolicy, i)
+		}
+		for _, c := range b.Capabilities {
+			if !validCapabilities[c] {
+				return fmt.Errorf("%w: path block %d has unknown capability %q", ErrInvalidPolicy, i, c)
+			}
+		}
+	}
+	return nil
+}
+
+// ToStringOutput renders the policy as HCL.
+func (p Policy) ToStringOutput() pulumi.StringOutput {
+	return p.ToStringOutputWithContext(context.Background())
+}
+
+// ToStringOutputWithContext renders the policy as HCL.
+func (p Policy) ToStringOutputWithContext(ctx context.Context) pulumi.StringOutput {
+	if err := p.Validate(); err != nil {
+		panic(err)
+	}
+	paths := make([]interface{}, len(p.Paths))
+	for i, b := range p.Paths {
+		paths[i] = b.Path
+	}
+	return pulumi.All(paths...).ApplyTWithContext(ctx, func(_ context.Context, resolved []interface{}) (string, error) {
+		var out strings.Builder
+		for i, b := range p.Paths {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			path, ok := resolved[i].(string)
+			if !ok || path == "" {
+				return "", fmt.Errorf("%w: path block %d resolved to an invalid path", ErrInvalidPolicy, i)
+			}
+			writePath(&out, path, b)
+		}
+		return out.String(), nil
+	}).(pulumi.StringOutput)
+}
+
+func writePath(out *strings.Builder, path string, b PathBlock) {
+	fmt.Fprintf(out, "path %s {\n", strconv.Quote(path))
+	fmt.Fprintf(out, "  capabilities = %s\n", hclList(b.Capabilities))
+	if len(b.RequiredParameters) > 0 {
+		fmt.Fprintf(out, "  required_parameters = %s\n", hclList(b.RequiredParameters))
+	}
+	writeParams(out, "allowed_parameters", b.AllowedParameters)
+	writeParams(out, "denied_parameters", b.DeniedParameters)
+	out.WriteString("}\n")
+}
+
+func writeParams(out *strings.Builder, name string, params map[string][]string) {
+	if len(params) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(out, "  %s = {\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(out, "    %s = %s\n", strconv.Quote(k), hclList(params[k]))
+	}
+	out.WriteString("  }\n")
+}
+
+func hclList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
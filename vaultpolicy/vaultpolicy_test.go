@@ -0,0 +1,97 @@
+package vaultpolicy
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mocks int
+
+func (mocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (mocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return args.Args, nil
+}
+
+func TestHCL(t *testing.T) {
+	assert := assert.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	_ = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		mount := pulumi.String("kv-app/").ToStringOutput()
+		p := New(
+			Path(Join(mount, "data", "app/*"),
+				Capabilities(Read, List),
+			),
+			Path("secret/config",
+				Capabilities(Create, Update),
+				RequiredParameters("owner"),
+				AllowedParameter("owner", "alice", "bob"),
+				AllowedParameter("*"),
+				DeniedParameter("admin"),
+			),
+		)
+
+		expected := `path "kv-app/data/app/*" {
+  capabilities = ["read", "list"]
+}
+
+path "secret/config" {
+  capabilities = ["create", "update"]
+  required_parameters = ["owner"]
+  allowed_parameters = {
+    "*" = []
+    "owner" = ["alice", "bob"]
+  }
+  denied_parameters = {
+    "admin" = []
+  }
+}
+`
+		p.ToStringOutput().ApplyT(func(hcl string) int {
+			assert.Equal(expected, hcl)
+			wg.Done()
+			return 0
+		})
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	wg.Wait()
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, "kv/data/app", Join("kv/", "/data/", "app"))
+	assert.Equal(t, "kv/app", Join("kv", "", "app"))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *Policy
+		expected error
+	}{
+		{"ok", New(Path("secret/*", Capabilities(Read))), nil},
+		{"no-paths", New(), ErrInvalidPolicy},
+		{"empty-path", New(Path("", Capabilities(Read))), ErrInvalidPolicy},
+		{"no-capabilities", New(Path("secret/*")), ErrInvalidPolicy},
+		{"bad-capability", New(Path("secret/*", Capabilities("write"))), ErrInvalidPolicy},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Validate()
+			if test.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, test.expected), "unexpected error %v", err)
+			}
+		})
+	}
+}